@@ -5,6 +5,8 @@ import (
 	"database/sql"
 	"fmt"
 	"os"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/joho/godotenv"
@@ -24,6 +26,32 @@ type Config struct {
 	JWTSecret           string
 	ServerPort          string
 	WhatsAppServiceURL  string
+	AccessTokenTTL      time.Duration
+	RefreshTokenTTL     time.Duration
+	LoginMaxAttempts    int
+	LoginIPMaxAttempts  int
+	LoginAttemptWindow  time.Duration
+	LoginLockoutBase    time.Duration
+	LoginLockoutMax     time.Duration
+	PasswordResetTTL    time.Duration
+	PasswordResetTries  int
+	SMTPHost            string
+	SMTPPort            string
+	SMTPUsername        string
+	SMTPPassword        string
+	SMTPFrom            string
+	TwoFactorRoles      []string
+	InvitationTTL       time.Duration
+	RequireNIMWhitelist bool
+	RoleSchedulerEvery  time.Duration
+	UploadDir           string
+	FirstSemesterSKS    int
+	LetterheadName      string
+	LetterheadAddress   string
+	LetterheadContact   string
+	LetterheadCity      string
+	RetakePolicy        string
+	GradeAppealWindow   time.Duration
 }
 
 var AppConfig Config
@@ -45,10 +73,85 @@ func LoadConfig() error {
 		JWTSecret:          os.Getenv("JWT_SECRET"),
 		ServerPort:         os.Getenv("SERVER_PORT"),
 		WhatsAppServiceURL: os.Getenv("WHATSAPP_SERVICE_URL"),
+		AccessTokenTTL:     getDurationEnv("ACCESS_TOKEN_TTL", 15*time.Minute),
+		RefreshTokenTTL:    getDurationEnv("REFRESH_TOKEN_TTL", 30*24*time.Hour),
+		LoginMaxAttempts:   getIntEnv("LOGIN_MAX_ATTEMPTS", 5),
+		LoginIPMaxAttempts: getIntEnv("LOGIN_IP_MAX_ATTEMPTS", 20),
+		LoginAttemptWindow: getDurationEnv("LOGIN_ATTEMPT_WINDOW", 15*time.Minute),
+		LoginLockoutBase:   getDurationEnv("LOGIN_LOCKOUT_BASE", 5*time.Minute),
+		LoginLockoutMax:    getDurationEnv("LOGIN_LOCKOUT_MAX", 24*time.Hour),
+		PasswordResetTTL:   getDurationEnv("PASSWORD_RESET_TTL", 10*time.Minute),
+		PasswordResetTries: getIntEnv("PASSWORD_RESET_MAX_ATTEMPTS", 5),
+		SMTPHost:           os.Getenv("SMTP_HOST"),
+		SMTPPort:           os.Getenv("SMTP_PORT"),
+		SMTPUsername:       os.Getenv("SMTP_USERNAME"),
+		SMTPPassword:       os.Getenv("SMTP_PASSWORD"),
+		SMTPFrom:           os.Getenv("SMTP_FROM"),
+		TwoFactorRoles:     getListEnv("TWO_FACTOR_REQUIRED_ROLES", []string{"kajur", "rektor"}),
+		InvitationTTL:      getDurationEnv("INVITATION_TTL", 72*time.Hour),
+		// Registrasi mahasiswa hanya untuk NIM yang sudah diimpor ke nim_whitelists
+		RequireNIMWhitelist: os.Getenv("REGISTRATION_REQUIRE_NIM_WHITELIST") == "true",
+		RoleSchedulerEvery:  getDurationEnv("ROLE_SCHEDULER_INTERVAL", 15*time.Minute),
+		UploadDir:           getEnv("UPLOAD_DIR", "uploads"),
+		// Batas SKS mahasiswa yang belum punya IPS semester sebelumnya
+		FirstSemesterSKS: getIntEnv("FIRST_SEMESTER_MAX_SKS", 20),
+		// Kop surat dokumen cetak (KRS/KHS)
+		LetterheadName:    getEnv("LETTERHEAD_NAME", "Universitas SIAku"),
+		LetterheadAddress: os.Getenv("LETTERHEAD_ADDRESS"),
+		LetterheadContact: os.Getenv("LETTERHEAD_CONTACT"),
+		LetterheadCity:    getEnv("LETTERHEAD_CITY", "Jakarta"),
+		// Nilai mata kuliah yang diulang yang dihitung di IPK: best atau latest
+		RetakePolicy: getEnv("RETAKE_GRADE_POLICY", "best"),
+		// Batas waktu sanggah nilai sejak lembar nilai dipublikasikan
+		GradeAppealWindow: getDurationEnv("GRADE_APPEAL_WINDOW", 7*24*time.Hour),
 	}
 	return nil
 }
 
+// getEnv membaca string dari env, pakai default jika kosong
+func getEnv(key, fallback string) string {
+	if value := os.Getenv(key); value != "" {
+		return value
+	}
+	return fallback
+}
+
+// getDurationEnv membaca durasi dari env (contoh: 15m, 720h), pakai default jika kosong/invalid
+func getDurationEnv(key string, fallback time.Duration) time.Duration {
+	if value := os.Getenv(key); value != "" {
+		if d, err := time.ParseDuration(value); err == nil && d > 0 {
+			return d
+		}
+	}
+	return fallback
+}
+
+// getListEnv membaca daftar dipisah koma dari env, pakai default jika variabel tidak di-set
+func getListEnv(key string, fallback []string) []string {
+	value, ok := os.LookupEnv(key)
+	if !ok {
+		return fallback
+	}
+
+	var list []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			list = append(list, item)
+		}
+	}
+	return list
+}
+
+// getIntEnv membaca angka positif dari env, pakai default jika kosong/invalid
+func getIntEnv(key string, fallback int) int {
+	if value := os.Getenv(key); value != "" {
+		if n, err := strconv.Atoi(value); err == nil && n > 0 {
+			return n
+		}
+	}
+	return fallback
+}
+
 // custom logger sederhana
 type customLogger struct{}
 
@@ -128,3 +231,116 @@ func InitDB() (*gorm.DB, error) {
 	DB = db
 	return db, nil
 }
+
+// LinkProfilesToUsers mengisi user_id profil dosen/kajur/rektor lama yang belum terhubung ke akun (dicocokkan lewat email)
+func LinkProfilesToUsers(db *gorm.DB) error {
+	tables := map[string]string{
+		"dosens":  "dosen",
+		"kajurs":  "kajur",
+		"rektors": "rektor",
+	}
+
+	for table, role := range tables {
+		err := db.Exec(fmt.Sprintf(`UPDATE %s SET user_id = users.id FROM users
+			WHERE %s.user_id IS NULL AND users.email = %s.email AND users.role = ?`, table, table, table), role).Error
+		if err != nil {
+			return fmt.Errorf("failed link %s to users: %w", table, err)
+		}
+	}
+
+	return nil
+}
+
+// MigrateKRSApprovalStatus memetakan status persetujuan KRS lama (pending/approved) ke state machine dua tahap
+// dan menyalin penyetuju lama ke kolom wali_approved_by/validated_by
+func MigrateKRSApprovalStatus(db *gorm.DB) error {
+	if err := db.Exec(`UPDATE krs SET approval_status = 'validated_kajur' WHERE approval_status = 'approved'`).Error; err != nil {
+		return fmt.Errorf("failed migrate approved KRS: %w", err)
+	}
+	if err := db.Exec(`UPDATE krs SET approval_status = 'submitted' WHERE approval_status = 'pending'`).Error; err != nil {
+		return fmt.Errorf("failed migrate pending KRS: %w", err)
+	}
+
+	// Kolom approved_by lama berisi ID kajur (validasi kajur) atau ID dosen (persetujuan wali).
+	// Disalin ke kolom per tahap supaya KRS lama tetap punya nama penyetuju; baris yang sudah terisi dilewati.
+	if !db.Migrator().HasColumn("krs", "approved_by") {
+		return nil
+	}
+	err := db.Exec(`UPDATE krs SET validated_by = krs.approved_by, validated_at = COALESCE(krs.approved_at, krs.updated_at)
+		FROM mahasiswas m, kajurs kj
+		WHERE m.id = krs.mahasiswa_id AND kj.id = krs.approved_by AND kj.jurusan = m.jurusan
+		AND krs.approval_status = 'validated_kajur' AND krs.validated_by IS NULL AND krs.wali_approved_by IS NULL`).Error
+	if err != nil {
+		return fmt.Errorf("failed backfill KRS validator: %w", err)
+	}
+	err = db.Exec(`UPDATE krs SET wali_approved_by = krs.approved_by, wali_approved_at = COALESCE(krs.approved_at, krs.updated_at)
+		FROM dosens d
+		WHERE d.id = krs.approved_by
+		AND krs.approval_status = 'validated_kajur' AND krs.validated_by IS NULL AND krs.wali_approved_by IS NULL`).Error
+	if err != nil {
+		return fmt.Errorf("failed backfill KRS wali approver: %w", err)
+	}
+	return nil
+}
+
+// MigrateNilaiAttempts menautkan nilai lama ke KRS pada semester dan tahun ajaran yang sama
+func MigrateNilaiAttempts(db *gorm.DB) error {
+	err := db.Exec(`UPDATE nilais SET krs_id = krs.id FROM krs
+		WHERE nilais.krs_id IS NULL AND krs.mahasiswa_id = nilais.mahasiswa_id AND krs.course_id = nilais.course_id
+		AND krs.semester = nilais.semester AND krs.tahun_ajaran = nilais.tahun_ajaran`).Error
+	if err != nil {
+		return fmt.Errorf("failed link nilai to KRS: %w", err)
+	}
+	return nil
+}
+
+// SeedDefaultGradeScale membuat skala standar A/AB/B/BC/C/D/E (sama dengan konversi lama) jika belum ada skala,
+// lalu mencatat skala tersebut pada nilai lama yang belum punya skala
+func SeedDefaultGradeScale(db *gorm.DB) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		var count int64
+		if err := tx.Table("grade_scales").Count(&count).Error; err != nil {
+			return fmt.Errorf("failed count grade scales: %w", err)
+		}
+		if count == 0 {
+			var scaleID uint
+			if err := tx.Raw(`INSERT INTO grade_scales (nama, jurusan, angkatan_mulai, versi, created_at)
+				VALUES ('Skala Standar', '', 0, 1, NOW()) RETURNING id`).Scan(&scaleID).Error; err != nil {
+				return fmt.Errorf("failed seed grade scale: %w", err)
+			}
+			if err := tx.Exec(`INSERT INTO grade_scale_items (grade_scale_id, huruf, min_nilai, bobot) VALUES
+				(?, 'A', 85, 4.0), (?, 'AB', 80, 3.5), (?, 'B', 75, 3.0), (?, 'BC', 70, 2.5),
+				(?, 'C', 65, 2.0), (?, 'D', 50, 1.0), (?, 'E', 0, 0.0)`,
+				scaleID, scaleID, scaleID, scaleID, scaleID, scaleID, scaleID).Error; err != nil {
+				return fmt.Errorf("failed seed grade scale items: %w", err)
+			}
+		}
+
+		err := tx.Exec(`UPDATE nilais SET grade_scale_id = (
+				SELECT id FROM grade_scales WHERE jurusan = '' AND angkatan_mulai = 0 ORDER BY versi ASC LIMIT 1)
+			WHERE grade_scale_id IS NULL AND status = 'sudah_dinilai'`).Error
+		if err != nil {
+			return fmt.Errorf("failed backfill nilai grade scale: %w", err)
+		}
+		return nil
+	})
+}
+
+// MigrateGradeSheets membuat lembar nilai berstatus published untuk kelas yang nilainya sudah terlihat mahasiswa
+// sebelum ada alur publikasi, sehingga nilai tersebut langsung terkunci
+func MigrateGradeSheets(db *gorm.DB) error {
+	err := db.Exec(`INSERT INTO grade_sheets (course_id, tahun_ajaran, kelas_id, jurusan, status, published_at, created_at, updated_at)
+		SELECT n.course_id, n.tahun_ajaran, COALESCE(k.kelas_id, 0), COALESCE(MAX(d.jurusan), ''), 'published', MAX(n.updated_at), NOW(), NOW()
+		FROM nilais n
+		JOIN courses c ON c.id = n.course_id
+		LEFT JOIN krs k ON k.id = n.krs_id
+		LEFT JOIN kelas kl ON kl.id = k.kelas_id
+		LEFT JOIN dosens d ON d.id = COALESCE(kl.dosen_id, c.dosen_id)
+		WHERE n.status = 'sudah_dinilai'
+		GROUP BY n.course_id, n.tahun_ajaran, COALESCE(k.kelas_id, 0)
+		ON CONFLICT DO NOTHING`).Error
+	if err != nil {
+		return fmt.Errorf("failed migrate grade sheets: %w", err)
+	}
+	return nil
+}
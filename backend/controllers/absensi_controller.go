@@ -2,12 +2,14 @@ package controllers
 
 import (
 	"SIAku/config"
+	"SIAku/middleware"
 	"SIAku/models"
 	"SIAku/utils"
 	"net/http"
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
 )
 
 type AbsensiController struct{}
@@ -18,7 +20,7 @@ func NewAbsensiController() *AbsensiController {
 
 // Input Absensi per Pertemuan
 func (ac *AbsensiController) InputAbsensiPertemuan(c *gin.Context) {
-	dosenID, _ := c.Get("user_id")
+	dosenID := middleware.GetPrincipal(c).DosenID
 	var req models.AbsensiPertemuanRequest
 
 	if err := c.ShouldBindJSON(&req); err != nil {
@@ -33,7 +35,7 @@ func (ac *AbsensiController) InputAbsensiPertemuan(c *gin.Context) {
 
 	// Verifikasi dosen mengajar mata kuliah ini
 	var course models.Course
-	if err := config.DB.Where("id = ? AND dosen_id = ?", req.CourseID, dosenID).First(&course).Error; err != nil {
+	if err := config.DB.Scopes(taughtBy(dosenID)).Where("courses.id = ?", req.CourseID).First(&course).Error; err != nil {
 		utils.ErrorResponse(c, http.StatusForbidden, "You are not authorized to input attendance for this course")
 		return
 	}
@@ -52,8 +54,8 @@ func (ac *AbsensiController) InputAbsensiPertemuan(c *gin.Context) {
 	for _, absensiInput := range req.Absensi {
 		// Verifikasi mahasiswa terdaftar di mata kuliah
 		var krs models.KRS
-		if err := config.DB.Where("course_id = ? AND mahasiswa_id = ? AND approval_status = 'approved'",
-			req.CourseID, absensiInput.MahasiswaID).First(&krs).Error; err != nil {
+		if err := config.DB.Where("course_id = ? AND mahasiswa_id = ? AND approval_status = ?",
+			req.CourseID, absensiInput.MahasiswaID, models.KRSValidatedKajur).First(&krs).Error; err != nil {
 			errors = append(errors, "Student ID "+string(rune(absensiInput.MahasiswaID))+" not enrolled in this course")
 			continue
 		}
@@ -71,17 +73,30 @@ func (ac *AbsensiController) InputAbsensiPertemuan(c *gin.Context) {
 				Status:      absensiInput.Status,
 				Keterangan:  absensiInput.Keterangan,
 			}
-			if err := config.DB.Create(&absensi).Error; err != nil {
+			err := config.DB.Transaction(func(tx *gorm.DB) error {
+				if err := tx.Create(&absensi).Error; err != nil {
+					return err
+				}
+				return middleware.RecordAudit(c, tx, "create", "absensi", absensi.ID, nil, absensi)
+			})
+			if err != nil {
 				errors = append(errors, "Failed to create attendance for student ID "+string(rune(absensiInput.MahasiswaID)))
 				continue
 			}
 		} else {
 			// Update absensi yang ada
+			before := absensi
 			absensi.Status = absensiInput.Status
 			absensi.Keterangan = absensiInput.Keterangan
 			absensi.Tanggal = tanggal
 
-			if err := config.DB.Save(&absensi).Error; err != nil {
+			err := config.DB.Transaction(func(tx *gorm.DB) error {
+				if err := tx.Save(&absensi).Error; err != nil {
+					return err
+				}
+				return middleware.RecordAudit(c, tx, "update", "absensi", absensi.ID, before, absensi)
+			})
+			if err != nil {
 				errors = append(errors, "Failed to update attendance for student ID "+string(rune(absensiInput.MahasiswaID)))
 				continue
 			}
@@ -111,13 +126,13 @@ func (ac *AbsensiController) InputAbsensiPertemuan(c *gin.Context) {
 
 // Get Absensi by Course and Pertemuan
 func (ac *AbsensiController) GetAbsensiByPertemuan(c *gin.Context) {
-	dosenID, _ := c.Get("user_id")
+	dosenID := middleware.GetPrincipal(c).DosenID
 	courseID := c.Param("courseId")
 	pertemuan := c.Query("pertemuan")
 
 	// Verifikasi dosen mengajar mata kuliah ini
 	var course models.Course
-	if err := config.DB.Where("id = ? AND dosen_id = ?", courseID, dosenID).First(&course).Error; err != nil {
+	if err := config.DB.Scopes(taughtBy(dosenID)).Where("courses.id = ?", courseID).First(&course).Error; err != nil {
 		utils.ErrorResponse(c, http.StatusForbidden, "You are not authorized to view attendance for this course")
 		return
 	}
@@ -165,19 +180,19 @@ func (ac *AbsensiController) GetAbsensiByPertemuan(c *gin.Context) {
 
 // Get Rekap Absensi by Course
 func (ac *AbsensiController) GetRekapAbsensi(c *gin.Context) {
-	dosenID, _ := c.Get("user_id")
+	dosenID := middleware.GetPrincipal(c).DosenID
 	courseID := c.Param("courseId")
 
 	// Verifikasi dosen mengajar mata kuliah ini
 	var course models.Course
-	if err := config.DB.Where("id = ? AND dosen_id = ?", courseID, dosenID).First(&course).Error; err != nil {
+	if err := config.DB.Scopes(taughtBy(dosenID)).Where("courses.id = ?", courseID).First(&course).Error; err != nil {
 		utils.ErrorResponse(c, http.StatusForbidden, "You are not authorized to view attendance for this course")
 		return
 	}
 
 	// Ambil semua mahasiswa yang terdaftar di mata kuliah
 	var krsList []models.KRS
-	if err := config.DB.Preload("Mahasiswa").Where("course_id = ? AND approval_status = 'approved'", courseID).Find(&krsList).Error; err != nil {
+	if err := config.DB.Preload("Mahasiswa").Where("course_id = ? AND approval_status = ?", courseID, models.KRSValidatedKajur).Find(&krsList).Error; err != nil {
 		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to fetch enrolled students")
 		return
 	}
@@ -0,0 +1,234 @@
+package controllers
+
+import (
+	"SIAku/config"
+	"SIAku/middleware"
+	"SIAku/models"
+	"SIAku/utils"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+type AcademicCalendarController struct{}
+
+func NewAcademicCalendarController() *AcademicCalendarController {
+	return &AcademicCalendarController{}
+}
+
+// GetCalendars - Kalender KRS beserta status jendela saat ini, filter tahun_ajaran
+func (ac *AcademicCalendarController) GetCalendars(c *gin.Context) {
+	query := config.DB.Order("tahun_ajaran DESC, periode ASC")
+	if tahunAjaran := c.Query("tahun_ajaran"); tahunAjaran != "" {
+		query = query.Where("tahun_ajaran = ?", tahunAjaran)
+	}
+
+	var calendars []models.AcademicCalendar
+	if err := query.Find(&calendars).Error; err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to fetch academic calendar")
+		return
+	}
+
+	now := time.Now()
+	responses := make([]gin.H, 0, len(calendars))
+	for _, calendar := range calendars {
+		responses = append(responses, gin.H{
+			"calendar": calendar,
+			"open": gin.H{
+				models.WindowKRS:      calendar.IsOpen(models.WindowKRS, now),
+				models.WindowApproval: calendar.IsOpen(models.WindowApproval, now),
+				models.WindowAddDrop:  calendar.IsOpen(models.WindowAddDrop, now),
+			},
+		})
+	}
+
+	utils.SuccessResponse(c, responses)
+}
+
+// SetCalendar - Rektor menetapkan atau mengubah kalender KRS satu tahun ajaran dan periode
+func (ac *AcademicCalendarController) SetCalendar(c *gin.Context) {
+	var req models.AcademicCalendarRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid JSON format")
+		return
+	}
+
+	if err := utils.ValidateStruct(req); err != nil {
+		utils.HandleValidationError(c, err)
+		return
+	}
+
+	parse := func(value string) time.Time {
+		date, _ := time.ParseInLocation("2006-01-02", value, time.Local)
+		return date
+	}
+	calendar := models.AcademicCalendar{
+		TahunAjaran:   req.TahunAjaran,
+		Periode:       req.Periode,
+		KRSStart:      parse(req.KRSStart),
+		KRSEnd:        parse(req.KRSEnd),
+		ApprovalStart: parse(req.ApprovalStart),
+		ApprovalEnd:   parse(req.ApprovalEnd),
+		AddDropStart:  parse(req.AddDropStart),
+		AddDropEnd:    parse(req.AddDropEnd),
+	}
+
+	if calendar.KRSEnd.Before(calendar.KRSStart) || calendar.ApprovalEnd.Before(calendar.ApprovalStart) || calendar.AddDropEnd.Before(calendar.AddDropStart) {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Tanggal akhir tidak boleh sebelum tanggal mulai")
+		return
+	}
+	if calendar.ApprovalStart.Before(calendar.KRSStart) || calendar.AddDropStart.Before(calendar.KRSEnd) {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Persetujuan dimulai setelah pengisian KRS dibuka, perubahan rencana studi setelah pengisian KRS ditutup")
+		return
+	}
+
+	userID := middleware.GetPrincipal(c).UserID
+	calendar.UpdatedBy = &userID
+
+	var existing models.AcademicCalendar
+	err := config.DB.Where("tahun_ajaran = ? AND periode = ?", req.TahunAjaran, req.Periode).First(&existing).Error
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to fetch academic calendar")
+		return
+	}
+
+	err = config.DB.Transaction(func(tx *gorm.DB) error {
+		if existing.ID == 0 {
+			if err := tx.Create(&calendar).Error; err != nil {
+				return err
+			}
+			return middleware.RecordAudit(c, tx, "create", "academic_calendar", calendar.ID, nil, calendar)
+		}
+
+		calendar.ID = existing.ID
+		calendar.CreatedAt = existing.CreatedAt
+		if err := tx.Save(&calendar).Error; err != nil {
+			return err
+		}
+		return middleware.RecordAudit(c, tx, "update", "academic_calendar", calendar.ID, existing, calendar)
+	})
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to save academic calendar")
+		return
+	}
+
+	utils.SuccessResponse(c, calendar)
+}
+
+// CreateOverride - Kajur membuka jendela KRS untuk satu mahasiswa di jurusannya sampai tanggal tertentu
+func (ac *AcademicCalendarController) CreateOverride(c *gin.Context) {
+	var req models.KRSWindowOverrideRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid JSON format")
+		return
+	}
+
+	if err := utils.ValidateStruct(req); err != nil {
+		utils.HandleValidationError(c, err)
+		return
+	}
+
+	principal := middleware.GetPrincipal(c)
+
+	var mahasiswa models.Mahasiswa
+	if err := config.DB.Where("id = ?", req.MahasiswaID).First(&mahasiswa).Error; err != nil {
+		utils.ErrorResponse(c, http.StatusNotFound, "Mahasiswa not found")
+		return
+	}
+	if mahasiswa.Jurusan != principal.Jurusan {
+		utils.ErrorResponse(c, http.StatusForbidden, "You can only grant overrides for students in your department")
+		return
+	}
+
+	// valid_until inklusif sampai akhir hari
+	validUntil, _ := time.ParseInLocation("2006-01-02", req.ValidUntil, time.Local)
+	validUntil = validUntil.AddDate(0, 0, 1)
+	if !validUntil.After(time.Now()) {
+		utils.ErrorResponse(c, http.StatusBadRequest, "valid_until must not be in the past")
+		return
+	}
+
+	override := models.KRSWindowOverride{
+		MahasiswaID: mahasiswa.ID,
+		TahunAjaran: req.TahunAjaran,
+		Periode:     req.Periode,
+		Window:      req.Window,
+		ValidUntil:  validUntil,
+		Reason:      req.Reason,
+		GrantedBy:   principal.KajurID,
+	}
+
+	err := config.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&override).Error; err != nil {
+			return err
+		}
+		return middleware.RecordAudit(c, tx, "grant_override", "krs_window_override", override.ID, nil, override)
+	})
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to create override")
+		return
+	}
+
+	utils.CreatedResponse(c, override)
+}
+
+// GetOverrides - Daftar override jendela KRS untuk mahasiswa di jurusan kajur
+func (ac *AcademicCalendarController) GetOverrides(c *gin.Context) {
+	query := config.DB.Preload("Mahasiswa").
+		Joins("JOIN mahasiswas ON mahasiswas.id = krs_window_overrides.mahasiswa_id").
+		Where("mahasiswas.jurusan = ?", middleware.GetPrincipal(c).Jurusan)
+	if tahunAjaran := c.Query("tahun_ajaran"); tahunAjaran != "" {
+		query = query.Where("krs_window_overrides.tahun_ajaran = ?", tahunAjaran)
+	}
+	if c.Query("active") == "true" {
+		query = query.Where("krs_window_overrides.revoked_at IS NULL AND krs_window_overrides.valid_until > ?", time.Now())
+	}
+
+	var overrides []models.KRSWindowOverride
+	if err := query.Order("krs_window_overrides.created_at DESC").Find(&overrides).Error; err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to fetch overrides")
+		return
+	}
+
+	utils.SuccessResponse(c, overrides)
+}
+
+// RevokeOverride - Cabut override yang masih berlaku
+func (ac *AcademicCalendarController) RevokeOverride(c *gin.Context) {
+	var override models.KRSWindowOverride
+	if err := config.DB.Preload("Mahasiswa").Where("id = ?", c.Param("id")).First(&override).Error; err != nil {
+		utils.ErrorResponse(c, http.StatusNotFound, "Override not found")
+		return
+	}
+	if override.Mahasiswa.Jurusan != middleware.GetPrincipal(c).Jurusan {
+		utils.ErrorResponse(c, http.StatusForbidden, "You can only revoke overrides in your department")
+		return
+	}
+
+	before := override
+	now := time.Now()
+	err := config.DB.Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&models.KRSWindowOverride{}).Where("id = ? AND revoked_at IS NULL", override.ID).Update("revoked_at", now)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return errOverrideRevoked
+		}
+		override.RevokedAt = &now
+		return middleware.RecordAudit(c, tx, "revoke_override", "krs_window_override", override.ID, before, override)
+	})
+	if errors.Is(err, errOverrideRevoked) {
+		utils.ErrorResponse(c, http.StatusConflict, "Override already revoked")
+		return
+	}
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to revoke override")
+		return
+	}
+
+	utils.SuccessResponse(c, gin.H{"message": "Override revoked"})
+}
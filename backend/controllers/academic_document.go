@@ -0,0 +1,240 @@
+package controllers
+
+import (
+	"SIAku/config"
+	"SIAku/models"
+	"SIAku/utils"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Tata letak dokumen cetak (KRS, KHS) di atas utils.PDF: kop surat, identitas, tabel dan tanda tangan
+
+const (
+	printMargin = 50.0
+	printBottom = utils.PDFPageHeight - 60
+	printRowH   = 16.0
+)
+
+var bulanIndonesia = [...]string{"Januari", "Februari", "Maret", "April", "Mei", "Juni",
+	"Juli", "Agustus", "September", "Oktober", "November", "Desember"}
+
+type printColumn struct {
+	title string
+	width float64
+	align byte // 'L', 'C' atau 'R'
+}
+
+type printSignature struct {
+	role string
+	name string
+	id   string // NIM/NIDN, kosong jika tidak ada
+}
+
+type printDocument struct {
+	pdf *utils.PDF
+	y   float64
+}
+
+// newPrintDocument membuat dokumen dengan halaman pertama dan kop surat
+func newPrintDocument(title string) *printDocument {
+	doc := &printDocument{pdf: utils.NewPDF(title)}
+	doc.newPage()
+	return doc
+}
+
+func (d *printDocument) newPage() {
+	d.pdf.AddPage()
+	d.y = printMargin
+	d.letterhead()
+}
+
+func (d *printDocument) letterhead() {
+	center := utils.PDFPageWidth / 2
+	d.pdf.TextCenter(center, d.y+10, 15, true, config.AppConfig.LetterheadName)
+	d.y += 26
+	for _, line := range []string{config.AppConfig.LetterheadAddress, config.AppConfig.LetterheadContact} {
+		if line != "" {
+			d.pdf.TextCenter(center, d.y, 9, false, line)
+			d.y += 12
+		}
+	}
+	d.pdf.Line(printMargin, d.y, utils.PDFPageWidth-printMargin, d.y, 1.5)
+	d.pdf.Line(printMargin, d.y+2.5, utils.PDFPageWidth-printMargin, d.y+2.5, 0.5)
+	d.y += 24
+}
+
+// ensureSpace pindah ke halaman baru jika sisa tinggi halaman kurang dari h
+func (d *printDocument) ensureSpace(h float64) bool {
+	if d.y+h <= printBottom {
+		return false
+	}
+	d.newPage()
+	return true
+}
+
+func (d *printDocument) heading(title, subtitle string) {
+	d.pdf.TextCenter(utils.PDFPageWidth/2, d.y, 13, true, title)
+	d.y += 16
+	if subtitle != "" {
+		d.pdf.TextCenter(utils.PDFPageWidth/2, d.y, 10, false, subtitle)
+		d.y += 14
+	}
+	d.y += 10
+}
+
+// identity menulis pasangan label: nilai dalam dua kolom
+func (d *printDocument) identity(left, right [][2]string) {
+	rows := len(left)
+	if len(right) > rows {
+		rows = len(right)
+	}
+	half := (utils.PDFPageWidth - 2*printMargin) / 2
+	for i := 0; i < rows; i++ {
+		for col, items := range [][][2]string{left, right} {
+			if i >= len(items) {
+				continue
+			}
+			x := printMargin + float64(col)*half
+			d.pdf.Text(x, d.y, 9.5, false, items[i][0])
+			d.pdf.Text(x+80, d.y, 9.5, false, ": "+utils.FitText(items[i][1], half-95, 9.5, false))
+		}
+		d.y += 14
+	}
+	d.y += 8
+}
+
+// table menulis tabel berbingkai; header diulang jika tabel berlanjut ke halaman berikutnya
+func (d *printDocument) table(columns []printColumn, rows [][]string) {
+	d.ensureSpace(printRowH * 3)
+	d.tableHeader(columns)
+	for _, row := range rows {
+		if d.ensureSpace(printRowH) {
+			d.tableHeader(columns)
+		}
+		d.tableRow(columns, row, false)
+	}
+}
+
+func (d *printDocument) tableHeader(columns []printColumn) {
+	width := 0.0
+	for _, col := range columns {
+		width += col.width
+	}
+	d.pdf.FillRect(printMargin, d.y, width, printRowH, 0.88)
+	titles := make([]string, len(columns))
+	for i, col := range columns {
+		titles[i] = col.title
+	}
+	d.tableRow(columns, titles, true)
+}
+
+func (d *printDocument) tableRow(columns []printColumn, cells []string, bold bool) {
+	x := printMargin
+	baseline := d.y + printRowH - 4.5
+	d.pdf.Line(x, d.y, x, d.y+printRowH, 0.5)
+	for i, col := range columns {
+		text := ""
+		if i < len(cells) {
+			text = utils.FitText(cells[i], col.width-8, 9, bold)
+		}
+		switch {
+		case bold || col.align == 'C':
+			d.pdf.TextCenter(x+col.width/2, baseline, 9, bold, text)
+		case col.align == 'R':
+			d.pdf.TextRight(x+col.width-4, baseline, 9, bold, text)
+		default:
+			d.pdf.Text(x+4, baseline, 9, bold, text)
+		}
+		x += col.width
+		d.pdf.Line(x, d.y, x, d.y+printRowH, 0.5)
+	}
+	d.pdf.Line(printMargin, d.y, x, d.y, 0.5)
+	d.pdf.Line(printMargin, d.y+printRowH, x, d.y+printRowH, 0.5)
+	d.y += printRowH
+}
+
+// summary menulis baris ringkasan (total SKS, IPS, IPK) di bawah tabel
+func (d *printDocument) summary(items [][2]string) {
+	d.y += 8
+	for _, item := range items {
+		d.ensureSpace(14)
+		d.pdf.Text(printMargin, d.y+10, 9.5, true, item[0])
+		d.pdf.Text(printMargin+130, d.y+10, 9.5, false, ": "+item[1])
+		d.y += 14
+	}
+	d.y += 10
+}
+
+// signatures menulis blok tanda tangan berjajar; tanggal cetak di atas blok paling kanan
+func (d *printDocument) signatures(blocks []printSignature, now time.Time) {
+	d.ensureSpace(110)
+	width := (utils.PDFPageWidth - 2*printMargin) / float64(len(blocks))
+	d.pdf.TextCenter(printMargin+width*(float64(len(blocks))-0.5), d.y+10, 9.5, false,
+		config.AppConfig.LetterheadCity+", "+formatTanggal(now))
+	d.y += 24
+	for i, block := range blocks {
+		center := printMargin + width*(float64(i)+0.5)
+		d.pdf.TextCenter(center, d.y, 9.5, false, block.role)
+		name := block.name
+		if name == "" {
+			name = "...................................."
+		}
+		d.pdf.TextCenter(center, d.y+62, 9.5, true, utils.FitText(name, width-10, 9.5, true))
+		if block.id != "" {
+			d.pdf.TextCenter(center, d.y+75, 9, false, block.id)
+		}
+	}
+	d.y += 90
+}
+
+// send mengirim PDF ke klien untuk ditampilkan/diunduh
+func (d *printDocument) send(c *gin.Context, filename string) {
+	data, err := d.pdf.Bytes()
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to generate PDF")
+		return
+	}
+	c.Header("Content-Disposition", fmt.Sprintf(`inline; filename="%s"`, filename))
+	c.Data(http.StatusOK, "application/pdf", data)
+}
+
+// formatTanggal menulis tanggal seperti "18 Oktober 2026"
+func formatTanggal(t time.Time) string {
+	return fmt.Sprintf("%d %s %d", t.Day(), bulanIndonesia[t.Month()-1], t.Year())
+}
+
+// formatIndeks menulis IPS/IPK dengan dua desimal, "-" jika belum ada nilai
+func formatIndeks(value float64, ok bool) string {
+	if !ok {
+		return "-"
+	}
+	return fmt.Sprintf("%.2f", value)
+}
+
+// semesterQuery membaca query semester, memakai fallback jika kosong; menulis 400 jika tidak valid
+func semesterQuery(c *gin.Context, fallback int) (int, bool) {
+	value := c.Query("semester")
+	if value == "" {
+		return fallback, true
+	}
+	semester, err := strconv.Atoi(value)
+	if err != nil || semester < 1 || semester > 14 {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid semester")
+		return 0, false
+	}
+	return semester, true
+}
+
+// activeKajur mengambil ketua jurusan aktif untuk blok tanda tangan; nil jika belum ada
+func activeKajur(jurusan string) *models.Kajur {
+	var kajur models.Kajur
+	if err := config.DB.Where("jurusan = ? AND status = ?", jurusan, "aktif").Order("id DESC").First(&kajur).Error; err != nil {
+		return nil
+	}
+	return &kajur
+}
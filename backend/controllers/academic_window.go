@@ -0,0 +1,87 @@
+package controllers
+
+import (
+	"SIAku/config"
+	"SIAku/models"
+	"SIAku/utils"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// Helper jendela kalender akademik untuk endpoint KRS, dosen wali dan kajur
+
+var errOverrideRevoked = errors.New("override already revoked")
+
+var windowLabels = map[string]string{
+	models.WindowKRS:      "pengisian KRS",
+	models.WindowApproval: "persetujuan KRS",
+	models.WindowAddDrop:  "perubahan rencana studi",
+}
+
+// openKRSWindow mengembalikan jendela pertama yang sedang terbuka untuk mahasiswa,
+// termasuk override kajur yang masih berlaku; string kosong jika semua tertutup
+func openKRSWindow(mahasiswaID uint, semester int, tahunAjaran string, windows ...string) (string, *models.AcademicCalendar, error) {
+	now := time.Now()
+	periode := models.PeriodeForSemester(semester)
+
+	var calendar models.AcademicCalendar
+	err := config.DB.Where("tahun_ajaran = ? AND periode = ?", tahunAjaran, periode).First(&calendar).Error
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return "", nil, err
+	}
+
+	var found *models.AcademicCalendar
+	if err == nil {
+		found = &calendar
+		for _, window := range windows {
+			if calendar.IsOpen(window, now) {
+				return window, found, nil
+			}
+		}
+	}
+
+	var override models.KRSWindowOverride
+	err = config.DB.Where("mahasiswa_id = ? AND tahun_ajaran = ? AND periode = ? AND window_name IN ? AND revoked_at IS NULL AND valid_until > ?",
+		mahasiswaID, tahunAjaran, periode, windows, now).
+		Order("valid_until DESC").First(&override).Error
+	if err == nil {
+		return override.Window, found, nil
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return "", found, err
+	}
+	return "", found, nil
+}
+
+// requireKRSWindow menulis response 403 jika tidak ada jendela yang terbuka
+func requireKRSWindow(c *gin.Context, mahasiswaID uint, semester int, tahunAjaran string, windows ...string) bool {
+	open, calendar, err := openKRSWindow(mahasiswaID, semester, tahunAjaran, windows...)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to check academic calendar")
+		return false
+	}
+	if open != "" {
+		return true
+	}
+
+	labels := make([]string, 0, len(windows))
+	for _, window := range windows {
+		labels = append(labels, windowLabels[window])
+	}
+	message := "Di luar jadwal " + strings.Join(labels, " / ") + " untuk tahun ajaran " + tahunAjaran + " periode " + models.PeriodeForSemester(semester)
+	if calendar == nil {
+		message = "Kalender akademik tahun ajaran " + tahunAjaran + " periode " + models.PeriodeForSemester(semester) + " belum ditetapkan"
+	}
+
+	c.JSON(http.StatusForbidden, gin.H{
+		"success":  false,
+		"error":    message,
+		"calendar": calendar,
+	})
+	return false
+}
@@ -0,0 +1,93 @@
+package controllers
+
+import (
+	"SIAku/config"
+	"SIAku/middleware"
+	"SIAku/models"
+	"SIAku/utils"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+type AuditController struct{}
+
+func NewAuditController() *AuditController {
+	return &AuditController{}
+}
+
+// GetAuditLogs - Riwayat perubahan data dengan filter entity, actor dan rentang tanggal (kajur hanya jurusannya)
+func (ac *AuditController) GetAuditLogs(c *gin.Context) {
+	principal := middleware.GetPrincipal(c)
+	page := utils.GetPageParam(c)
+	limit := utils.GetLimitParam(c)
+	entity := c.Query("entity")
+	entityID := c.Query("entity_id")
+	actorID := c.Query("actor_id")
+	actorRole := c.Query("actor_role")
+	action := c.Query("action")
+	requestID := c.Query("request_id")
+	offset := (page - 1) * limit
+
+	query := config.DB.Model(&models.AuditLog{})
+	if principal.Role == "kajur" {
+		query = query.Where("jurusan = ?", principal.Jurusan)
+	}
+
+	if entity != "" {
+		query = query.Where("entity = ?", entity)
+	}
+	if entityID != "" {
+		query = query.Where("entity_id = ?", entityID)
+	}
+	if actorID != "" {
+		query = query.Where("actor_id = ?", actorID)
+	}
+	if actorRole != "" {
+		query = query.Where("actor_role = ?", actorRole)
+	}
+	if action != "" {
+		query = query.Where("action = ?", action)
+	}
+	if requestID != "" {
+		query = query.Where("request_id = ?", requestID)
+	}
+
+	// Rentang tanggal format YYYY-MM-DD, "to" inklusif sampai akhir hari
+	if from := c.Query("from"); from != "" {
+		fromDate, err := time.Parse("2006-01-02", from)
+		if err != nil {
+			utils.ErrorResponse(c, http.StatusBadRequest, "Invalid from date format. Use YYYY-MM-DD")
+			return
+		}
+		query = query.Where("created_at >= ?", fromDate)
+	}
+	if to := c.Query("to"); to != "" {
+		toDate, err := time.Parse("2006-01-02", to)
+		if err != nil {
+			utils.ErrorResponse(c, http.StatusBadRequest, "Invalid to date format. Use YYYY-MM-DD")
+			return
+		}
+		query = query.Where("created_at < ?", toDate.AddDate(0, 0, 1))
+	}
+
+	var logs []models.AuditLog
+	var total int64
+
+	query.Count(&total)
+	if err := query.Order("created_at DESC").Offset(offset).Limit(limit).Find(&logs).Error; err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to fetch audit logs")
+		return
+	}
+
+	utils.SuccessResponse(c, gin.H{
+		"logs": logs,
+		"pagination": gin.H{
+			"page":        page,
+			"limit":       limit,
+			"total":       total,
+			"total_pages": (total + int64(limit) - 1) / int64(limit),
+		},
+	})
+}
@@ -5,11 +5,18 @@ import (
 	"SIAku/middleware"
 	"SIAku/models"
 	"SIAku/utils"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"log"
+	"math/big"
 	"net/http"
 	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
 )
 
 type AuthController struct{}
@@ -18,7 +25,7 @@ func NewAuthController() *AuthController {
 	return &AuthController{}
 }
 
-// Register - Universal registration for all roles
+// Register - Registrasi mandiri mahasiswa (akun staf dibuat lewat undangan)
 func (ac *AuthController) Register(c *gin.Context) {
 	var req models.UserRegistrationRequest
 
@@ -27,11 +34,32 @@ func (ac *AuthController) Register(c *gin.Context) {
 		return
 	}
 
+	// NIM whitelist disimpan tanpa spasi, jadi NIM input juga dirapikan sebelum dicocokkan
+	req.NIM = strings.TrimSpace(req.NIM)
 	if err := utils.ValidateStruct(req); err != nil {
 		utils.HandleValidationError(c, err)
 		return
 	}
 
+	jurusan := req.Jurusan
+	var whitelist models.NIMWhitelist
+	if config.AppConfig.RequireNIMWhitelist {
+		if err := config.DB.Where("nim = ?", req.NIM).First(&whitelist).Error; err != nil {
+			utils.ErrorResponse(c, http.StatusForbidden, "NIM tidak terdaftar, silakan hubungi bagian akademik")
+			return
+		}
+		if whitelist.RegisteredAt != nil {
+			utils.ErrorResponse(c, http.StatusConflict, "NIM sudah terdaftar, silakan gunakan NIM yang lain")
+			return
+		}
+		jurusan = whitelist.Jurusan
+	}
+
+	if jurusan == "" {
+		utils.ErrorResponse(c, http.StatusBadRequest, "NIM and Jurusan are required for mahasiswa")
+		return
+	}
+
 	// Hash password
 	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(req.Password), bcrypt.DefaultCost)
 	if err != nil {
@@ -52,141 +80,56 @@ func (ac *AuthController) Register(c *gin.Context) {
 		Username: req.Username,
 		Email:    req.Email,
 		Password: string(hashedPassword),
-		Role:     req.Role,
+		Role:     "mahasiswa",
 		Status:   "aktif",
 	}
 
 	if err := tx.Create(&user).Error; err != nil {
 		tx.Rollback()
-		errorMsg := err.Error()
-		if strings.Contains(errorMsg, "username") || strings.Contains(errorMsg, "uni_users_username") {
-			utils.ErrorResponse(c, http.StatusConflict, "Username sudah terdaftar, silakan gunakan username yang lain")
-			return
-		}
-		if strings.Contains(errorMsg, "email") || strings.Contains(errorMsg, "uni_users_email") {
-			utils.ErrorResponse(c, http.StatusConflict, "Email sudah terdaftar, silakan gunakan email yang lain")
-			return
-		}
-		utils.ErrorResponse(c, http.StatusInternalServerError, "Gagal membuat akun, silakan coba lagi")
+		respondUserCreateError(c, err)
 		return
 	}
 
-	// Create role-specific record
-	switch req.Role {
-	case "mahasiswa":
-		if req.NIM == "" || req.Jurusan == "" {
-			tx.Rollback()
-			utils.ErrorResponse(c, http.StatusBadRequest, "NIM and Jurusan are required for mahasiswa")
-			return
-		}
-
-		semester := req.Semester
-		if semester == 0 {
-			semester = 1
-		}
-
-		statusAkademik := req.StatusAkademik
-		if statusAkademik == "" {
-			statusAkademik = "aktif"
-		}
-
-		mahasiswa := models.Mahasiswa{
-			UserID:         &user.ID,
-			NIM:            req.NIM,
-			Nama:           req.Nama,
-			Jurusan:        req.Jurusan,
-			PhoneNumber:    req.PhoneNumber,
-			StatusAkademik: statusAkademik,
-			Semester:       semester,
-			IPK:            0.00,
-		}
-
-		if err := tx.Create(&mahasiswa).Error; err != nil {
-			tx.Rollback()
-			errorMsg := err.Error()
-			if strings.Contains(errorMsg, "nim") {
-				utils.ErrorResponse(c, http.StatusConflict, "NIM sudah terdaftar, silakan gunakan NIM yang lain")
-				return
-			}
-			utils.ErrorResponse(c, http.StatusInternalServerError, "Gagal membuat data mahasiswa")
-			return
-		}
-
-	case "dosen":
-		if req.NIDN == "" || req.Jurusan == "" {
-			tx.Rollback()
-			utils.ErrorResponse(c, http.StatusBadRequest, "NIDN and Jurusan are required for dosen")
-			return
-		}
-
-		dosen := models.Dosen{
-			NIDN:        req.NIDN,
-			Nama:        req.Nama,
-			Email:       req.Email,
-			PhoneNumber: req.PhoneNumber,
-			Jurusan:     req.Jurusan,
-			Status:      "aktif",
-		}
-
-		if err := tx.Create(&dosen).Error; err != nil {
-			tx.Rollback()
-			errorMsg := err.Error()
-			if strings.Contains(errorMsg, "nidn") {
-				utils.ErrorResponse(c, http.StatusConflict, "NIDN sudah terdaftar, silakan gunakan NIDN yang lain")
-				return
-			}
-			utils.ErrorResponse(c, http.StatusInternalServerError, "Gagal membuat data dosen")
-			return
-		}
-
-	case "kajur":
-		if req.NIDN == "" || req.Jurusan == "" {
-			tx.Rollback()
-			utils.ErrorResponse(c, http.StatusBadRequest, "NIDN and Jurusan are required for kajur")
-			return
-		}
+	semester := req.Semester
+	if semester == 0 {
+		semester = 1
+	}
 
-		kajur := models.Kajur{
-			NIDN:    req.NIDN,
-			Nama:    req.Nama,
-			Email:   req.Email,
-			Jurusan: req.Jurusan,
-			Status:  "aktif",
-		}
+	statusAkademik := req.StatusAkademik
+	if statusAkademik == "" {
+		statusAkademik = "aktif"
+	}
 
-		if err := tx.Create(&kajur).Error; err != nil {
-			tx.Rollback()
-			errorMsg := err.Error()
-			if strings.Contains(errorMsg, "nidn") {
-				utils.ErrorResponse(c, http.StatusConflict, "NIDN sudah terdaftar sebagai kajur, silakan gunakan NIDN yang lain")
-				return
-			}
-			utils.ErrorResponse(c, http.StatusInternalServerError, "Gagal membuat data kajur")
-			return
-		}
+	mahasiswa := models.Mahasiswa{
+		UserID:         &user.ID,
+		NIM:            req.NIM,
+		Nama:           req.Nama,
+		Jurusan:        jurusan,
+		PhoneNumber:    req.PhoneNumber,
+		StatusAkademik: statusAkademik,
+		Semester:       semester,
+		IPK:            0.00,
+	}
 
-	case "rektor":
-		if req.NIDN == "" {
-			tx.Rollback()
-			utils.ErrorResponse(c, http.StatusBadRequest, "NIDN is required for rektor")
+	if err := tx.Create(&mahasiswa).Error; err != nil {
+		tx.Rollback()
+		errorMsg := err.Error()
+		if strings.Contains(errorMsg, "nim") {
+			utils.ErrorResponse(c, http.StatusConflict, "NIM sudah terdaftar, silakan gunakan NIM yang lain")
 			return
 		}
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Gagal membuat data mahasiswa")
+		return
+	}
 
-		rektor := models.Rektor{
-			NIDN:   req.NIDN,
-			Nama:   req.Nama,
-			Email:  req.Email,
-			Status: "aktif",
-		}
-
-		if err := tx.Create(&rektor).Error; err != nil {
+	// Tandai NIM whitelist sudah dipakai (bersyarat supaya tidak bisa diklaim dua kali)
+	if config.AppConfig.RequireNIMWhitelist {
+		result := tx.Model(&models.NIMWhitelist{}).
+			Where("id = ? AND registered_at IS NULL", whitelist.ID).
+			Update("registered_at", time.Now())
+		if result.Error != nil || result.RowsAffected == 0 {
 			tx.Rollback()
-			errorMsg := err.Error()
-			if strings.Contains(errorMsg, "nidn") {
-				utils.ErrorResponse(c, http.StatusConflict, "NIDN sudah terdaftar sebagai rektor, silakan gunakan NIDN yang lain")
-				return
-			}
-			utils.ErrorResponse(c, http.StatusInternalServerError, "Gagal membuat data rektor")
+			utils.ErrorResponse(c, http.StatusConflict, "NIM sudah terdaftar, silakan gunakan NIM yang lain")
 			return
 		}
 	}
@@ -197,8 +140,8 @@ func (ac *AuthController) Register(c *gin.Context) {
 		return
 	}
 
-	// Generate JWT token
-	token, err := middleware.GenerateJWT(user.ID, user.Username)
+	// Generate access & refresh token
+	tokens, err := ac.issueTokens(c, user, "")
 	if err != nil {
 		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to generate token")
 		return
@@ -208,10 +151,12 @@ func (ac *AuthController) Register(c *gin.Context) {
 	response := ac.buildUserResponse(user)
 
 	c.JSON(http.StatusCreated, gin.H{
-		"success": true,
-		"message": "Registration successful",
-		"data":    response,
-		"token":   token,
+		"success":       true,
+		"message":       "Registration successful",
+		"data":          response,
+		"token":         tokens.AccessToken,
+		"refresh_token": tokens.RefreshToken,
+		"expires_in":    tokens.ExpiresIn,
 	})
 }
 
@@ -229,40 +174,249 @@ func (ac *AuthController) Login(c *gin.Context) {
 		return
 	}
 
+	identifier := strings.ToLower(strings.TrimSpace(req.Identifier))
+	clientIP := c.ClientIP()
+
+	// Batasi percobaan login gagal per IP
+	if loginIPBlocked(clientIP) {
+		utils.ErrorResponse(c, http.StatusTooManyRequests, "Terlalu banyak percobaan login dari IP ini, silakan coba lagi nanti")
+		return
+	}
+
+	// Batasi percobaan login gagal per identifier, baik akun terdaftar maupun tidak, supaya respons
+	// tidak membocorkan username/email mana yang terdaftar
+	if loginIdentifierBlocked(identifier) {
+		utils.ErrorResponse(c, http.StatusTooManyRequests, "Terlalu banyak percobaan login, silakan coba lagi nanti")
+		return
+	}
+
 	var user models.Users
 
 	// Find user by username or email
 	err := config.DB.Where("username = ? OR email = ?", req.Identifier, req.Identifier).First(&user).Error
 
 	if err != nil {
+		recordLoginAttempt(identifier, clientIP, nil, false)
 		utils.ErrorResponse(c, http.StatusUnauthorized, "Username atau email tidak ditemukan")
 		return
 	}
 
+	// Akun sedang terkunci
+	if user.LockedUntil != nil && user.LockedUntil.After(time.Now()) {
+		recordLoginAttempt(identifier, clientIP, &user.ID, false)
+		c.JSON(http.StatusLocked, gin.H{
+			"success":      false,
+			"error":        "Akun terkunci sementara karena terlalu banyak percobaan login gagal",
+			"locked_until": user.LockedUntil,
+		})
+		return
+	}
+
 	// Verify password
 	if err := bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(req.Password)); err != nil {
+		recordLoginAttempt(identifier, clientIP, &user.ID, false)
+		if lockedUntil := registerFailedLogin(&user, clientIP); lockedUntil != nil {
+			c.JSON(http.StatusLocked, gin.H{
+				"success":      false,
+				"error":        "Akun terkunci sementara karena terlalu banyak percobaan login gagal",
+				"locked_until": lockedUntil,
+			})
+			return
+		}
 		utils.ErrorResponse(c, http.StatusUnauthorized, "Invalid credentials")
 		return
 	}
 
-	// Generate JWT token
-	token, err := middleware.GenerateJWT(user.ID, user.Username)
+	if user.Status != "aktif" {
+		utils.ErrorResponse(c, http.StatusForbidden, "Akun tidak aktif, silakan hubungi admin")
+		return
+	}
+
+	// Akun dengan 2FA aktif harus menyelesaikan langkah kode TOTP dulu; counter gagal baru direset
+	// setelah kode benar supaya kegagalan TOTP tetap menambah backoff lockout
+	if user.TwoFactorEnabled {
+		challengeToken, err := middleware.GenerateTwoFactorChallenge(user.ID, req.DeviceName)
+		if err != nil {
+			utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to generate token")
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{
+			"success":             true,
+			"message":             "Masukkan kode dari aplikasi authenticator",
+			"two_factor_required": true,
+			"challenge_token":     challengeToken,
+		})
+		return
+	}
+
+	// Generate access & refresh token
+	tokens, err := ac.issueTokens(c, user, req.DeviceName)
 	if err != nil {
 		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to generate token")
 		return
 	}
 
+	recordLoginAttempt(identifier, clientIP, &user.ID, true)
+	resetFailedLogins(user)
+
 	// Get complete user data with details
 	response := ac.buildUserResponse(user)
 
+	c.JSON(http.StatusOK, gin.H{
+		"success":       true,
+		"message":       "Login successful",
+		"data":          response,
+		"token":         tokens.AccessToken,
+		"refresh_token": tokens.RefreshToken,
+		"expires_in":    tokens.ExpiresIn,
+	})
+}
+
+// RefreshToken - Tukar refresh token dengan access token baru (refresh token ikut dirotasi)
+func (ac *AuthController) RefreshToken(c *gin.Context) {
+	var req models.RefreshTokenRequest
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid JSON format")
+		return
+	}
+
+	if err := utils.ValidateStruct(req); err != nil {
+		utils.HandleValidationError(c, err)
+		return
+	}
+
+	session, refreshToken, err := middleware.RotateRefreshToken(req.RefreshToken, c.Request.UserAgent(), c.ClientIP())
+	if err != nil {
+		if errors.Is(err, middleware.ErrRefreshTokenReused) {
+			utils.ErrorResponse(c, http.StatusUnauthorized, "Refresh token sudah pernah dipakai, sesi dicabut. Silakan login ulang")
+			return
+		}
+		utils.ErrorResponse(c, http.StatusUnauthorized, "Refresh token tidak valid atau sudah kadaluarsa")
+		return
+	}
+
+	var user models.Users
+	if err := config.DB.Where("id = ?", session.UserID).First(&user).Error; err != nil {
+		utils.ErrorResponse(c, http.StatusUnauthorized, "User not found")
+		return
+	}
+
+	if user.Status != "aktif" {
+		middleware.RevokeUserSessions(user.ID, "account_inactive")
+		utils.ErrorResponse(c, http.StatusForbidden, "Akun tidak aktif, silakan hubungi admin")
+		return
+	}
+
+	token, err := middleware.GenerateJWT(user.ID, user.Username, user.Role, session.ID)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to generate token")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success":       true,
+		"message":       "Token refreshed",
+		"token":         token,
+		"refresh_token": refreshToken,
+		"expires_in":    int(config.AppConfig.AccessTokenTTL.Seconds()),
+	})
+}
+
+// Logout - Cabut sesi yang sedang dipakai
+func (ac *AuthController) Logout(c *gin.Context) {
+	if err := middleware.RevokeSession(c.GetUint("session_id"), "logout"); err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to logout")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Logout successful",
+	})
+}
+
+// LogoutAll - Cabut semua sesi milik user di semua device
+func (ac *AuthController) LogoutAll(c *gin.Context) {
+	if err := middleware.RevokeUserSessions(c.GetUint("user_id"), "logout_all"); err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to logout from all sessions")
+		return
+	}
+
 	c.JSON(http.StatusOK, gin.H{
 		"success": true,
-		"message": "Login successful",
-		"data":    response,
-		"token":   token,
+		"message": "Logged out from all sessions",
 	})
 }
 
+// GetSessions - Daftar sesi aktif milik user
+func (ac *AuthController) GetSessions(c *gin.Context) {
+	currentSessionID := c.GetUint("session_id")
+
+	var sessions []models.UserSession
+	if err := config.DB.Where("user_id = ? AND revoked_at IS NULL AND expires_at > ?", c.GetUint("user_id"), time.Now()).
+		Order("last_used_at DESC").Find(&sessions).Error; err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to fetch sessions")
+		return
+	}
+
+	var responses []models.SessionResponse
+	for _, session := range sessions {
+		responses = append(responses, models.SessionResponse{
+			ID:         session.ID,
+			DeviceName: session.DeviceName,
+			UserAgent:  session.UserAgent,
+			IPAddress:  session.IPAddress,
+			LastUsedAt: session.LastUsedAt,
+			ExpiresAt:  session.ExpiresAt,
+			Current:    session.ID == currentSessionID,
+			CreatedAt:  session.CreatedAt,
+		})
+	}
+
+	utils.SuccessResponse(c, responses)
+}
+
+// respondUserCreateError menerjemahkan error unique constraint tabel users
+func respondUserCreateError(c *gin.Context, err error) {
+	errorMsg := err.Error()
+	if strings.Contains(errorMsg, "username") || strings.Contains(errorMsg, "uni_users_username") {
+		utils.ErrorResponse(c, http.StatusConflict, "Username sudah terdaftar, silakan gunakan username yang lain")
+		return
+	}
+	if strings.Contains(errorMsg, "email") || strings.Contains(errorMsg, "uni_users_email") {
+		utils.ErrorResponse(c, http.StatusConflict, "Email sudah terdaftar, silakan gunakan email yang lain")
+		return
+	}
+	utils.ErrorResponse(c, http.StatusInternalServerError, "Gagal membuat akun, silakan coba lagi")
+}
+
+type issuedTokens struct {
+	AccessToken  string
+	RefreshToken string
+	ExpiresIn    int
+}
+
+// issueTokens membuat sesi baru lalu menerbitkan access token dan refresh token untuk sesi tersebut
+func (ac *AuthController) issueTokens(c *gin.Context, user models.Users, deviceName string) (issuedTokens, error) {
+	session, refreshToken, err := middleware.CreateSession(user.ID, deviceName, c.Request.UserAgent(), c.ClientIP())
+	if err != nil {
+		return issuedTokens{}, err
+	}
+
+	accessToken, err := middleware.GenerateJWT(user.ID, user.Username, user.Role, session.ID)
+	if err != nil {
+		return issuedTokens{}, err
+	}
+
+	return issuedTokens{
+		AccessToken:  accessToken,
+		RefreshToken: refreshToken,
+		ExpiresIn:    int(config.AppConfig.AccessTokenTTL.Seconds()),
+	}, nil
+}
+
 // GetProfile - Get user profile with role details
 func (ac *AuthController) GetProfile(c *gin.Context) {
 	userID, exists := c.Get("user_id")
@@ -301,24 +455,250 @@ func (ac *AuthController) buildUserResponse(user models.Users) models.UserRespon
 			response.RoleData = mahasiswa
 		}
 
-	case "dosen":
+	case "dosen", "dekan":
 		var dosen models.Dosen
-		if err := config.DB.Where("email = ?", user.Email).First(&dosen).Error; err == nil {
+		if err := config.DB.Where("user_id = ?", user.ID).First(&dosen).Error; err == nil {
 			response.RoleData = dosen
 		}
 
 	case "kajur":
 		var kajur models.Kajur
-		if err := config.DB.Where("email = ?", user.Email).First(&kajur).Error; err == nil {
+		if err := config.DB.Where("user_id = ?", user.ID).First(&kajur).Error; err == nil {
 			response.RoleData = kajur
 		}
 
 	case "rektor":
 		var rektor models.Rektor
-		if err := config.DB.Where("email = ?", user.Email).First(&rektor).Error; err == nil {
+		if err := config.DB.Where("user_id = ?", user.ID).First(&rektor).Error; err == nil {
 			response.RoleData = rektor
 		}
 	}
 
 	return response
 }
+
+// ForgotPassword - Kirim kode OTP reset password lewat WhatsApp (nomor terikat) atau email
+func (ac *AuthController) ForgotPassword(c *gin.Context) {
+	var req models.ForgotPasswordRequest
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid JSON format")
+		return
+	}
+
+	if err := utils.ValidateStruct(req); err != nil {
+		utils.HandleValidationError(c, err)
+		return
+	}
+
+	// Response selalu sama supaya tidak bisa dipakai untuk menebak akun yang terdaftar
+	genericResponse := func() {
+		c.JSON(http.StatusOK, gin.H{
+			"success": true,
+			"message": "Jika akun terdaftar, kode reset password telah dikirim",
+		})
+	}
+
+	user, err := findUserByIdentifier(req.Identifier)
+	if err != nil || user.Status != "aktif" {
+		genericResponse()
+		return
+	}
+
+	// Jangan kirim ulang kode dalam 1 menit
+	var recent int64
+	config.DB.Model(&models.PasswordReset{}).
+		Where("user_id = ? AND created_at > ?", user.ID, time.Now().Add(-time.Minute)).
+		Count(&recent)
+	if recent > 0 {
+		genericResponse()
+		return
+	}
+
+	destination := user.Email
+	if req.Channel == "whatsapp" {
+		var mahasiswa models.Mahasiswa
+		if err := config.DB.Where("user_id = ?", user.ID).First(&mahasiswa).Error; err != nil || mahasiswa.PhoneNumber == "" {
+			genericResponse()
+			return
+		}
+		destination = mahasiswa.PhoneNumber
+	}
+
+	code, err := generateOTP()
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to generate reset code")
+		return
+	}
+
+	codeHash, err := bcrypt.GenerateFromPassword([]byte(code), bcrypt.DefaultCost)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to generate reset code")
+		return
+	}
+
+	now := time.Now()
+	reset := models.PasswordReset{
+		UserID:      user.ID,
+		CodeHash:    string(codeHash),
+		Channel:     req.Channel,
+		Destination: destination,
+		ExpiresAt:   now.Add(config.AppConfig.PasswordResetTTL),
+		MaxAttempts: config.AppConfig.PasswordResetTries,
+		IPAddress:   c.ClientIP(),
+	}
+
+	err = config.DB.Transaction(func(tx *gorm.DB) error {
+		// Kode lama yang belum dipakai langsung tidak berlaku
+		if err := tx.Model(&models.PasswordReset{}).
+			Where("user_id = ? AND used_at IS NULL AND expires_at > ?", user.ID, now).
+			Update("expires_at", now).Error; err != nil {
+			return err
+		}
+		return tx.Create(&reset).Error
+	})
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to create reset code")
+		return
+	}
+
+	minutes := int(config.AppConfig.PasswordResetTTL.Minutes())
+	if req.Channel == "whatsapp" {
+		message := fmt.Sprintf("🔐 *Reset Password SIAku*\n\nKode reset password Anda: *%s*\n\nKode berlaku %d menit dan hanya bisa dipakai sekali.\nAbaikan pesan ini jika Anda tidak meminta reset password.", code, minutes)
+		err = utils.SendWhatsAppMessage(destination, message)
+	} else {
+		body := fmt.Sprintf("Kode reset password SIAku Anda: %s\n\nKode berlaku %d menit dan hanya bisa dipakai sekali.\nAbaikan email ini jika Anda tidak meminta reset password.", code, minutes)
+		err = utils.GetMailer().Send(destination, "Kode Reset Password SIAku", body)
+	}
+	if err != nil {
+		log.Printf("Failed to send password reset code to user %d via %s: %v", user.ID, req.Channel, err)
+	}
+
+	genericResponse()
+}
+
+// ResetPassword - Verifikasi kode OTP lalu ganti password dan cabut semua sesi
+func (ac *AuthController) ResetPassword(c *gin.Context) {
+	var req models.ResetPasswordRequest
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid JSON format")
+		return
+	}
+
+	if err := utils.ValidateStruct(req); err != nil {
+		utils.HandleValidationError(c, err)
+		return
+	}
+
+	user, err := findUserByIdentifier(req.Identifier)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Kode reset tidak valid atau sudah kadaluarsa")
+		return
+	}
+
+	var reset models.PasswordReset
+	if err := config.DB.Where("user_id = ? AND used_at IS NULL AND expires_at > ?", user.ID, time.Now()).
+		Order("created_at DESC").First(&reset).Error; err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Kode reset tidak valid atau sudah kadaluarsa")
+		return
+	}
+
+	// Klaim satu percobaan secara atomik sebelum bcrypt supaya request paralel tidak bisa melewati batas percobaan
+	claim := config.DB.Model(&models.PasswordReset{}).
+		Where("id = ? AND attempts < max_attempts", reset.ID).
+		UpdateColumn("attempts", gorm.Expr("attempts + 1"))
+	if claim.Error != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to verify reset code")
+		return
+	}
+	if claim.RowsAffected == 0 {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Kode reset sudah terlalu banyak dicoba, silakan minta kode baru")
+		return
+	}
+
+	if err := bcrypt.CompareHashAndPassword([]byte(reset.CodeHash), []byte(req.Code)); err != nil {
+		remaining := 0
+		if err := config.DB.Select("attempts", "max_attempts").Where("id = ?", reset.ID).First(&reset).Error; err == nil {
+			remaining = reset.MaxAttempts - reset.Attempts
+		}
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success":            false,
+			"error":              "Kode reset tidak valid",
+			"remaining_attempts": remaining,
+		})
+		return
+	}
+
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(req.NewPassword), bcrypt.DefaultCost)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to hash password")
+		return
+	}
+
+	err = config.DB.Transaction(func(tx *gorm.DB) error {
+		// Tandai kode terpakai secara bersyarat supaya hanya bisa dipakai sekali
+		result := tx.Model(&models.PasswordReset{}).
+			Where("id = ? AND used_at IS NULL", reset.ID).
+			Update("used_at", time.Now())
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return errResetCodeUsed
+		}
+
+		return tx.Model(&models.Users{}).Where("id = ?", user.ID).Updates(map[string]interface{}{
+			"password":           string(hashedPassword),
+			"failed_login_count": 0,
+			"lockout_count":      0,
+			"locked_until":       nil,
+		}).Error
+	})
+	if err != nil {
+		if errors.Is(err, errResetCodeUsed) {
+			utils.ErrorResponse(c, http.StatusBadRequest, "Kode reset tidak valid atau sudah kadaluarsa")
+			return
+		}
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to reset password")
+		return
+	}
+
+	// Semua sesi lama dicabut, user harus login ulang dengan password baru
+	if err := middleware.RevokeUserSessions(user.ID, "password_reset"); err != nil {
+		log.Printf("Failed to revoke sessions after password reset for user %d: %v", user.ID, err)
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Password berhasil direset, silakan login ulang",
+	})
+}
+
+var errResetCodeUsed = errors.New("reset code already used")
+
+// findUserByIdentifier mencari akun lewat username, email, atau NIM mahasiswa
+func findUserByIdentifier(identifier string) (models.Users, error) {
+	var user models.Users
+	err := config.DB.Where("username = ? OR email = ?", identifier, identifier).First(&user).Error
+	if err == nil {
+		return user, nil
+	}
+
+	var mahasiswa models.Mahasiswa
+	if err := config.DB.Where("nim = ? AND user_id IS NOT NULL", identifier).First(&mahasiswa).Error; err != nil {
+		return user, err
+	}
+
+	err = config.DB.Where("id = ?", *mahasiswa.UserID).First(&user).Error
+	return user, err
+}
+
+// generateOTP membuat kode 6 digit dari crypto/rand
+func generateOTP() (string, error) {
+	n, err := rand.Int(rand.Reader, big.NewInt(1000000))
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%06d", n.Int64()), nil
+}
@@ -2,6 +2,7 @@ package controllers
 
 import (
 	"SIAku/config"
+	"SIAku/middleware"
 	"SIAku/models"
 	"SIAku/utils"
 	"net/http"
@@ -76,11 +77,17 @@ func (cc *CourseController) CreateCourse(c *gin.Context) {
 		return
 	}
 
+	if req.Sifat == "" {
+		req.Sifat = models.CourseWajib
+	}
+
 	// Create course
 	course := models.Course{
-		Code:    req.Code,
-		Name:    req.Name,
-		Credits: req.Credits,
+		Code:     req.Code,
+		Name:     req.Name,
+		Credits:  req.Credits,
+		Semester: req.Semester,
+		Sifat:    req.Sifat,
 	}
 
 	if err := config.DB.Create(&course).Error; err != nil {
@@ -120,6 +127,10 @@ func (cc *CourseController) UpdateCourse(c *gin.Context) {
 	course.Code = req.Code
 	course.Name = req.Name
 	course.Credits = req.Credits
+	course.Semester = req.Semester
+	if req.Sifat != "" {
+		course.Sifat = req.Sifat
+	}
 
 	if err := config.DB.Save(&course).Error; err != nil {
 		if err.Error() == `pq: duplicate key value violates unique constraint "courses_code_key"` {
@@ -157,7 +168,7 @@ func (cc *CourseController) DeleteCourse(c *gin.Context) {
 // EnrollCourse - Enroll mahasiswa ke course (require auth)
 func (cc *CourseController) EnrollCourse(c *gin.Context) {
 	courseID := c.Param("id")
-	userID, _ := c.Get("user_id")
+	mahasiswaID := middleware.GetPrincipal(c).MahasiswaID
 
 	var course models.Course
 	if err := config.DB.Where("id = ?", courseID).First(&course).Error; err != nil {
@@ -166,7 +177,7 @@ func (cc *CourseController) EnrollCourse(c *gin.Context) {
 	}
 
 	var mahasiswa models.Mahasiswa
-	if err := config.DB.Where("id = ?", userID).First(&mahasiswa).Error; err != nil {
+	if err := config.DB.Where("id = ?", mahasiswaID).First(&mahasiswa).Error; err != nil {
 		utils.ErrorResponse(c, http.StatusNotFound, "Mahasiswa not found")
 		return
 	}
@@ -192,7 +203,7 @@ func (cc *CourseController) EnrollCourse(c *gin.Context) {
 // UnenrollCourse - Unenroll mahasiswa dari course (require auth)
 func (cc *CourseController) UnenrollCourse(c *gin.Context) {
 	courseID := c.Param("id")
-	userID, _ := c.Get("user_id")
+	mahasiswaID := middleware.GetPrincipal(c).MahasiswaID
 
 	var course models.Course
 	if err := config.DB.Where("id = ?", courseID).First(&course).Error; err != nil {
@@ -201,7 +212,7 @@ func (cc *CourseController) UnenrollCourse(c *gin.Context) {
 	}
 
 	var mahasiswa models.Mahasiswa
-	if err := config.DB.Where("id = ?", userID).First(&mahasiswa).Error; err != nil {
+	if err := config.DB.Where("id = ?", mahasiswaID).First(&mahasiswa).Error; err != nil {
 		utils.ErrorResponse(c, http.StatusNotFound, "Mahasiswa not found")
 		return
 	}
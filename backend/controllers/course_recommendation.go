@@ -0,0 +1,198 @@
+package controllers
+
+import (
+	"SIAku/config"
+	"SIAku/models"
+	"fmt"
+	"sort"
+)
+
+// Helper rekomendasi mata kuliah KRS. Seluruh data dimuat per jenis (mata kuliah, nilai, KRS, prasyarat,
+// jadwal, kelas, kuota SKS) sehingga jumlah query tetap, tidak bergantung pada banyaknya mata kuliah.
+
+// Bobot skor rekomendasi
+const (
+	scoreRetake          = 50 // mengulang mata kuliah yang tidak lulus
+	scoreMandatoryBehind = 40 // wajib dari semester sebelumnya yang belum diambil
+	scoreCurriculumWajib = 30 // wajib sesuai semester kurikulum
+	scoreCurriculumPilih = 10 // pilihan sesuai semester kurikulum
+	scoreElectiveBehind  = 5  // pilihan dari semester sebelumnya
+	scoreKelasFull       = -10
+)
+
+// recommendCourses menyusun rekomendasi mata kuliah untuk mahasiswa pada semester dan tahun ajaran tersebut
+func recommendCourses(mahasiswa models.Mahasiswa, semester int, tahunAjaran string) (models.KRSRecommendationResponse, error) {
+	result := models.KRSRecommendationResponse{Semester: semester, TahunAjaran: tahunAjaran}
+
+	var courses []models.Course
+	if err := config.DB.Where("semester <= ?", semester).Order("semester ASC, code ASC").Find(&courses).Error; err != nil {
+		return result, err
+	}
+
+	// Nilai terbaik per mata kuliah menentukan lulus atau perlu mengulang
+	var nilaiList []models.Nilai
+	if err := config.DB.Select("course_id", "grade_huruf", "grade_point").
+		Where("mahasiswa_id = ? AND status = ?", mahasiswa.ID, models.NilaiPublished).
+		Find(&nilaiList).Error; err != nil {
+		return result, err
+	}
+	best := map[uint]models.Nilai{}
+	for _, n := range nilaiList {
+		if current, ok := best[n.CourseID]; !ok || n.GradePoint > current.GradePoint {
+			best[n.CourseID] = n
+		}
+	}
+
+	// Mata kuliah yang sudah ada di KRS tahun ajaran ini tidak disarankan lagi
+	var inKRS []uint
+	if err := config.DB.Model(&models.KRS{}).
+		Where("mahasiswa_id = ? AND tahun_ajaran = ? AND approval_status <> ?", mahasiswa.ID, tahunAjaran, models.KRSRejected).
+		Pluck("course_id", &inKRS).Error; err != nil {
+		return result, err
+	}
+	taken := map[uint]bool{}
+	for _, id := range inKRS {
+		taken[id] = true
+	}
+
+	var candidates []models.Course
+	courseIDs := make([]uint, 0, len(courses))
+	for _, course := range courses {
+		achieved, graded := best[course.ID]
+		if taken[course.ID] || (graded && models.MeetsMinimumPoint(achieved.GradePoint, "D")) {
+			continue
+		}
+		candidates = append(candidates, course)
+		courseIDs = append(courseIDs, course.ID)
+	}
+
+	unmet, err := evaluatePrerequisites(config.DB, mahasiswa.ID, courseIDs)
+	if err != nil {
+		return result, err
+	}
+
+	var jadwal []models.Jadwal
+	if len(courseIDs) > 0 {
+		if err := config.DB.Where("tahun_ajaran = ? AND course_id IN ?", tahunAjaran, courseIDs).Find(&jadwal).Error; err != nil {
+			return result, err
+		}
+	}
+	clashes, err := findScheduleClashes(config.DB, mahasiswa.ID, tahunAjaran, jadwal)
+	if err != nil {
+		return result, err
+	}
+	courseClashes := map[uint][]models.ScheduleClash{}
+	kelasClashes := map[uint][]models.ScheduleClash{}
+	for _, clash := range clashes {
+		if clash.KelasID != nil {
+			kelasClashes[*clash.KelasID] = append(kelasClashes[*clash.KelasID], clash)
+		} else {
+			courseClashes[clash.CourseID] = append(courseClashes[clash.CourseID], clash)
+		}
+	}
+
+	kelasByCourse, err := kelasOptions(tahunAjaran, courseIDs, jadwal, kelasClashes)
+	if err != nil {
+		return result, err
+	}
+
+	if result.SKS, err = sksQuota(config.DB, mahasiswa, semester, tahunAjaran); err != nil {
+		return result, err
+	}
+
+	recommendations := make([]models.CourseRecommendation, 0, len(candidates))
+	for _, course := range candidates {
+		rec := models.CourseRecommendation{
+			Course:             course,
+			Recommended:        true,
+			Reasons:            []string{},
+			UnmetPrerequisites: unmet[course.ID],
+			Kelas:              kelasByCourse[course.ID],
+		}
+
+		// Posisi dalam kurikulum
+		wajib := course.Sifat != models.CoursePilihan
+		if achieved, graded := best[course.ID]; graded {
+			rec.Retake = true
+			rec.PreviousGrade = achieved.GradeHuruf
+			rec.Score += scoreRetake
+			rec.Reasons = append(rec.Reasons, "Mengulang, nilai sebelumnya "+achieved.GradeHuruf)
+		} else if course.Semester < semester && wajib {
+			rec.Score += scoreMandatoryBehind
+			rec.Reasons = append(rec.Reasons, fmt.Sprintf("Mata kuliah wajib semester %d belum diambil", course.Semester))
+		} else if course.Semester < semester {
+			rec.Score += scoreElectiveBehind
+			rec.Reasons = append(rec.Reasons, fmt.Sprintf("Mata kuliah pilihan semester %d", course.Semester))
+		} else if wajib {
+			rec.Score += scoreCurriculumWajib
+			rec.Reasons = append(rec.Reasons, fmt.Sprintf("Mata kuliah wajib semester %d sesuai kurikulum", course.Semester))
+		} else {
+			rec.Score += scoreCurriculumPilih
+			rec.Reasons = append(rec.Reasons, fmt.Sprintf("Mata kuliah pilihan semester %d sesuai kurikulum", course.Semester))
+		}
+
+		if len(rec.UnmetPrerequisites) > 0 {
+			rec.Recommended = false
+			rec.Reasons = append(rec.Reasons, "Prasyarat belum terpenuhi: "+describeUnmetPrerequisites(rec.UnmetPrerequisites))
+		}
+
+		// Kecocokan jadwal: mata kuliah berkelas cukup punya satu kelas yang tidak bentrok
+		if len(rec.Kelas) > 0 {
+			fit, open := false, false
+			for _, k := range rec.Kelas {
+				if len(k.ScheduleClashes) == 0 {
+					fit = true
+					if k.SisaKursi > 0 {
+						open = true
+					}
+				}
+			}
+			switch {
+			case !fit:
+				rec.Recommended = false
+				rec.Reasons = append(rec.Reasons, "Semua kelas bentrok dengan jadwal KRS")
+			case !open:
+				rec.Score += scoreKelasFull
+				rec.Reasons = append(rec.Reasons, "Kelas yang jadwalnya cocok sudah penuh, akan masuk daftar tunggu")
+			}
+		} else if rec.ScheduleClashes = courseClashes[course.ID]; len(rec.ScheduleClashes) > 0 {
+			rec.Recommended = false
+			rec.Reasons = append(rec.Reasons, "Bentrok jadwal dengan "+describeScheduleClashes(rec.ScheduleClashes))
+		}
+
+		recommendations = append(recommendations, rec)
+	}
+
+	sort.SliceStable(recommendations, func(i, j int) bool {
+		a, b := recommendations[i], recommendations[j]
+		if a.Recommended != b.Recommended {
+			return a.Recommended
+		}
+		if a.Score != b.Score {
+			return a.Score > b.Score
+		}
+		if a.Semester != b.Semester {
+			return a.Semester < b.Semester
+		}
+		return a.Code < b.Code
+	})
+
+	// Isi sisa SKS berurutan dari skor tertinggi
+	remaining := result.SKS.SisaSKS
+	for i := range recommendations {
+		rec := &recommendations[i]
+		if !rec.Recommended {
+			continue
+		}
+		if rec.Credits > remaining {
+			rec.Reasons = append(rec.Reasons, fmt.Sprintf("Melebihi sisa SKS (sisa %d)", remaining))
+			continue
+		}
+		rec.FitsQuota = true
+		remaining -= rec.Credits
+		result.SuggestedSKS += rec.Credits
+	}
+
+	result.Recommendations = recommendations
+	return result, nil
+}
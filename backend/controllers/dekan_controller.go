@@ -0,0 +1,31 @@
+package controllers
+
+import (
+	"SIAku/config"
+	"SIAku/middleware"
+	"SIAku/models"
+	"SIAku/utils"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+type DekanController struct{}
+
+func NewDekanController() *DekanController {
+	return &DekanController{}
+}
+
+// GetFacultyReport - Laporan fakultas yang dipimpin dekan (fakultas diambil dari penugasan jabatan aktif)
+func (dc *DekanController) GetFacultyReport(c *gin.Context) {
+	principal := middleware.GetPrincipal(c)
+
+	var assignment models.RoleAssignment
+	if err := config.DB.Where("user_id = ? AND role = ? AND status = ?", principal.UserID, "dekan", "active").
+		Order("applied_at DESC").First(&assignment).Error; err != nil {
+		utils.ErrorResponse(c, http.StatusNotFound, "Penugasan dekan aktif tidak ditemukan")
+		return
+	}
+
+	utils.SuccessResponse(c, buildFacultyReport(assignment.Faculty))
+}
@@ -2,13 +2,16 @@ package controllers
 
 import (
 	"SIAku/config"
+	"SIAku/middleware"
 	"SIAku/models"
 	"SIAku/utils"
+	"errors"
+	"fmt"
 	"net/http"
 	"strconv"
-	"time"
 
 	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
 )
 
 type DosenController struct{}
@@ -19,16 +22,12 @@ func NewDosenController() *DosenController {
 
 // Input Nilai Mahasiswa per Mata Kuliah
 func (dc *DosenController) InputNilai(c *gin.Context) {
-	dosenID, _ := c.Get("user_id")
+	principal := middleware.GetPrincipal(c)
+	dosenID := principal.DosenID
 	courseID := c.Param("courseId")
 	mahasiswaID := c.Param("mahasiswaId")
 
-	var req struct {
-		NilaiTugas float64 `json:"nilai_tugas" validate:"min=0,max=100"`
-		NilaiUTS   float64 `json:"nilai_uts" validate:"min=0,max=100"`
-		NilaiUAS   float64 `json:"nilai_uas" validate:"min=0,max=100"`
-	}
-
+	var req models.InputNilaiRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
 		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid JSON format")
 		return
@@ -41,91 +40,149 @@ func (dc *DosenController) InputNilai(c *gin.Context) {
 
 	// Verifikasi dosen mengajar mata kuliah ini
 	var course models.Course
-	if err := config.DB.Where("id = ? AND dosen_id = ?", courseID, dosenID).First(&course).Error; err != nil {
+	if err := config.DB.Scopes(taughtBy(dosenID)).Where("courses.id = ?", courseID).First(&course).Error; err != nil {
 		utils.ErrorResponse(c, http.StatusForbidden, "You are not authorized to input grades for this course")
 		return
 	}
 
-	// Verifikasi mahasiswa terdaftar di mata kuliah
+	// Verifikasi mahasiswa terdaftar di mata kuliah (percobaan terbaru jika mengulang)
 	var krs models.KRS
-	if err := config.DB.Where("course_id = ? AND mahasiswa_id = ? AND approval_status = 'approved'", courseID, mahasiswaID).First(&krs).Error; err != nil {
+	if err := config.DB.Scopes(dosenKelasScope(course, dosenID)).
+		Where("course_id = ? AND mahasiswa_id = ? AND approval_status = ?", courseID, mahasiswaID, models.KRSValidatedKajur).
+		Order("semester DESC, id DESC").First(&krs).Error; err != nil {
 		utils.ErrorResponse(c, http.StatusNotFound, "Student not enrolled in this course")
 		return
 	}
 
-	// Hitung nilai akhir (30% tugas, 35% UTS, 35% UAS)
-	nilaiAkhir := (req.NilaiTugas * 0.3) + (req.NilaiUTS * 0.35) + (req.NilaiUAS * 0.35)
+	// Nilai hanya bisa diisi selama lembar nilai kelas masih draft
+	sheet, err := gradeSheetFor(config.DB, krs.CourseID, krs.TahunAjaran, krsKelasID(&krs), principal.Jurusan)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to fetch grade sheet")
+		return
+	}
+	if sheet.Status != models.GradeSheetDraft {
+		utils.ErrorResponse(c, http.StatusConflict, "Lembar nilai kelas sudah diajukan, nilai tidak dapat diubah")
+		return
+	}
 
-	// Tentukan grade huruf dan poin
-	gradeHuruf, gradePoint := calculateGrade(nilaiAkhir)
+	// Komponen penilaian mata kuliah/kelas pada tahun ajaran KRS
+	scheme, _, err := gradingScheme(config.DB, krs.CourseID, krs.TahunAjaran, krs.KelasID)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to fetch grading scheme")
+		return
+	}
+	inScheme := map[string]bool{}
+	for _, component := range scheme {
+		inScheme[component.Kode] = true
+	}
 
-	// Cek apakah nilai sudah ada
-	var nilai models.Nilai
-	if err := config.DB.Where("mahasiswa_id = ? AND course_id = ?", mahasiswaID, courseID).First(&nilai).Error; err != nil {
-		// Buat nilai baru
-		nilai = models.Nilai{
-			MahasiswaID: uint(parseUint(mahasiswaID)),
-			CourseID:    uint(parseUint(courseID)),
-			Semester:    krs.Semester,
-			TahunAjaran: krs.TahunAjaran,
-			NilaiTugas:  req.NilaiTugas,
-			NilaiUTS:    req.NilaiUTS,
-			NilaiUAS:    req.NilaiUAS,
-			NilaiAkhir:  nilaiAkhir,
-			GradeHuruf:  gradeHuruf,
-			GradePoint:  gradePoint,
-			Status:      "sudah_dinilai",
+	input := req.Komponen
+	if len(input) == 0 {
+		// Format lama: nilai_tugas/nilai_uts/nilai_uas untuk skema yang memakai kode tersebut
+		input = map[string]float64{}
+		for kode, skor := range map[string]float64{"tugas": req.NilaiTugas, "uts": req.NilaiUTS, "uas": req.NilaiUAS} {
+			if inScheme[kode] {
+				input[kode] = skor
+			}
 		}
-		if err := config.DB.Create(&nilai).Error; err != nil {
-			utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to create grade")
+		if len(input) == 0 {
+			utils.ErrorResponse(c, http.StatusBadRequest, "Isi skor per komponen lewat field komponen")
 			return
 		}
-	} else {
-		// Update nilai yang ada
-		nilai.NilaiTugas = req.NilaiTugas
-		nilai.NilaiUTS = req.NilaiUTS
-		nilai.NilaiUAS = req.NilaiUAS
-		nilai.NilaiAkhir = nilaiAkhir
-		nilai.GradeHuruf = gradeHuruf
-		nilai.GradePoint = gradePoint
-		nilai.Status = "sudah_dinilai"
-
-		if err := config.DB.Save(&nilai).Error; err != nil {
-			utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to update grade")
+	}
+	for kode := range input {
+		if !inScheme[kode] {
+			utils.ErrorResponse(c, http.StatusBadRequest, "Komponen "+kode+" tidak ada di skema penilaian mata kuliah ini")
 			return
 		}
 	}
 
+	// Skala yang tercatat pada nilai dipertahankan; nilai baru memakai skala yang berlaku untuk mahasiswa
+	var mahasiswa models.Mahasiswa
+	if err := config.DB.First(&mahasiswa, krs.MahasiswaID).Error; err != nil {
+		utils.ErrorResponse(c, http.StatusNotFound, "Mahasiswa not found")
+		return
+	}
+
+	// Lembar nilai dicek ulang dan nilai dibaca ulang di dalam transaksi supaya tidak berubah setelah lembar
+	// diajukan dan tidak menimpa skor yang disimpan bersamaan (mis. impor nilai)
+	var nilai models.Nilai
+	var scale models.GradeScale
+	err = config.DB.Transaction(func(tx *gorm.DB) error {
+		if err := requireDraftSheet(tx, sheet.CourseID, sheet.TahunAjaran, &sheet.KelasID); err != nil {
+			return err
+		}
+		if err := lockRosterNilai(tx, sheet, []models.KRS{krs}); err != nil {
+			return err
+		}
+		var isNew bool
+		var err error
+		nilai, isNew, err = attemptNilai(tx, krs)
+		if err != nil {
+			return err
+		}
+		if nilai.Status == models.NilaiPublished {
+			return errNilaiPublished
+		}
+		if scale, err = newGradeScaleCache(tx).forNilai(nilai, mahasiswa); err != nil {
+			return err
+		}
+
+		before := nilai
+		komponen := scoreNilai(&nilai, scheme, scale, storedScores(nilai), input)
+		return saveDraftNilai(c, tx, &nilai, before, isNew, komponen)
+	})
+	if err != nil {
+		switch {
+		case errors.Is(err, errGradeSheetLocked):
+			utils.ErrorResponse(c, http.StatusConflict, "Lembar nilai kelas sudah diajukan, nilai tidak dapat diubah")
+		case errors.Is(err, errNilaiPublished):
+			utils.ErrorResponse(c, http.StatusConflict, "Nilai sudah dipublikasikan, ajukan koreksi nilai")
+		default:
+			utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to save grade")
+		}
+		return
+	}
+
 	// Notification removed - WhatsApp integration disabled
 
 	utils.SuccessResponse(c, gin.H{
 		"message": "Grade successfully inputted",
 		"nilai": gin.H{
-			"nilai_tugas": req.NilaiTugas,
-			"nilai_uts":   req.NilaiUTS,
-			"nilai_uas":   req.NilaiUAS,
-			"nilai_akhir": nilaiAkhir,
-			"grade_huruf": gradeHuruf,
-			"grade_point": gradePoint,
+			"nilai_tugas": nilai.NilaiTugas,
+			"nilai_uts":   nilai.NilaiUTS,
+			"nilai_uas":   nilai.NilaiUAS,
+			"nilai_akhir": nilai.NilaiAkhir,
+			"grade_huruf": nilai.GradeHuruf,
+			"grade_point": nilai.GradePoint,
+			"grade_scale": scale.Nama,
+			"attempt":     nilai.Attempt,
+			"status":      nilai.Status,
+			"komponen":    nilaiKomponenResponse(nilai),
 		},
 	})
 }
 
 // Lihat Daftar Mahasiswa di Kelas
 func (dc *DosenController) GetMahasiswaInClass(c *gin.Context) {
-	dosenID, _ := c.Get("user_id")
+	dosenID := middleware.GetPrincipal(c).DosenID
 	courseID := c.Param("courseId")
 
 	// Verifikasi dosen mengajar mata kuliah ini
 	var course models.Course
-	if err := config.DB.Where("id = ? AND dosen_id = ?", courseID, dosenID).First(&course).Error; err != nil {
+	if err := config.DB.Scopes(taughtBy(dosenID)).Where("courses.id = ?", courseID).First(&course).Error; err != nil {
 		utils.ErrorResponse(c, http.StatusForbidden, "You are not authorized to view this class")
 		return
 	}
 
-	// Ambil daftar mahasiswa yang KRS-nya sudah approved
+	// Ambil daftar mahasiswa yang KRS-nya sudah approved (dosen kelas paralel hanya melihat kelasnya)
 	var krsList []models.KRS
-	if err := config.DB.Preload("Mahasiswa").Where("course_id = ? AND approval_status = 'approved'", courseID).Find(&krsList).Error; err != nil {
+	query := config.DB.Preload("Mahasiswa").Scopes(dosenKelasScope(course, dosenID)).
+		Where("course_id = ? AND approval_status = ?", courseID, models.KRSValidatedKajur)
+	if kelasID := c.Query("kelas_id"); kelasID != "" {
+		query = query.Where("kelas_id = ?", kelasID)
+	}
+	if err := query.Find(&krsList).Error; err != nil {
 		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to fetch students")
 		return
 	}
@@ -139,6 +196,7 @@ func (dc *DosenController) GetMahasiswaInClass(c *gin.Context) {
 			"jurusan":         krs.Mahasiswa.Jurusan,
 			"semester":        krs.Mahasiswa.Semester,
 			"status_akademik": krs.Mahasiswa.StatusAkademik,
+			"kelas_id":        krs.KelasID,
 			"enrolled_at":     krs.CreatedAt,
 		})
 	}
@@ -157,7 +215,7 @@ func (dc *DosenController) GetMahasiswaInClass(c *gin.Context) {
 
 // Approve/Reject KRS Mahasiswa
 func (dc *DosenController) ProcessKRSApproval(c *gin.Context) {
-	dosenID, _ := c.Get("user_id")
+	dosenID := middleware.GetPrincipal(c).DosenID
 	krsID := c.Param("krsId")
 
 	var req models.KRSApprovalRequest
@@ -179,56 +237,152 @@ func (dc *DosenController) ProcessKRSApproval(c *gin.Context) {
 	}
 
 	// Verifikasi dosen adalah dosen wali mahasiswa
-	if krs.Mahasiswa.DosenWaliID == nil || *krs.Mahasiswa.DosenWaliID != dosenID.(uint) {
+	if krs.Mahasiswa.DosenWaliID == nil || *krs.Mahasiswa.DosenWaliID != dosenID {
 		utils.ErrorResponse(c, http.StatusForbidden, "You are not authorized to approve this KRS")
 		return
 	}
 
-	// Update status approval
-	now := time.Now()
-	dosenIDUint := dosenID.(uint)
-	if req.Action == "approve" {
-		krs.ApprovalStatus = "approved"
-		krs.Status = "diambil"
-		krs.ApprovedBy = &dosenIDUint
-		krs.ApprovedAt = &now
-		krs.RejectionReason = ""
-	} else {
-		krs.ApprovalStatus = "rejected"
-		krs.Status = "ditolak"
-		krs.ApprovedBy = &dosenIDUint
-		krs.ApprovedAt = &now
-		krs.RejectionReason = req.RejectionReason
-	}
-
-	if err := config.DB.Save(&krs).Error; err != nil {
-		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to process KRS approval")
+	// Persetujuan hanya pada masa persetujuan KRS atau perubahan rencana studi
+	if !requireKRSWindow(c, krs.MahasiswaID, krs.Semester, krs.TahunAjaran, models.WindowApproval, models.WindowAddDrop) {
 		return
 	}
 
-	// Notification removed - WhatsApp integration disabled
+	// Transisi dijaga state machine; penolakan melepas kursi kelas
+	var seatReleased bool
+	err := config.DB.Transaction(func(tx *gorm.DB) error {
+		var err error
+		seatReleased, err = transitionKRS(c, tx, &krs, "dosen_wali", req.Action, req.RejectionReason)
+		return err
+	})
+	if err != nil {
+		respondKRSTransitionError(c, err, "Failed to process KRS approval")
+		return
+	}
+	if seatReleased {
+		promoteWaitlist(*krs.KelasID)
+	}
 
 	utils.SuccessResponse(c, gin.H{
-		"message": "KRS " + req.Action + "d successfully",
-		"krs": gin.H{
-			"id":               krs.ID,
-			"approval_status":  krs.ApprovalStatus,
-			"status":           krs.Status,
-			"approved_by":      krs.ApprovedBy,
-			"approved_at":      krs.ApprovedAt,
-			"rejection_reason": krs.RejectionReason,
-		},
+		"message": "KRS " + req.Action + " processed by dosen wali",
+		"krs":     krsApprovalSummary(krs),
+	})
+}
+
+// findWaliMahasiswa mengambil mahasiswa dari parameter URL dan memastikan dosen adalah dosen walinya
+func findWaliMahasiswa(c *gin.Context, dosenID uint) (models.Mahasiswa, bool) {
+	var mahasiswa models.Mahasiswa
+	if err := config.DB.Where("id = ?", c.Param("mahasiswaId")).First(&mahasiswa).Error; err != nil {
+		utils.ErrorResponse(c, http.StatusNotFound, "Mahasiswa not found")
+		return mahasiswa, false
+	}
+	if mahasiswa.DosenWaliID == nil || *mahasiswa.DosenWaliID != dosenID {
+		utils.ErrorResponse(c, http.StatusForbidden, "You are not the academic advisor of this student")
+		return mahasiswa, false
+	}
+	return mahasiswa, true
+}
+
+// GetKRSPackageReview - Tinjauan paket KRS satu mahasiswa bimbingan: total SKS, IPS sebelumnya dan bentrok jadwal
+func (dc *DosenController) GetKRSPackageReview(c *gin.Context) {
+	dosenID := middleware.GetPrincipal(c).DosenID
+
+	mahasiswa, ok := findWaliMahasiswa(c, dosenID)
+	if !ok {
+		return
+	}
+
+	semester, ok := semesterQuery(c, mahasiswa.Semester)
+	if !ok {
+		return
+	}
+	tahunAjaran := c.DefaultQuery("tahun_ajaran", getCurrentAcademicYear())
+
+	review, err := krsPackageReview(mahasiswa, semester, tahunAjaran)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to build KRS review")
+		return
+	}
+
+	utils.SuccessResponse(c, review)
+}
+
+// ProcessKRSPackage - Approve/reject/minta revisi seluruh paket KRS mahasiswa bimbingan dengan catatan per mata kuliah
+func (dc *DosenController) ProcessKRSPackage(c *gin.Context) {
+	dosenID := middleware.GetPrincipal(c).DosenID
+
+	var req models.KRSPackageApprovalRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid JSON format")
+		return
+	}
+
+	if err := utils.ValidateStruct(req); err != nil {
+		utils.HandleValidationError(c, err)
+		return
+	}
+
+	mahasiswa, ok := findWaliMahasiswa(c, dosenID)
+	if !ok {
+		return
+	}
+
+	if !requireKRSWindow(c, mahasiswa.ID, req.Semester, req.TahunAjaran, models.WindowApproval, models.WindowAddDrop) {
+		return
+	}
+
+	var released []uint
+	err := config.DB.Transaction(func(tx *gorm.DB) error {
+		entries, err := loadKRSPackage(tx, mahasiswa.ID, req.Semester, req.TahunAjaran)
+		if err != nil {
+			return err
+		}
+
+		// Catatan per mata kuliah hanya untuk KRS di paket ini
+		inPackage := map[uint]bool{}
+		for _, entry := range entries {
+			inPackage[entry.ID] = true
+		}
+		notes := map[uint]string{}
+		for _, item := range req.Items {
+			if !inPackage[item.KRSID] {
+				return fmt.Errorf("%w: krs %d", errKRSNotInPackage, item.KRSID)
+			}
+			notes[item.KRSID] = item.Note
+		}
+
+		released, err = transitionKRSPackage(c, tx, entries, "dosen_wali", req.Action, req.Note, notes)
+		return err
+	})
+	if errors.Is(err, errKRSNotInPackage) {
+		utils.ErrorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+	if err != nil {
+		respondKRSTransitionError(c, err, "Failed to process KRS package")
+		return
+	}
+	promoteReleasedKelas(released)
+
+	review, err := krsPackageReview(mahasiswa, req.Semester, req.TahunAjaran)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "KRS processed but failed to build review")
+		return
+	}
+
+	utils.SuccessResponse(c, gin.H{
+		"message": "KRS package " + req.Action + " processed by dosen wali",
+		"review":  review,
 	})
 }
 
 // Get Pending KRS for Approval
 func (dc *DosenController) GetPendingKRS(c *gin.Context) {
-	dosenID, _ := c.Get("user_id")
+	dosenID := middleware.GetPrincipal(c).DosenID
 
 	var pendingKRS []models.KRS
 	if err := config.DB.Preload("Mahasiswa").Preload("Course").
 		Joins("JOIN mahasiswas ON mahasiswas.id = krs.mahasiswa_id").
-		Where("mahasiswas.dosen_wali_id = ? AND krs.approval_status = 'pending'", dosenID).
+		Where("mahasiswas.dosen_wali_id = ? AND krs.approval_status = ?", dosenID, models.KRSSubmitted).
 		Find(&pendingKRS).Error; err != nil {
 		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to fetch pending KRS")
 		return
@@ -267,21 +421,3 @@ func parseUint(s string) uint64 {
 	val, _ := strconv.ParseUint(s, 10, 32)
 	return val
 }
-
-func calculateGrade(nilai float64) (string, float64) {
-	if nilai >= 85 {
-		return "A", 4.0
-	} else if nilai >= 80 {
-		return "AB", 3.5
-	} else if nilai >= 75 {
-		return "B", 3.0
-	} else if nilai >= 70 {
-		return "BC", 2.5
-	} else if nilai >= 65 {
-		return "C", 2.0
-	} else if nilai >= 50 {
-		return "D", 1.0
-	} else {
-		return "E", 0.0
-	}
-}
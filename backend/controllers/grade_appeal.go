@@ -0,0 +1,184 @@
+package controllers
+
+import (
+	"SIAku/config"
+	"SIAku/middleware"
+	"SIAku/models"
+	"SIAku/utils"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// Helper sanggah nilai: batas waktu pengajuan, penanggung jawab, transisi status dan notifikasi
+
+var (
+	errIllegalGradeAppealTransition = errors.New("illegal grade appeal transition")
+	errGradeAppealChanged           = errors.New("grade appeal status changed concurrently")
+	errAppealKomponenRequired       = errors.New("komponen wajib diisi saat menerima sanggah")
+)
+
+// appealDeadline menghitung batas sanggah dari waktu publikasi lembar nilai kelas
+func appealDeadline(db *gorm.DB, nilai models.Nilai) (*time.Time, models.GradeSheet, error) {
+	var sheet models.GradeSheet
+	if err := db.Where("course_id = ? AND tahun_ajaran = ? AND kelas_id = ?", nilai.CourseID, nilai.TahunAjaran, krsKelasID(nilai.KRS)).
+		First(&sheet).Error; err != nil {
+		return nil, sheet, err
+	}
+	if sheet.PublishedAt == nil {
+		return nil, sheet, nil
+	}
+	deadline := sheet.PublishedAt.Add(config.AppConfig.GradeAppealWindow)
+	return &deadline, sheet, nil
+}
+
+// appealDosen memilih dosen kelas paralel nilai, atau dosen pengampu utama mata kuliah
+func appealDosen(nilai models.Nilai) *uint {
+	if nilai.KRS != nil && nilai.KRS.KelasID != nil {
+		var kelas models.Kelas
+		if err := config.DB.Select("dosen_id").First(&kelas, *nilai.KRS.KelasID).Error; err == nil && kelas.DosenID != nil {
+			return kelas.DosenID
+		}
+	}
+	return nilai.Course.DosenID
+}
+
+// transitionGradeAppeal menjalankan aksi sanggah dengan update bersyarat pada status lama.
+// Menerima sanggah menghitung ulang nilai dengan skor komponen revisi dan memperbarui IPK mahasiswa.
+func transitionGradeAppeal(c *gin.Context, tx *gorm.DB, appeal *models.GradeAppeal, role string, req models.GradeAppealResponseRequest) error {
+	next, err := models.NextGradeAppealStatus(appeal.Status, role, req.Action)
+	if err != nil {
+		return fmt.Errorf("%w: %v", errIllegalGradeAppealTransition, err)
+	}
+
+	principal := middleware.GetPrincipal(c)
+	before := *appeal
+	now := time.Now()
+
+	updates := map[string]interface{}{"status": next}
+	switch req.Action {
+	case "escalate":
+		updates["escalated_by"] = principal.KajurID
+		updates["escalated_at"] = now
+		updates["escalation_note"] = req.Response
+	case "accept":
+		if len(req.Komponen) == 0 {
+			return errAppealKomponenRequired
+		}
+		nilai, err := loadCorrectableNilai(tx, appeal.NilaiID)
+		if err != nil {
+			return err
+		}
+		nilaiBefore := nilai
+		komponen, err := rescoreNilai(tx, &nilai, req.Komponen)
+		if err != nil {
+			return err
+		}
+		if err := tx.Omit("Komponen", "KRS", "Mahasiswa", "Course").Save(&nilai).Error; err != nil {
+			return err
+		}
+		if err := saveNilaiKomponen(tx, nilai.ID, komponen); err != nil {
+			return err
+		}
+		if err := refreshMahasiswaIPK(tx, nilai.MahasiswaID); err != nil {
+			return err
+		}
+		nilai.Komponen = komponen
+		if err := middleware.RecordAudit(c, tx, "appeal", "nilai", nilai.ID, nilaiBefore, nilai); err != nil {
+			return err
+		}
+
+		scores := models.JSONMap{}
+		for kode, skor := range req.Komponen {
+			scores[kode] = skor
+		}
+		updates["komponen"] = scores
+		updates["nilai_akhir_lama"] = nilaiBefore.NilaiAkhir
+		updates["grade_huruf_lama"] = nilaiBefore.GradeHuruf
+		updates["nilai_akhir_baru"] = nilai.NilaiAkhir
+		updates["grade_huruf_baru"] = nilai.GradeHuruf
+		fallthrough
+	case "reject":
+		updates["response"] = req.Response
+		updates["responded_by"] = principal.UserID
+		updates["responded_at"] = now
+	}
+
+	result := tx.Model(&models.GradeAppeal{}).Where("id = ? AND status = ?", appeal.ID, appeal.Status).Updates(updates)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return errGradeAppealChanged
+	}
+	if err := tx.Where("id = ?", appeal.ID).First(appeal).Error; err != nil {
+		return err
+	}
+
+	return middleware.RecordAudit(c, tx, req.Action+"_grade_appeal", "grade_appeal", appeal.ID, before, appeal)
+}
+
+// respondGradeAppealError memetakan error sanggah nilai ke response HTTP
+func respondGradeAppealError(c *gin.Context, err error, fallback string) {
+	switch {
+	case errors.Is(err, errIllegalGradeAppealTransition):
+		utils.ErrorResponse(c, http.StatusConflict, err.Error())
+	case errors.Is(err, errGradeAppealChanged):
+		utils.ErrorResponse(c, http.StatusConflict, "Sanggah nilai sudah diproses oleh request lain, muat ulang data")
+	case errors.Is(err, errAppealKomponenRequired), errors.Is(err, errUnknownKomponen):
+		utils.ErrorResponse(c, http.StatusBadRequest, err.Error())
+	default:
+		utils.ErrorResponse(c, http.StatusInternalServerError, fallback)
+	}
+}
+
+// notifyAppeal mengirim email status sanggah ke mahasiswa, dosen penanggung jawab dan kajur jurusan.
+// Kegagalan kirim hanya dicatat di log supaya tidak membatalkan proses sanggah.
+func notifyAppeal(appeal models.GradeAppeal, subject, body string) {
+	var recipients []string
+
+	var mahasiswa models.Mahasiswa
+	if err := config.DB.First(&mahasiswa, appeal.MahasiswaID).Error; err == nil && mahasiswa.UserID != nil {
+		var user models.Users
+		if err := config.DB.Select("email").First(&user, *mahasiswa.UserID).Error; err == nil {
+			recipients = append(recipients, user.Email)
+		}
+	}
+	if appeal.DosenID != nil {
+		var dosen models.Dosen
+		if err := config.DB.Select("email").First(&dosen, *appeal.DosenID).Error; err == nil {
+			recipients = append(recipients, dosen.Email)
+		}
+	}
+	if kajur := activeKajur(appeal.Jurusan); kajur != nil {
+		recipients = append(recipients, kajur.Email)
+	}
+
+	message := fmt.Sprintf("%s\n\nSanggah nilai #%d (mahasiswa %s)\nStatus: %s", body, appeal.ID, mahasiswa.NIM, appeal.Status)
+	for _, to := range recipients {
+		if to == "" {
+			continue
+		}
+		if err := utils.GetMailer().Send(to, subject, message); err != nil {
+			log.Printf("Failed to send grade appeal %d notification to %s: %v", appeal.ID, to, err)
+		}
+	}
+}
+
+// canViewAppeal membatasi sanggah ke mahasiswa pengaju, dosen penanggung jawab dan kajur jurusan
+func canViewAppeal(principal *middleware.Principal, appeal models.GradeAppeal) bool {
+	switch principal.Role {
+	case "mahasiswa":
+		return principal.MahasiswaID == appeal.MahasiswaID
+	case "dosen":
+		return appeal.DosenID != nil && *appeal.DosenID == principal.DosenID
+	case "kajur":
+		return principal.Jurusan == appeal.Jurusan
+	}
+	return false
+}
@@ -0,0 +1,301 @@
+package controllers
+
+import (
+	"SIAku/config"
+	"SIAku/middleware"
+	"SIAku/models"
+	"SIAku/utils"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+const appealAttachmentMaxSize = 5 << 20 // 5 MB
+
+type GradeAppealController struct{}
+
+func NewGradeAppealController() *GradeAppealController {
+	return &GradeAppealController{}
+}
+
+// OpenAppeal - Mahasiswa mengajukan sanggah atas nilai yang sudah dipublikasikan selama masa sanggah
+func (gc *GradeAppealController) OpenAppeal(c *gin.Context) {
+	principal := middleware.GetPrincipal(c)
+
+	var req models.GradeAppealRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid JSON format")
+		return
+	}
+
+	if err := utils.ValidateStruct(req); err != nil {
+		utils.HandleValidationError(c, err)
+		return
+	}
+
+	nilai, err := loadCorrectableNilai(config.DB, c.Param("id"))
+	if err != nil || nilai.MahasiswaID != principal.MahasiswaID {
+		utils.ErrorResponse(c, http.StatusNotFound, "Nilai not found")
+		return
+	}
+	if nilai.Status != models.NilaiPublished {
+		utils.ErrorResponse(c, http.StatusConflict, "Sanggah hanya untuk nilai yang sudah dipublikasikan")
+		return
+	}
+
+	deadline, sheet, err := appealDeadline(config.DB, nilai)
+	if err != nil && err != gorm.ErrRecordNotFound {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to check appeal window")
+		return
+	}
+	if deadline == nil || time.Now().After(*deadline) {
+		utils.ErrorResponse(c, http.StatusForbidden, "Masa sanggah nilai sudah berakhir")
+		return
+	}
+
+	var active int64
+	config.DB.Model(&models.GradeAppeal{}).
+		Where("nilai_id = ? AND status IN ?", nilai.ID, []string{models.AppealOpen, models.AppealEscalated}).Count(&active)
+	if active > 0 {
+		utils.ErrorResponse(c, http.StatusConflict, "Sanggah untuk nilai ini masih diproses")
+		return
+	}
+
+	jurusan := sheet.Jurusan
+	if jurusan == "" {
+		jurusan = nilai.Mahasiswa.Jurusan
+	}
+	appeal := models.GradeAppeal{
+		NilaiID:        nilai.ID,
+		MahasiswaID:    nilai.MahasiswaID,
+		DosenID:        appealDosen(nilai),
+		Jurusan:        jurusan,
+		Pesan:          req.Pesan,
+		Status:         models.AppealOpen,
+		NilaiAkhirLama: nilai.NilaiAkhir,
+		GradeHurufLama: nilai.GradeHuruf,
+	}
+
+	err = config.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Omit("Nilai", "Mahasiswa", "Attachments").Create(&appeal).Error; err != nil {
+			return err
+		}
+		return middleware.RecordAudit(c, tx, "open_grade_appeal", "grade_appeal", appeal.ID, nil, appeal)
+	})
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to create grade appeal")
+		return
+	}
+
+	notifyAppeal(appeal, "Sanggah nilai baru - SIAku",
+		fmt.Sprintf("Mahasiswa mengajukan sanggah nilai %s %s (%s, %.2f).", nilai.Course.Code, nilai.Course.Name, nilai.GradeHuruf, nilai.NilaiAkhir))
+
+	utils.CreatedResponse(c, gin.H{
+		"message":  "Grade appeal submitted",
+		"appeal":   appeal,
+		"deadline": deadline,
+	})
+}
+
+// UploadEvidence - Mahasiswa melampirkan bukti selama sanggah belum ditanggapi
+func (gc *GradeAppealController) UploadEvidence(c *gin.Context) {
+	principal := middleware.GetPrincipal(c)
+
+	var appeal models.GradeAppeal
+	if err := config.DB.Where("id = ? AND mahasiswa_id = ?", c.Param("id"), principal.MahasiswaID).First(&appeal).Error; err != nil {
+		utils.ErrorResponse(c, http.StatusNotFound, "Grade appeal not found")
+		return
+	}
+	if appeal.Status != models.AppealOpen {
+		utils.ErrorResponse(c, http.StatusConflict, "Bukti hanya bisa ditambahkan sebelum sanggah ditanggapi")
+		return
+	}
+
+	file, err := c.FormFile("file")
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "File is required")
+		return
+	}
+	if file.Size > appealAttachmentMaxSize {
+		utils.ErrorResponse(c, http.StatusBadRequest, "File size exceeds 5 MB")
+		return
+	}
+
+	dir := filepath.Join(config.AppConfig.UploadDir, "grade-appeals", strconv.Itoa(int(appeal.ID)))
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to store attachment")
+		return
+	}
+
+	// Nama file di disk dibuat server, nama asli hanya disimpan sebagai metadata
+	storedName := fmt.Sprintf("%d_%s", time.Now().UnixNano(), filepath.Base(file.Filename))
+	path := filepath.Join(dir, storedName)
+	if err := c.SaveUploadedFile(file, path); err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to store attachment")
+		return
+	}
+
+	attachment := models.GradeAppealAttachment{
+		AppealID:   appeal.ID,
+		FileName:   filepath.Base(file.Filename),
+		FilePath:   path,
+		FileSize:   file.Size,
+		MimeType:   file.Header.Get("Content-Type"),
+		UploadedBy: principal.UserID,
+	}
+	err = config.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&attachment).Error; err != nil {
+			return err
+		}
+		return middleware.RecordAudit(c, tx, "attach_grade_appeal", "grade_appeal", appeal.ID, nil, attachment)
+	})
+	if err != nil {
+		os.Remove(path)
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to save attachment")
+		return
+	}
+
+	utils.CreatedResponse(c, attachment)
+}
+
+// GetMyAppeals - Daftar sanggah nilai mahasiswa
+func (gc *GradeAppealController) GetMyAppeals(c *gin.Context) {
+	mahasiswaID := middleware.GetPrincipal(c).MahasiswaID
+
+	var appeals []models.GradeAppeal
+	if err := config.DB.Preload("Attachments").Preload("Nilai.Course").
+		Where("mahasiswa_id = ?", mahasiswaID).Order("created_at DESC").Find(&appeals).Error; err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to fetch grade appeals")
+		return
+	}
+
+	utils.SuccessResponse(c, appeals)
+}
+
+// GetAppeals - Sanggah yang harus ditanggapi dosen, atau sanggah jurusan untuk kajur
+func (gc *GradeAppealController) GetAppeals(c *gin.Context) {
+	principal := middleware.GetPrincipal(c)
+
+	query := config.DB.Preload("Attachments").Preload("Nilai.Course").Preload("Mahasiswa")
+	if principal.Role == "kajur" {
+		query = query.Where("jurusan = ?", principal.Jurusan)
+	} else {
+		query = query.Where("dosen_id = ?", principal.DosenID)
+	}
+	if status := c.Query("status"); status != "" {
+		query = query.Where("status = ?", status)
+	}
+
+	var appeals []models.GradeAppeal
+	if err := query.Order("created_at ASC").Find(&appeals).Error; err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to fetch grade appeals")
+		return
+	}
+
+	utils.SuccessResponse(c, gin.H{
+		"total":   len(appeals),
+		"appeals": appeals,
+	})
+}
+
+// GetAppealDetail - Detail sanggah beserta lampiran (mahasiswa pengaju, dosen penanggung jawab, kajur jurusan)
+func (gc *GradeAppealController) GetAppealDetail(c *gin.Context) {
+	var appeal models.GradeAppeal
+	if err := config.DB.Preload("Attachments").Preload("Nilai.Course").Preload("Mahasiswa").
+		First(&appeal, "id = ?", c.Param("id")).Error; err != nil {
+		utils.ErrorResponse(c, http.StatusNotFound, "Grade appeal not found")
+		return
+	}
+	if !canViewAppeal(middleware.GetPrincipal(c), appeal) {
+		utils.ErrorResponse(c, http.StatusForbidden, "You are not authorized to view this grade appeal")
+		return
+	}
+
+	utils.SuccessResponse(c, appeal)
+}
+
+// DownloadEvidence - Unduh bukti sanggah
+func (gc *GradeAppealController) DownloadEvidence(c *gin.Context) {
+	var appeal models.GradeAppeal
+	if err := config.DB.First(&appeal, "id = ?", c.Param("id")).Error; err != nil {
+		utils.ErrorResponse(c, http.StatusNotFound, "Grade appeal not found")
+		return
+	}
+	if !canViewAppeal(middleware.GetPrincipal(c), appeal) {
+		utils.ErrorResponse(c, http.StatusForbidden, "You are not authorized to view this grade appeal")
+		return
+	}
+
+	var attachment models.GradeAppealAttachment
+	if err := config.DB.Where("id = ? AND appeal_id = ?", c.Param("attachmentId"), appeal.ID).First(&attachment).Error; err != nil {
+		utils.ErrorResponse(c, http.StatusNotFound, "Attachment not found")
+		return
+	}
+
+	c.FileAttachment(attachment.FilePath, attachment.FileName)
+}
+
+// RespondAppeal - Dosen menerima (dengan skor komponen revisi) atau menolak sanggah;
+// kajur dapat mengambil alih lalu memutuskan sendiri
+func (gc *GradeAppealController) RespondAppeal(c *gin.Context) {
+	principal := middleware.GetPrincipal(c)
+
+	var req models.GradeAppealResponseRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid JSON format")
+		return
+	}
+
+	if err := utils.ValidateStruct(req); err != nil {
+		utils.HandleValidationError(c, err)
+		return
+	}
+	if req.Action != "accept" && req.Response == "" {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Response wajib diisi saat menolak atau mengambil alih sanggah")
+		return
+	}
+
+	var appeal models.GradeAppeal
+	if err := config.DB.First(&appeal, "id = ?", c.Param("id")).Error; err != nil {
+		utils.ErrorResponse(c, http.StatusNotFound, "Grade appeal not found")
+		return
+	}
+	if !canViewAppeal(principal, appeal) {
+		utils.ErrorResponse(c, http.StatusForbidden, "You are not authorized to respond to this grade appeal")
+		return
+	}
+	if req.Action == "escalate" && appeal.EscalatedAt != nil {
+		utils.ErrorResponse(c, http.StatusConflict, "Sanggah sudah pernah diambil alih kajur")
+		return
+	}
+
+	err := config.DB.Transaction(func(tx *gorm.DB) error {
+		return transitionGradeAppeal(c, tx, &appeal, principal.Role, req)
+	})
+	if err != nil {
+		respondGradeAppealError(c, err, "Failed to process grade appeal")
+		return
+	}
+
+	body := "Sanggah nilai telah ditanggapi."
+	switch appeal.Status {
+	case models.AppealEscalated:
+		body = "Sanggah nilai diambil alih oleh kajur: " + appeal.EscalationNote
+	case models.AppealAccepted:
+		body = fmt.Sprintf("Sanggah nilai diterima. Nilai baru %.2f (%s).", *appeal.NilaiAkhirBaru, appeal.GradeHurufBaru)
+	case models.AppealRejected:
+		body = "Sanggah nilai ditolak: " + appeal.Response
+	}
+	notifyAppeal(appeal, "Pembaruan sanggah nilai - SIAku", body)
+
+	utils.SuccessResponse(c, gin.H{
+		"message": "Grade appeal " + appeal.Status,
+		"appeal":  appeal,
+	})
+}
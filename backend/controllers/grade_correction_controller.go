@@ -0,0 +1,281 @@
+package controllers
+
+import (
+	"SIAku/config"
+	"SIAku/middleware"
+	"SIAku/models"
+	"SIAku/utils"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+var errCorrectionProcessed = errors.New("grade correction already processed")
+
+type GradeCorrectionController struct{}
+
+func NewGradeCorrectionController() *GradeCorrectionController {
+	return &GradeCorrectionController{}
+}
+
+// loadCorrectableNilai memuat nilai beserta data yang dibutuhkan untuk menghitung ulang
+func loadCorrectableNilai(db *gorm.DB, nilaiID interface{}) (models.Nilai, error) {
+	var nilai models.Nilai
+	err := db.Preload("Komponen", orderKomponen).Preload("KRS").Preload("Mahasiswa").Preload("Course").
+		First(&nilai, "id = ?", nilaiID).Error
+	return nilai, err
+}
+
+// dosenGradesNilai mengecek dosen adalah pengampu utama mata kuliah atau dosen kelas paralel nilai tersebut
+func dosenGradesNilai(nilai models.Nilai, dosenID uint) bool {
+	if nilai.Course.DosenID != nil && *nilai.Course.DosenID == dosenID {
+		return true
+	}
+	if nilai.KRS == nil || nilai.KRS.KelasID == nil {
+		return false
+	}
+	var count int64
+	config.DB.Model(&models.Kelas{}).Where("id = ? AND dosen_id = ?", *nilai.KRS.KelasID, dosenID).Count(&count)
+	return count > 0
+}
+
+// correctionScores mengubah skor koreksi tersimpan (jsonb) kembali menjadi map kode -> skor
+func correctionScores(komponen models.JSONMap) map[string]float64 {
+	scores := map[string]float64{}
+	for kode, value := range komponen {
+		if skor, ok := value.(float64); ok {
+			scores[kode] = skor
+		}
+	}
+	return scores
+}
+
+// RequestCorrection - Dosen mengajukan koreksi nilai yang sudah dipublikasikan beserta alasannya
+func (gc *GradeCorrectionController) RequestCorrection(c *gin.Context) {
+	principal := middleware.GetPrincipal(c)
+
+	var req models.GradeCorrectionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid JSON format")
+		return
+	}
+
+	if err := utils.ValidateStruct(req); err != nil {
+		utils.HandleValidationError(c, err)
+		return
+	}
+
+	nilai, err := loadCorrectableNilai(config.DB, c.Param("nilaiId"))
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusNotFound, "Nilai not found")
+		return
+	}
+	if !dosenGradesNilai(nilai, principal.DosenID) {
+		utils.ErrorResponse(c, http.StatusForbidden, "You are not authorized to correct this grade")
+		return
+	}
+	if nilai.Status != models.NilaiPublished {
+		utils.ErrorResponse(c, http.StatusConflict, "Koreksi hanya untuk nilai yang sudah dipublikasikan")
+		return
+	}
+
+	var pending int64
+	config.DB.Model(&models.GradeCorrection{}).Where("nilai_id = ? AND status = ?", nilai.ID, models.CorrectionPending).Count(&pending)
+	if pending > 0 {
+		utils.ErrorResponse(c, http.StatusConflict, "Masih ada koreksi nilai ini yang menunggu persetujuan")
+		return
+	}
+
+	// Pratinjau nilai baru dengan skema kelas dan skala yang tercatat pada nilai
+	preview := nilai
+	if _, err := rescoreNilai(config.DB, &preview, req.Komponen); err != nil {
+		if errors.Is(err, errUnknownKomponen) {
+			utils.ErrorResponse(c, http.StatusBadRequest, err.Error())
+			return
+		}
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to calculate corrected grade")
+		return
+	}
+
+	komponen := models.JSONMap{}
+	for kode, skor := range req.Komponen {
+		komponen[kode] = skor
+	}
+	correction := models.GradeCorrection{
+		NilaiID:        nilai.ID,
+		RequestedBy:    principal.DosenID,
+		Jurusan:        principal.Jurusan,
+		Alasan:         req.Alasan,
+		Komponen:       komponen,
+		NilaiAkhirLama: nilai.NilaiAkhir,
+		GradeHurufLama: nilai.GradeHuruf,
+		NilaiAkhirBaru: preview.NilaiAkhir,
+		GradeHurufBaru: preview.GradeHuruf,
+		Status:         models.CorrectionPending,
+	}
+
+	err = config.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Omit("Nilai").Create(&correction).Error; err != nil {
+			return err
+		}
+		return middleware.RecordAudit(c, tx, "request_grade_correction", "grade_correction", correction.ID, nil, correction)
+	})
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to create grade correction")
+		return
+	}
+
+	utils.CreatedResponse(c, gin.H{
+		"message":    "Grade correction submitted for kajur approval",
+		"correction": correction,
+	})
+}
+
+// GetMyCorrections - Riwayat koreksi nilai yang diajukan dosen
+func (gc *GradeCorrectionController) GetMyCorrections(c *gin.Context) {
+	dosenID := middleware.GetPrincipal(c).DosenID
+
+	query := config.DB.Preload("Nilai.Course").Preload("Nilai.Mahasiswa").Where("requested_by = ?", dosenID)
+	if status := c.Query("status"); status != "" {
+		query = query.Where("status = ?", status)
+	}
+
+	var corrections []models.GradeCorrection
+	if err := query.Order("created_at DESC").Find(&corrections).Error; err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to fetch grade corrections")
+		return
+	}
+
+	utils.SuccessResponse(c, corrections)
+}
+
+// GetCorrections - Koreksi nilai dari dosen jurusan kajur (default yang menunggu persetujuan)
+func (gc *GradeCorrectionController) GetCorrections(c *gin.Context) {
+	principal := middleware.GetPrincipal(c)
+
+	status := c.DefaultQuery("status", models.CorrectionPending)
+	var corrections []models.GradeCorrection
+	if err := config.DB.Preload("Nilai.Course").Preload("Nilai.Mahasiswa").
+		Where("jurusan = ? AND status = ?", principal.Jurusan, status).
+		Order("created_at ASC").Find(&corrections).Error; err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to fetch grade corrections")
+		return
+	}
+
+	utils.SuccessResponse(c, gin.H{
+		"total":       len(corrections),
+		"corrections": corrections,
+	})
+}
+
+// ReviewCorrection - Kajur menyetujui (nilai dihitung ulang dan IPK diperbarui) atau menolak koreksi nilai
+func (gc *GradeCorrectionController) ReviewCorrection(c *gin.Context) {
+	principal := middleware.GetPrincipal(c)
+
+	var req models.GradeCorrectionReviewRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid JSON format")
+		return
+	}
+
+	if err := utils.ValidateStruct(req); err != nil {
+		utils.HandleValidationError(c, err)
+		return
+	}
+	if req.Action == "reject" && req.Note == "" {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Catatan wajib diisi saat menolak koreksi")
+		return
+	}
+
+	var correction models.GradeCorrection
+	if err := config.DB.First(&correction, "id = ?", c.Param("id")).Error; err != nil {
+		utils.ErrorResponse(c, http.StatusNotFound, "Grade correction not found")
+		return
+	}
+	if correction.Jurusan != principal.Jurusan {
+		utils.ErrorResponse(c, http.StatusForbidden, "Koreksi nilai bukan dari jurusan Anda")
+		return
+	}
+	if correction.Status != models.CorrectionPending {
+		utils.ErrorResponse(c, http.StatusConflict, "Koreksi nilai sudah diproses")
+		return
+	}
+
+	before := correction
+	now := time.Now()
+	next := models.CorrectionRejected
+	if req.Action == "approve" {
+		next = models.CorrectionApproved
+	}
+
+	err := config.DB.Transaction(func(tx *gorm.DB) error {
+		updates := map[string]interface{}{
+			"status":      next,
+			"reviewed_by": principal.KajurID,
+			"reviewed_at": now,
+			"review_note": req.Note,
+		}
+
+		if next == models.CorrectionApproved {
+			nilai, err := loadCorrectableNilai(tx, correction.NilaiID)
+			if err != nil {
+				return err
+			}
+			nilaiBefore := nilai
+			komponen, err := rescoreNilai(tx, &nilai, correctionScores(correction.Komponen))
+			if err != nil {
+				return err
+			}
+			if err := tx.Omit("Komponen", "KRS", "Mahasiswa", "Course").Save(&nilai).Error; err != nil {
+				return err
+			}
+			if err := saveNilaiKomponen(tx, nilai.ID, komponen); err != nil {
+				return err
+			}
+			if err := refreshMahasiswaIPK(tx, nilai.MahasiswaID); err != nil {
+				return err
+			}
+			nilai.Komponen = komponen
+			if err := middleware.RecordAudit(c, tx, "correct", "nilai", nilai.ID, nilaiBefore, nilai); err != nil {
+				return err
+			}
+
+			// Nilai bisa berubah sejak pengajuan; catat nilai yang benar-benar diganti
+			updates["nilai_akhir_lama"] = nilaiBefore.NilaiAkhir
+			updates["grade_huruf_lama"] = nilaiBefore.GradeHuruf
+			updates["nilai_akhir_baru"] = nilai.NilaiAkhir
+			updates["grade_huruf_baru"] = nilai.GradeHuruf
+		}
+
+		result := tx.Model(&models.GradeCorrection{}).Where("id = ? AND status = ?", correction.ID, models.CorrectionPending).Updates(updates)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return errCorrectionProcessed
+		}
+		if err := tx.First(&correction, correction.ID).Error; err != nil {
+			return err
+		}
+		return middleware.RecordAudit(c, tx, req.Action+"_grade_correction", "grade_correction", correction.ID, before, correction)
+	})
+	if err != nil {
+		switch {
+		case errors.Is(err, errCorrectionProcessed):
+			utils.ErrorResponse(c, http.StatusConflict, "Koreksi nilai sudah diproses oleh request lain, muat ulang data")
+		case errors.Is(err, errUnknownKomponen):
+			utils.ErrorResponse(c, http.StatusConflict, "Skema penilaian berubah sejak koreksi diajukan: "+err.Error())
+		default:
+			utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to process grade correction")
+		}
+		return
+	}
+
+	utils.SuccessResponse(c, gin.H{
+		"message":    "Grade correction " + correction.Status,
+		"correction": correction,
+	})
+}
@@ -0,0 +1,313 @@
+package controllers
+
+import (
+	"SIAku/models"
+	"SIAku/utils"
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Helper impor/ekspor nilai: template per lembar nilai dan pembacaan file CSV/XLSX hasil isian dosen
+
+const gradeImportMaxSize = 2 << 20 // 2 MB
+
+var errUnsupportedGradeFile = errors.New("format file harus .csv atau .xlsx")
+
+// errGradeImportRows - pratinjau ulang di dalam transaksi commit menemukan baris yang tidak valid
+var errGradeImportRows = errors.New("baris impor nilai tidak valid")
+
+// Kolom template yang hanya informasi dan diabaikan saat impor
+var gradeImportIgnoredColumns = map[string]bool{
+	"nama": true, "nilai_akhir": true, "grade": true, "grade_huruf": true,
+}
+
+// gradeSheetRoster mengambil KRS mahasiswa kelas lembar nilai (satu per mahasiswa, percobaan terbaru), urut NIM
+func gradeSheetRoster(db *gorm.DB, sheet models.GradeSheet) ([]models.KRS, error) {
+	var krsList []models.KRS
+	if err := db.Preload("Mahasiswa").Scopes(gradeSheetEnrollment(sheet)).
+		Order("semester DESC, id DESC").Find(&krsList).Error; err != nil {
+		return nil, err
+	}
+
+	seen := map[uint]bool{}
+	roster := make([]models.KRS, 0, len(krsList))
+	for _, krs := range krsList {
+		if seen[krs.MahasiswaID] {
+			continue
+		}
+		seen[krs.MahasiswaID] = true
+		roster = append(roster, krs)
+	}
+	sort.Slice(roster, func(i, j int) bool { return roster[i].Mahasiswa.NIM < roster[j].Mahasiswa.NIM })
+	return roster, nil
+}
+
+// lockRosterNilai mengunci nilai tersimpan mahasiswa kelas (SELECT ... FOR UPDATE) selama transaksi impor/input nilai
+func lockRosterNilai(tx *gorm.DB, sheet models.GradeSheet, roster []models.KRS) error {
+	if len(roster) == 0 {
+		return nil
+	}
+	mahasiswaIDs := make([]uint, 0, len(roster))
+	for _, krs := range roster {
+		mahasiswaIDs = append(mahasiswaIDs, krs.MahasiswaID)
+	}
+	var locked []models.Nilai
+	return tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("mahasiswa_id IN ? AND course_id = ? AND tahun_ajaran = ?", mahasiswaIDs, sheet.CourseID, sheet.TahunAjaran).
+		Find(&locked).Error
+}
+
+// readGradeFile membaca isi file impor nilai sesuai ekstensinya
+func readGradeFile(filename string, data []byte) ([][]string, error) {
+	switch strings.ToLower(filepath.Ext(filename)) {
+	case ".csv":
+		return readGradeCSV(data)
+	case ".xlsx":
+		return utils.ReadXLSX(data)
+	default:
+		return nil, errUnsupportedGradeFile
+	}
+}
+
+// readGradeCSV membaca CSV berpemisah koma atau titik koma (default Excel dengan locale Indonesia)
+func readGradeCSV(data []byte) ([][]string, error) {
+	data = bytes.TrimPrefix(data, []byte("\xef\xbb\xbf"))
+	header := data
+	if i := bytes.IndexByte(data, '\n'); i >= 0 {
+		header = data[:i]
+	}
+
+	reader := csv.NewReader(bytes.NewReader(data))
+	if bytes.Count(header, []byte(";")) > bytes.Count(header, []byte(",")) {
+		reader.Comma = ';'
+	}
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+	return reader.ReadAll()
+}
+
+// gradeImportColumns memetakan header file ke kolom NIM dan kode komponen skema (tidak peka huruf besar/kecil)
+func gradeImportColumns(header []string, scheme []models.GradingComponent) (int, map[int]string, error) {
+	byKode := map[string]string{}
+	for _, component := range scheme {
+		byKode[strings.ToLower(component.Kode)] = component.Kode
+	}
+
+	nimCol := -1
+	columns := map[int]string{}
+	for i, name := range header {
+		key := strings.ToLower(strings.TrimSpace(name))
+		switch {
+		case key == "nim":
+			nimCol = i
+		case byKode[key] != "":
+			columns[i] = byKode[key]
+		case key == "" || gradeImportIgnoredColumns[key]:
+		default:
+			return 0, nil, fmt.Errorf("kolom %q tidak ada di skema penilaian", strings.TrimSpace(name))
+		}
+	}
+	if nimCol < 0 {
+		return 0, nil, errors.New("kolom NIM wajib ada")
+	}
+	if len(columns) == 0 {
+		return 0, nil, errors.New("tidak ada kolom komponen penilaian")
+	}
+	return nimCol, columns, nil
+}
+
+// parseImportScore membaca skor sel (koma desimal diterima); sel kosong berarti skor tersimpan dipertahankan
+func parseImportScore(cell string) (float64, bool, error) {
+	cell = strings.TrimSpace(cell)
+	if cell == "" {
+		return 0, false, nil
+	}
+	skor, err := strconv.ParseFloat(strings.Replace(cell, ",", ".", 1), 64)
+	if err != nil {
+		return 0, false, fmt.Errorf("skor %q bukan angka", cell)
+	}
+	if skor < 0 || skor > 100 {
+		return 0, false, fmt.Errorf("skor %q harus di antara 0 dan 100", cell)
+	}
+	return skor, true, nil
+}
+
+// blankRow mengecek apakah semua sel baris kosong
+func blankRow(row []string) bool {
+	for _, cell := range row {
+		if strings.TrimSpace(cell) != "" {
+			return false
+		}
+	}
+	return true
+}
+
+// cellAt mengambil sel baris; baris CSV/XLSX bisa lebih pendek dari header
+func cellAt(row []string, i int) string {
+	if i < len(row) {
+		return row[i]
+	}
+	return ""
+}
+
+// formatScore menulis skor tanpa nol di belakang untuk template CSV
+func formatScore(skor float64) string {
+	return strconv.FormatFloat(skor, 'f', -1, 64)
+}
+
+// gradeImportWrite - nilai hasil pratinjau yang akan disimpan saat impor di-commit
+type gradeImportWrite struct {
+	nilai    models.Nilai
+	before   models.Nilai
+	isNew    bool
+	komponen []models.NilaiKomponen
+}
+
+// previewGradeImport memvalidasi baris file terhadap daftar mahasiswa kelas dan menghitung nilai baru
+// beserta perbedaannya dengan nilai tersimpan. Tidak ada yang ditulis ke database.
+func previewGradeImport(db *gorm.DB, sheet models.GradeSheet, scheme []models.GradingComponent, roster []models.KRS, rows [][]string, nimCol int, columns map[int]string) (models.GradeImportResult, []gradeImportWrite, error) {
+	result := models.GradeImportResult{
+		GradeSheetID: sheet.ID,
+		CourseID:     sheet.CourseID,
+		TahunAjaran:  sheet.TahunAjaran,
+		KelasID:      sheet.KelasID,
+		Rows:         []models.GradeImportRow{},
+	}
+	cols := make([]int, 0, len(columns))
+	for col := range columns {
+		cols = append(cols, col)
+	}
+	sort.Ints(cols)
+	for _, col := range cols {
+		result.Komponen = append(result.Komponen, columns[col])
+	}
+
+	byNIM := map[string]models.KRS{}
+	for _, krs := range roster {
+		byNIM[krs.Mahasiswa.NIM] = krs
+	}
+
+	scales := newGradeScaleCache(db)
+	seen := map[string]int{}
+	var writes []gradeImportWrite
+	for i, cells := range rows[1:] {
+		if blankRow(cells) {
+			continue
+		}
+		row := models.GradeImportRow{Row: i + 2, NIM: strings.TrimSpace(cellAt(cells, nimCol))}
+
+		input := map[string]float64{}
+		for _, col := range cols {
+			skor, ok, err := parseImportScore(cellAt(cells, col))
+			if err != nil {
+				row.Errors = append(row.Errors, columns[col]+": "+err.Error())
+			} else if ok {
+				input[columns[col]] = skor
+			}
+		}
+
+		krs, enrolled := byNIM[row.NIM]
+		switch {
+		case row.NIM == "":
+			row.Errors = append(row.Errors, "NIM kosong")
+		case seen[row.NIM] > 0:
+			row.Errors = append(row.Errors, fmt.Sprintf("NIM sudah ada di baris %d", seen[row.NIM]))
+			enrolled = false
+		case !enrolled:
+			row.Errors = append(row.Errors, "mahasiswa tidak terdaftar di kelas ini")
+		default:
+			seen[row.NIM] = row.Row
+		}
+
+		if enrolled {
+			row.Nama = krs.Mahasiswa.Nama
+			row.MahasiswaID = krs.MahasiswaID
+
+			nilai, isNew, err := attemptNilai(db, krs)
+			if err != nil {
+				return result, nil, err
+			}
+			if nilai.Status == models.NilaiPublished {
+				row.Errors = append(row.Errors, "nilai sudah dipublikasikan, ajukan koreksi nilai")
+			}
+			if !isNew {
+				lama := nilai.NilaiAkhir
+				row.NilaiAkhirLama = &lama
+				row.GradeHurufLama = nilai.GradeHuruf
+			}
+
+			if len(row.Errors) == 0 && len(input) > 0 {
+				scale, err := scales.forNilai(nilai, krs.Mahasiswa)
+				if err != nil {
+					return result, nil, err
+				}
+				before := nilai
+				stored := storedScores(nilai)
+				komponen := scoreNilai(&nilai, scheme, scale, stored, input)
+
+				lamaByKode := map[string]float64{}
+				if !isNew {
+					for _, s := range stored {
+						lamaByKode[s.Kode] = s.Skor
+					}
+				}
+				for _, kode := range result.Komponen {
+					baru, ok := input[kode]
+					if !ok {
+						continue
+					}
+					change := models.GradeImportChange{Kode: kode, SkorBaru: baru}
+					if lama, ok := lamaByKode[kode]; ok {
+						if lama == baru {
+							continue
+						}
+						change.SkorLama = &lama
+					}
+					row.Changes = append(row.Changes, change)
+				}
+
+				baru := nilai.NilaiAkhir
+				row.NilaiAkhirBaru = &baru
+				row.GradeHurufBaru = nilai.GradeHuruf
+				switch {
+				case isNew:
+					row.Status = models.ImportRowNew
+				case len(row.Changes) > 0 || before.Status != models.NilaiDraft:
+					row.Status = models.ImportRowUpdate
+				default:
+					row.Status = models.ImportRowUnchanged
+				}
+				if row.Status != models.ImportRowUnchanged {
+					writes = append(writes, gradeImportWrite{nilai: nilai, before: before, isNew: isNew, komponen: komponen})
+				}
+			}
+		}
+
+		switch {
+		case len(row.Errors) > 0:
+			row.Status = models.ImportRowError
+			result.Errors++
+		case row.Status == "":
+			row.Status = models.ImportRowSkipped
+			result.Skipped++
+		case row.Status == models.ImportRowNew:
+			result.New++
+		case row.Status == models.ImportRowUpdate:
+			result.Updated++
+		default:
+			result.Unchanged++
+		}
+		result.Rows = append(result.Rows, row)
+	}
+	result.TotalRows = len(result.Rows)
+	return result, writes, nil
+}
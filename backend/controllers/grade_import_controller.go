@@ -0,0 +1,279 @@
+package controllers
+
+import (
+	"SIAku/config"
+	"SIAku/middleware"
+	"SIAku/models"
+	"SIAku/utils"
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+type GradeImportController struct{}
+
+func NewGradeImportController() *GradeImportController {
+	return &GradeImportController{}
+}
+
+// findImportSheet memuat mata kuliah, lembar nilai dan skema penilaian kelas yang dipegang dosen
+func findImportSheet(c *gin.Context, tahunAjaran, kelasValue string) (models.Course, models.GradeSheet, []models.GradingComponent, bool) {
+	principal := middleware.GetPrincipal(c)
+
+	var kelasID *uint
+	if kelasValue != "" {
+		id, err := strconv.ParseUint(kelasValue, 10, 32)
+		if err != nil {
+			utils.ErrorResponse(c, http.StatusBadRequest, "Invalid kelas_id")
+			return models.Course{}, models.GradeSheet{}, nil, false
+		}
+		parsed := uint(id)
+		kelasID = &parsed
+	}
+
+	course, kelas, ok := findGradeSheetClass(c, principal.DosenID, tahunAjaran, kelasID)
+	if !ok {
+		return course, models.GradeSheet{}, nil, false
+	}
+
+	sheet, err := gradeSheetFor(config.DB, course.ID, tahunAjaran, kelas, principal.Jurusan)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to fetch grade sheet")
+		return course, sheet, nil, false
+	}
+	scheme, _, err := gradingScheme(config.DB, course.ID, tahunAjaran, kelasID)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to fetch grading scheme")
+		return course, sheet, nil, false
+	}
+	return course, sheet, scheme, true
+}
+
+// DownloadTemplate - Template nilai kelas (CSV/XLSX) berisi NIM mahasiswa terdaftar dan skor yang sudah diisi
+func (gc *GradeImportController) DownloadTemplate(c *gin.Context) {
+	tahunAjaran := c.DefaultQuery("tahun_ajaran", getCurrentAcademicYear())
+	format := strings.ToLower(c.DefaultQuery("format", "xlsx"))
+	if format != "csv" && format != "xlsx" {
+		utils.ErrorResponse(c, http.StatusBadRequest, "format harus csv atau xlsx")
+		return
+	}
+
+	course, sheet, scheme, ok := findImportSheet(c, tahunAjaran, c.Query("kelas_id"))
+	if !ok {
+		return
+	}
+
+	roster, err := gradeSheetRoster(config.DB, sheet)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to fetch students")
+		return
+	}
+
+	header := []interface{}{"NIM", "Nama"}
+	for _, component := range scheme {
+		header = append(header, component.Kode)
+	}
+	rows := [][]interface{}{header}
+	for _, krs := range roster {
+		nilai, _, err := attemptNilai(config.DB, krs)
+		if err != nil {
+			utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to fetch grades")
+			return
+		}
+		scores := map[string]float64{}
+		for _, s := range storedScores(nilai) {
+			scores[s.Kode] = s.Skor
+		}
+
+		row := []interface{}{krs.Mahasiswa.NIM, krs.Mahasiswa.Nama}
+		for _, component := range scheme {
+			if skor, ok := scores[component.Kode]; ok {
+				row = append(row, skor)
+			} else {
+				row = append(row, nil)
+			}
+		}
+		rows = append(rows, row)
+	}
+
+	filename := fmt.Sprintf("nilai_%s_%s", course.Code, strings.ReplaceAll(tahunAjaran, "/", "-"))
+	if sheet.KelasID != 0 {
+		filename += fmt.Sprintf("_kelas%d", sheet.KelasID)
+	}
+
+	var data []byte
+	contentType := "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+	if format == "csv" {
+		var buf bytes.Buffer
+		writer := csv.NewWriter(&buf)
+		for _, row := range rows {
+			record := make([]string, len(row))
+			for i, cell := range row {
+				switch v := cell.(type) {
+				case string:
+					record[i] = v
+				case float64:
+					record[i] = formatScore(v)
+				}
+			}
+			writer.Write(record)
+		}
+		writer.Flush()
+		if err := writer.Error(); err != nil {
+			utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to generate template")
+			return
+		}
+		data = buf.Bytes()
+		contentType = "text/csv; charset=utf-8"
+	} else {
+		data, err = utils.WriteXLSX("Nilai", rows)
+		if err != nil {
+			utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to generate template")
+			return
+		}
+	}
+
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="%s.%s"`, filename, format))
+	c.Data(http.StatusOK, contentType, data)
+}
+
+// ImportGrades - Unggah nilai kelas (multipart field "file", .csv/.xlsx). Default hanya pratinjau;
+// commit=true menyimpan semua baris dalam satu transaksi dan ditolak jika ada baris yang error.
+func (gc *GradeImportController) ImportGrades(c *gin.Context) {
+	tahunAjaran := c.DefaultPostForm("tahun_ajaran", getCurrentAcademicYear())
+	commit := false
+	if value := c.PostForm("commit"); value != "" {
+		parsed, err := strconv.ParseBool(value)
+		if err != nil {
+			utils.ErrorResponse(c, http.StatusBadRequest, "Invalid commit flag")
+			return
+		}
+		commit = parsed
+	}
+
+	course, sheet, scheme, ok := findImportSheet(c, tahunAjaran, c.PostForm("kelas_id"))
+	if !ok {
+		return
+	}
+	if sheet.Status != models.GradeSheetDraft {
+		utils.ErrorResponse(c, http.StatusConflict, "Lembar nilai kelas sudah diajukan, nilai tidak dapat diubah")
+		return
+	}
+
+	file, err := c.FormFile("file")
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "File is required")
+		return
+	}
+	if file.Size > gradeImportMaxSize {
+		utils.ErrorResponse(c, http.StatusBadRequest, "File size exceeds 2 MB")
+		return
+	}
+	src, err := file.Open()
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Failed to read file")
+		return
+	}
+	data, err := io.ReadAll(io.LimitReader(src, gradeImportMaxSize))
+	src.Close()
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Failed to read file")
+		return
+	}
+
+	rows, err := readGradeFile(file.Filename, data)
+	if err != nil {
+		if errors.Is(err, errUnsupportedGradeFile) {
+			utils.ErrorResponse(c, http.StatusBadRequest, "Format file harus .csv atau .xlsx")
+			return
+		}
+		utils.ErrorResponse(c, http.StatusBadRequest, "File tidak dapat dibaca: "+err.Error())
+		return
+	}
+	if len(rows) < 2 {
+		utils.ErrorResponse(c, http.StatusBadRequest, "File tidak berisi baris nilai")
+		return
+	}
+	nimCol, columns, err := gradeImportColumns(rows[0], scheme)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Header tidak valid: "+err.Error())
+		return
+	}
+
+	roster, err := gradeSheetRoster(config.DB, sheet)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to fetch students")
+		return
+	}
+	result, _, err := previewGradeImport(config.DB, sheet, scheme, roster, rows, nimCol, columns)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to preview grade import")
+		return
+	}
+	if !commit {
+		utils.SuccessResponse(c, result)
+		return
+	}
+	if result.Errors > 0 {
+		utils.ErrorResponseWithData(c, http.StatusUnprocessableEntity,
+			fmt.Sprintf("%d baris tidak valid, tidak ada nilai yang disimpan", result.Errors), result)
+		return
+	}
+
+	// Semua baris disimpan sekaligus. Lembar nilai dicek ulang dan nilai mahasiswa dikunci di dalam
+	// transaksi, lalu pratinjau dihitung ulang agar input nilai yang masuk bersamaan tidak tertimpa.
+	err = config.DB.Transaction(func(tx *gorm.DB) error {
+		if err := requireDraftSheet(tx, sheet.CourseID, sheet.TahunAjaran, &sheet.KelasID); err != nil {
+			return err
+		}
+		if err := lockRosterNilai(tx, sheet, roster); err != nil {
+			return err
+		}
+		preview, writes, err := previewGradeImport(tx, sheet, scheme, roster, rows, nimCol, columns)
+		if err != nil {
+			return err
+		}
+		result = preview
+		if result.Errors > 0 {
+			return errGradeImportRows
+		}
+		for i := range writes {
+			w := &writes[i]
+			if err := saveDraftNilai(c, tx, &w.nilai, w.before, w.isNew, w.komponen); err != nil {
+				return err
+			}
+		}
+		return middleware.RecordAudit(c, tx, "import_grades", "grade_sheet", sheet.ID, nil, gin.H{
+			"file":      file.Filename,
+			"course_id": course.ID,
+			"new":       result.New,
+			"updated":   result.Updated,
+			"unchanged": result.Unchanged,
+			"skipped":   result.Skipped,
+		})
+	})
+	if err != nil {
+		if errors.Is(err, errGradeSheetLocked) {
+			utils.ErrorResponse(c, http.StatusConflict, "Lembar nilai kelas sudah diajukan, nilai tidak dapat diubah")
+			return
+		}
+		if errors.Is(err, errGradeImportRows) {
+			utils.ErrorResponseWithData(c, http.StatusUnprocessableEntity,
+				fmt.Sprintf("%d baris tidak valid, tidak ada nilai yang disimpan", result.Errors), result)
+			return
+		}
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to import grades")
+		return
+	}
+
+	result.Committed = true
+	utils.SuccessResponse(c, result)
+}
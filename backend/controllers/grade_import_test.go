@@ -0,0 +1,169 @@
+package controllers
+
+import (
+	"SIAku/models"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestReadGradeCSV(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+		want [][]string
+	}{
+		{
+			name: "koma",
+			data: "NIM,uts,uas\n001,80,90\n",
+			want: [][]string{{"NIM", "uts", "uas"}, {"001", "80", "90"}},
+		},
+		{
+			name: "titik koma dengan koma desimal",
+			data: "NIM;uts;uas\r\n001;80,5;90\r\n",
+			want: [][]string{{"NIM", "uts", "uas"}, {"001", "80,5", "90"}},
+		},
+		{
+			name: "BOM dan spasi di depan sel",
+			data: "\xef\xbb\xbfNIM, uts\n001, 75\n",
+			want: [][]string{{"NIM", "uts"}, {"001", "75"}},
+		},
+		{
+			name: "jumlah kolom berbeda",
+			data: "NIM;Nama;uts\n001;Ani\n",
+			want: [][]string{{"NIM", "Nama", "uts"}, {"001", "Ani"}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := readGradeCSV([]byte(tt.data))
+			if err != nil {
+				t.Fatalf("readGradeCSV: %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+
+	if _, err := readGradeFile("nilai.txt", []byte("NIM\n")); err != errUnsupportedGradeFile {
+		t.Errorf("readGradeFile(.txt) err = %v, want errUnsupportedGradeFile", err)
+	}
+}
+
+func TestGradeImportColumns(t *testing.T) {
+	scheme := []models.GradingComponent{{Kode: "tugas"}, {Kode: "UTS"}, {Kode: "uas"}}
+
+	tests := []struct {
+		name     string
+		header   []string
+		wantNIM  int
+		wantCols map[int]string
+		wantErr  string
+	}{
+		{
+			name:     "template lengkap",
+			header:   []string{"NIM", "Nama", "tugas", "UTS", "uas", "nilai_akhir", "grade"},
+			wantNIM:  0,
+			wantCols: map[int]string{2: "tugas", 3: "UTS", 4: "uas"},
+		},
+		{
+			name:     "huruf besar/kecil dan kolom kosong",
+			header:   []string{"Nama", " nim ", "", "uts"},
+			wantNIM:  1,
+			wantCols: map[int]string{3: "UTS"},
+		},
+		{name: "kolom di luar skema", header: []string{"NIM", "kuis"}, wantErr: `kolom "kuis"`},
+		{name: "tanpa NIM", header: []string{"Nama", "uts"}, wantErr: "kolom NIM wajib ada"},
+		{name: "tanpa komponen", header: []string{"NIM", "Nama"}, wantErr: "tidak ada kolom komponen"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			nimCol, cols, err := gradeImportColumns(tt.header, scheme)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("got %v, want error containing %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if nimCol != tt.wantNIM || !reflect.DeepEqual(cols, tt.wantCols) {
+				t.Errorf("got (%d, %v), want (%d, %v)", nimCol, cols, tt.wantNIM, tt.wantCols)
+			}
+		})
+	}
+}
+
+func TestParseImportScore(t *testing.T) {
+	tests := []struct {
+		cell    string
+		want    float64
+		wantOK  bool
+		wantErr bool
+	}{
+		{cell: "80", want: 80, wantOK: true},
+		{cell: " 80.5 ", want: 80.5, wantOK: true},
+		{cell: "80,5", want: 80.5, wantOK: true},
+		{cell: "0", want: 0, wantOK: true},
+		{cell: "100", want: 100, wantOK: true},
+		{cell: ""},
+		{cell: "   "},
+		{cell: "100.01", wantErr: true},
+		{cell: "-1", wantErr: true},
+		{cell: "A", wantErr: true},
+		{cell: "1,000,5", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.cell, func(t *testing.T) {
+			got, ok, err := parseImportScore(tt.cell)
+			if (err != nil) != tt.wantErr || ok != tt.wantOK || got != tt.want {
+				t.Errorf("parseImportScore(%q) = (%v, %v, %v), want (%v, %v, err=%v)",
+					tt.cell, got, ok, err, tt.want, tt.wantOK, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestPreviewGradeImportRowErrors(t *testing.T) {
+	scheme := []models.GradingComponent{{Kode: "uts", Bobot: 40}, {Kode: "uas", Bobot: 60}}
+	sheet := models.GradeSheet{ID: 3, CourseID: 5, TahunAjaran: "2025/2026", KelasID: 2}
+	rows := [][]string{
+		{"NIM", "uts", "uas"},
+		{"", "80", "90"},
+		{"999", "80", "90"},
+		{"", "", ""},
+		{"888", "abc", "101"},
+	}
+
+	// Roster kosong: tidak ada baris yang sampai ke database
+	result, writes, err := previewGradeImport(nil, sheet, scheme, nil, rows, 0, map[int]string{1: "uts", 2: "uas"})
+	if err != nil {
+		t.Fatalf("previewGradeImport: %v", err)
+	}
+	if len(writes) != 0 {
+		t.Errorf("got %d writes, want 0", len(writes))
+	}
+	if result.GradeSheetID != 3 || result.KelasID != 2 || !reflect.DeepEqual(result.Komponen, []string{"uts", "uas"}) {
+		t.Errorf("unexpected result header: %+v", result)
+	}
+	if result.TotalRows != 3 || result.Errors != 3 {
+		t.Fatalf("got total %d errors %d, want 3 and 3", result.TotalRows, result.Errors)
+	}
+
+	want := []struct {
+		row    int
+		errors []string
+	}{
+		{row: 2, errors: []string{"NIM kosong"}},
+		{row: 3, errors: []string{"mahasiswa tidak terdaftar di kelas ini"}},
+		{row: 5, errors: []string{`uts: skor "abc" bukan angka`, `uas: skor "101" harus di antara 0 dan 100`, "mahasiswa tidak terdaftar di kelas ini"}},
+	}
+	for i, w := range want {
+		got := result.Rows[i]
+		if got.Row != w.row || got.Status != models.ImportRowError || !reflect.DeepEqual(got.Errors, w.errors) {
+			t.Errorf("row %d: got %+v, want row %d errors %q", i, got, w.row, w.errors)
+		}
+	}
+}
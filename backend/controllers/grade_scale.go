@@ -0,0 +1,110 @@
+package controllers
+
+import (
+	"SIAku/models"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+// Helper skala nilai: memilih skala yang berlaku untuk mahasiswa dan memuat skala yang tercatat pada nilai
+
+// mahasiswaAngkatan memakai kolom angkatan, atau tahun pendaftaran jika belum diisi
+func mahasiswaAngkatan(mahasiswa models.Mahasiswa) int {
+	if mahasiswa.Angkatan > 0 {
+		return mahasiswa.Angkatan
+	}
+	return mahasiswa.CreatedAt.Year()
+}
+
+// gradeScaleFor memilih versi terbaru skala jurusan mahasiswa (lalu skala umum) dengan angkatan mulai terdekat.
+// Jika belum ada skala tersimpan, dipakai konversi standar tanpa ID.
+func gradeScaleFor(db *gorm.DB, mahasiswa models.Mahasiswa) (models.GradeScale, error) {
+	var scale models.GradeScale
+	err := db.Preload("Items").
+		Where("jurusan IN ? AND angkatan_mulai <= ?", []string{mahasiswa.Jurusan, ""}, mahasiswaAngkatan(mahasiswa)).
+		Order("jurusan DESC, angkatan_mulai DESC, versi DESC").
+		First(&scale).Error
+	if err == gorm.ErrRecordNotFound {
+		return models.GradeScale{Nama: "Skala Standar", Items: models.DefaultGradeScaleItems}, nil
+	}
+	return scale, err
+}
+
+// gradeScaleCache menyimpan skala per ID atau per mahasiswa selama satu proses hitung ulang
+type gradeScaleCache struct {
+	db          *gorm.DB
+	byID        map[uint]models.GradeScale
+	byMahasiswa map[uint]models.GradeScale
+}
+
+func newGradeScaleCache(db *gorm.DB) *gradeScaleCache {
+	return &gradeScaleCache{db: db, byID: map[uint]models.GradeScale{}, byMahasiswa: map[uint]models.GradeScale{}}
+}
+
+// forNilai memakai skala yang sudah tercatat pada nilai supaya grade lama tidak ikut berubah,
+// dan memilih skala yang berlaku untuk mahasiswa jika nilai belum punya skala
+func (gc *gradeScaleCache) forNilai(nilai models.Nilai, mahasiswa models.Mahasiswa) (models.GradeScale, error) {
+	if nilai.GradeScaleID != nil {
+		if scale, ok := gc.byID[*nilai.GradeScaleID]; ok {
+			return scale, nil
+		}
+		var scale models.GradeScale
+		err := gc.db.Preload("Items").First(&scale, *nilai.GradeScaleID).Error
+		if err == nil {
+			gc.byID[scale.ID] = scale
+			return scale, nil
+		}
+		if err != gorm.ErrRecordNotFound {
+			return scale, err
+		}
+	}
+
+	if scale, ok := gc.byMahasiswa[mahasiswa.ID]; ok {
+		return scale, nil
+	}
+	scale, err := gradeScaleFor(gc.db, mahasiswa)
+	if err != nil {
+		return scale, err
+	}
+	gc.byMahasiswa[mahasiswa.ID] = scale
+	return scale, nil
+}
+
+// applyGradeScale mengisi grade dari nilai akhir dan mencatat skala yang dipakai
+func applyGradeScale(nilai *models.Nilai, scale models.GradeScale) {
+	nilai.GradeHuruf, nilai.GradePoint = scale.Convert(nilai.NilaiAkhir)
+	nilai.GradeScaleID = nil
+	if scale.ID != 0 {
+		id := scale.ID
+		nilai.GradeScaleID = &id
+	}
+}
+
+// normalizeGradeScaleItems mengurutkan item dari batas tertinggi dan menormalkan huruf (mis. "a-" menjadi "A-").
+// Huruf dan batas bawah harus unik, harus ada batas 0, dan bobot tidak boleh naik saat batas turun.
+func normalizeGradeScaleItems(req []models.GradeScaleItemRequest) ([]models.GradeScaleItemRequest, error) {
+	items := append([]models.GradeScaleItemRequest(nil), req...)
+	sort.Slice(items, func(i, j int) bool { return items[i].MinNilai > items[j].MinNilai })
+	seenHuruf := map[string]bool{}
+	for i := range items {
+		items[i].Huruf = strings.ToUpper(strings.TrimSpace(items[i].Huruf))
+		if seenHuruf[items[i].Huruf] {
+			return nil, fmt.Errorf("Duplicate huruf %s", items[i].Huruf)
+		}
+		seenHuruf[items[i].Huruf] = true
+		if i > 0 && items[i].MinNilai == items[i-1].MinNilai {
+			return nil, errors.New("Duplicate min_nilai in items")
+		}
+		if i > 0 && items[i].Bobot > items[i-1].Bobot {
+			return nil, fmt.Errorf("Bobot %s lebih tinggi dari huruf dengan batas nilai di atasnya", items[i].Huruf)
+		}
+	}
+	if len(items) == 0 || items[len(items)-1].MinNilai != 0 {
+		return nil, errors.New("Items must include min_nilai 0")
+	}
+	return items, nil
+}
@@ -0,0 +1,106 @@
+package controllers
+
+import (
+	"SIAku/config"
+	"SIAku/middleware"
+	"SIAku/models"
+	"SIAku/utils"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+type GradeScaleController struct{}
+
+func NewGradeScaleController() *GradeScaleController {
+	return &GradeScaleController{}
+}
+
+// GetScales - Semua versi skala nilai (kajur: jurusannya dan skala umum)
+func (gc *GradeScaleController) GetScales(c *gin.Context) {
+	principal := middleware.GetPrincipal(c)
+	jurusan := c.Query("jurusan")
+	if principal.Role == "kajur" {
+		jurusan = principal.Jurusan
+	}
+
+	var scales []models.GradeScale
+	query := config.DB.Preload("Items", func(db *gorm.DB) *gorm.DB {
+		return db.Order("min_nilai DESC")
+	}).Order("jurusan ASC, angkatan_mulai DESC, versi DESC")
+	if jurusan != "" {
+		query = query.Where("jurusan IN ?", []string{jurusan, ""})
+	}
+	if err := query.Find(&scales).Error; err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to fetch grade scales")
+		return
+	}
+
+	utils.SuccessResponse(c, gin.H{
+		"scales":            scales,
+		"built_in_defaults": models.DefaultGradeScaleItems,
+	})
+}
+
+// CreateScale - Simpan versi baru skala nilai; versi lama tetap dipakai nilai yang sudah dihitung dengannya
+func (gc *GradeScaleController) CreateScale(c *gin.Context) {
+	var req models.CreateGradeScaleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid JSON format")
+		return
+	}
+
+	if err := utils.ValidateStruct(req); err != nil {
+		utils.HandleValidationError(c, err)
+		return
+	}
+
+	principal := middleware.GetPrincipal(c)
+	jurusan := req.Jurusan
+	if principal.Role == "kajur" {
+		if jurusan != "" && jurusan != principal.Jurusan {
+			utils.ErrorResponse(c, http.StatusForbidden, "Kajur hanya dapat mengatur skala nilai jurusannya")
+			return
+		}
+		jurusan = principal.Jurusan
+	}
+
+	items, err := normalizeGradeScaleItems(req.Items)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	scale := models.GradeScale{
+		Nama:          req.Nama,
+		Jurusan:       jurusan,
+		AngkatanMulai: req.AngkatanMulai,
+		CreatedBy:     &principal.UserID,
+	}
+	for _, item := range items {
+		scale.Items = append(scale.Items, models.GradeScaleItem{Huruf: item.Huruf, MinNilai: item.MinNilai, Bobot: item.Bobot})
+	}
+
+	err = config.DB.Transaction(func(tx *gorm.DB) error {
+		var latest int
+		if err := tx.Model(&models.GradeScale{}).Where("jurusan = ? AND angkatan_mulai = ?", jurusan, req.AngkatanMulai).
+			Select("COALESCE(MAX(versi), 0)").Scan(&latest).Error; err != nil {
+			return err
+		}
+		scale.Versi = latest + 1
+		if err := tx.Create(&scale).Error; err != nil {
+			return err
+		}
+		return middleware.RecordAudit(c, tx, "create_grade_scale", "grade_scale", scale.ID, nil, scale)
+	})
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to save grade scale")
+		return
+	}
+
+	utils.CreatedResponse(c, gin.H{
+		"message": "Grade scale created",
+		"scale":   scale,
+	})
+}
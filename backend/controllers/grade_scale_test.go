@@ -0,0 +1,87 @@
+package controllers
+
+import (
+	"SIAku/models"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestNormalizeGradeScaleItems(t *testing.T) {
+	item := func(huruf string, min, bobot float64) models.GradeScaleItemRequest {
+		return models.GradeScaleItemRequest{Huruf: huruf, MinNilai: min, Bobot: bobot}
+	}
+
+	tests := []struct {
+		name      string
+		items     []models.GradeScaleItemRequest
+		wantHuruf []string
+		wantErr   string
+	}{
+		{
+			name:      "plus/minus dinormalkan dan diurutkan",
+			items:     []models.GradeScaleItemRequest{item(" b+", 75, 3.3), item("E", 0, 0), item("a-", 80, 3.7), item("A", 85, 4)},
+			wantHuruf: []string{"A", "A-", "B+", "E"},
+		},
+		{
+			name:    "huruf ganda setelah dinormalkan",
+			items:   []models.GradeScaleItemRequest{item("A-", 80, 3.7), item("a-", 70, 3), item("E", 0, 0)},
+			wantErr: "Duplicate huruf A-",
+		},
+		{
+			name:    "batas bawah ganda",
+			items:   []models.GradeScaleItemRequest{item("A", 80, 4), item("A-", 80, 3.7), item("E", 0, 0)},
+			wantErr: "Duplicate min_nilai",
+		},
+		{
+			name:    "bobot naik saat batas turun",
+			items:   []models.GradeScaleItemRequest{item("A-", 80, 3.7), item("B+", 75, 3.8), item("E", 0, 0)},
+			wantErr: "Bobot B+ lebih tinggi",
+		},
+		{
+			name:    "tanpa batas 0",
+			items:   []models.GradeScaleItemRequest{item("A", 85, 4), item("B+", 75, 3.3)},
+			wantErr: "min_nilai 0",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			items, err := normalizeGradeScaleItems(tt.items)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("got %v, want error containing %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			var huruf []string
+			for _, item := range items {
+				huruf = append(huruf, item.Huruf)
+			}
+			if !reflect.DeepEqual(huruf, tt.wantHuruf) {
+				t.Errorf("got %v, want %v", huruf, tt.wantHuruf)
+			}
+		})
+	}
+}
+
+func TestApplyGradeScaleRecordsScale(t *testing.T) {
+	scale := models.GradeScale{ID: 7, Items: []models.GradeScaleItem{
+		{Huruf: "A", MinNilai: 85, Bobot: 4}, {Huruf: "A-", MinNilai: 80, Bobot: 3.7},
+		{Huruf: "B+", MinNilai: 75, Bobot: 3.3}, {Huruf: "E", MinNilai: 0, Bobot: 0},
+	}}
+
+	nilai := models.Nilai{NilaiAkhir: 82.5}
+	applyGradeScale(&nilai, scale)
+	if nilai.GradeHuruf != "A-" || nilai.GradePoint != 3.7 || nilai.GradeScaleID == nil || *nilai.GradeScaleID != 7 {
+		t.Errorf("got %s/%v/%v, want A-/3.7/7", nilai.GradeHuruf, nilai.GradePoint, nilai.GradeScaleID)
+	}
+
+	// Skala standar (tanpa ID) tidak dicatat
+	applyGradeScale(&nilai, models.GradeScale{Items: models.DefaultGradeScaleItems})
+	if nilai.GradeHuruf != "AB" || nilai.GradeScaleID != nil {
+		t.Errorf("got %s/%v, want AB/nil", nilai.GradeHuruf, nilai.GradeScaleID)
+	}
+}
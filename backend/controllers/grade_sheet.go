@@ -0,0 +1,193 @@
+package controllers
+
+import (
+	"SIAku/config"
+	"SIAku/middleware"
+	"SIAku/models"
+	"SIAku/utils"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Helper lembar nilai: satu lembar per mata kuliah, tahun ajaran dan kelas paralel
+
+var (
+	errGradeSheetLocked            = errors.New("grade sheet is locked")
+	errIllegalGradeSheetTransition = errors.New("illegal grade sheet transition")
+	errGradeSheetStatusChanged     = errors.New("grade sheet status changed concurrently")
+)
+
+// krsKelasID mengubah kelas KRS menjadi kunci lembar nilai (0 = tanpa kelas paralel)
+func krsKelasID(krs *models.KRS) uint {
+	if krs == nil || krs.KelasID == nil {
+		return 0
+	}
+	return *krs.KelasID
+}
+
+// gradeSheetFor mengambil lembar nilai kelas, membuat draft baru jika belum ada
+func gradeSheetFor(db *gorm.DB, courseID uint, tahunAjaran string, kelasID uint, jurusan string) (models.GradeSheet, error) {
+	sheet := models.GradeSheet{CourseID: courseID, TahunAjaran: tahunAjaran, KelasID: kelasID, Jurusan: jurusan, Status: models.GradeSheetDraft}
+	if err := db.Clauses(clause.OnConflict{DoNothing: true}).Create(&sheet).Error; err != nil {
+		return sheet, err
+	}
+	err := db.Where("course_id = ? AND tahun_ajaran = ? AND kelas_id = ?", courseID, tahunAjaran, kelasID).First(&sheet).Error
+	return sheet, err
+}
+
+// gradeSheetNilai membatasi query nilai ke nilai milik lembar (kelas diambil dari KRS nilai)
+func gradeSheetNilai(sheet models.GradeSheet) func(*gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		return db.Where("nilais.course_id = ? AND nilais.tahun_ajaran = ? AND COALESCE((SELECT kelas_id FROM krs WHERE krs.id = nilais.krs_id), 0) = ?",
+			sheet.CourseID, sheet.TahunAjaran, sheet.KelasID)
+	}
+}
+
+// gradeSheetEnrollment membatasi query KRS ke mahasiswa yang tervalidasi di kelas lembar nilai
+func gradeSheetEnrollment(sheet models.GradeSheet) func(*gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		db = db.Where("course_id = ? AND tahun_ajaran = ? AND approval_status = ?", sheet.CourseID, sheet.TahunAjaran, models.KRSValidatedKajur)
+		if sheet.KelasID == 0 {
+			return db.Where("kelas_id IS NULL")
+		}
+		return db.Where("kelas_id = ?", sheet.KelasID)
+	}
+}
+
+// publishedNilai menyembunyikan nilai yang belum dipublikasikan dari mahasiswa
+func publishedNilai(db *gorm.DB) *gorm.DB {
+	return db.Where("nilais.status <> ?", models.NilaiDraft)
+}
+
+// gradeSheetSummary menghitung jumlah mahasiswa kelas dan yang sudah dinilai
+func gradeSheetSummary(db *gorm.DB, sheet models.GradeSheet) (models.GradeSheetResponse, error) {
+	summary := models.GradeSheetResponse{GradeSheet: sheet}
+
+	var enrolled []uint
+	if err := db.Model(&models.KRS{}).Scopes(gradeSheetEnrollment(sheet)).Distinct().Pluck("mahasiswa_id", &enrolled).Error; err != nil {
+		return summary, err
+	}
+	var graded int64
+	if len(enrolled) > 0 {
+		if err := db.Model(&models.Nilai{}).Scopes(gradeSheetNilai(sheet)).
+			Where("nilais.mahasiswa_id IN ? AND nilais.status IN ?", enrolled, []string{models.NilaiDraft, models.NilaiPublished}).
+			Distinct("nilais.mahasiswa_id").Count(&graded).Error; err != nil {
+			return summary, err
+		}
+	}
+
+	summary.TotalMahasiswa = len(enrolled)
+	summary.SudahDinilai = int(graded)
+	summary.BelumDinilai = len(enrolled) - int(graded)
+	return summary, nil
+}
+
+// transitionGradeSheet menjalankan aksi lembar nilai dengan update bersyarat pada status lama lalu mencatat audit.
+// Aksi publish sekaligus membuka nilai ke mahasiswa; mengembalikan jumlah nilai yang dipublikasikan.
+func transitionGradeSheet(c *gin.Context, tx *gorm.DB, sheet *models.GradeSheet, role, action, note string) (int, error) {
+	next, err := models.NextGradeSheetStatus(sheet.Status, role, action)
+	if err != nil {
+		return 0, fmt.Errorf("%w: %v", errIllegalGradeSheetTransition, err)
+	}
+
+	principal := middleware.GetPrincipal(c)
+	before := *sheet
+	now := time.Now()
+	published := 0
+
+	updates := map[string]interface{}{"status": next, "note": note}
+	switch next {
+	case models.GradeSheetSubmitted:
+		updates["submitted_by"] = principal.DosenID
+		updates["submitted_at"] = now
+	case models.GradeSheetVerified:
+		updates["verified_by"] = principal.KajurID
+		updates["verified_at"] = now
+	case models.GradeSheetPublished:
+		if published, err = publishGradeSheet(tx, sheet); err != nil {
+			return 0, err
+		}
+		updates["published_at"] = now
+	case models.GradeSheetDraft:
+		updates["verified_by"] = nil
+		updates["verified_at"] = nil
+	}
+
+	result := tx.Model(&models.GradeSheet{}).Where("id = ? AND status = ?", sheet.ID, sheet.Status).Updates(updates)
+	if result.Error != nil {
+		return 0, result.Error
+	}
+	if result.RowsAffected == 0 {
+		return 0, errGradeSheetStatusChanged
+	}
+	if err := tx.Where("id = ?", sheet.ID).First(sheet).Error; err != nil {
+		return 0, err
+	}
+
+	return published, middleware.RecordAudit(c, tx, action+"_grade_sheet", "grade_sheet", sheet.ID, before, sheet)
+}
+
+// respondGradeSheetError memetakan error transisi lembar nilai ke response HTTP
+func respondGradeSheetError(c *gin.Context, err error, fallback string) {
+	switch {
+	case errors.Is(err, errIllegalGradeSheetTransition):
+		utils.ErrorResponse(c, http.StatusConflict, err.Error())
+	case errors.Is(err, errGradeSheetStatusChanged):
+		utils.ErrorResponse(c, http.StatusConflict, "Lembar nilai sudah diproses oleh request lain, muat ulang data")
+	default:
+		utils.ErrorResponse(c, http.StatusInternalServerError, fallback)
+	}
+}
+
+// publishGradeSheet membuka nilai draft lembar ke mahasiswa dan menghitung ulang IPK mereka
+func publishGradeSheet(tx *gorm.DB, sheet *models.GradeSheet) (int, error) {
+	var mahasiswaIDs []uint
+	if err := tx.Model(&models.Nilai{}).Scopes(gradeSheetNilai(*sheet)).Where("nilais.status = ?", models.NilaiDraft).
+		Pluck("nilais.mahasiswa_id", &mahasiswaIDs).Error; err != nil {
+		return 0, err
+	}
+	if err := tx.Model(&models.Nilai{}).Scopes(gradeSheetNilai(*sheet)).Where("nilais.status = ?", models.NilaiDraft).
+		Update("status", models.NilaiPublished).Error; err != nil {
+		return 0, err
+	}
+	for _, mahasiswaID := range mahasiswaIDs {
+		if err := refreshMahasiswaIPK(tx, mahasiswaID); err != nil {
+			return 0, err
+		}
+	}
+	return len(mahasiswaIDs), nil
+}
+
+// requireDraftSheet memastikan nilai kelas masih boleh diubah (lembar belum diajukan).
+// kelasID nil memeriksa semua lembar mata kuliah pada tahun ajaran itu. Di dalam transaksi, baris lembar
+// dikunci (FOR UPDATE) sehingga pengajuan lembar menunggu sampai perubahan nilai selesai.
+func requireDraftSheet(db *gorm.DB, courseID uint, tahunAjaran string, kelasID *uint) error {
+	query := db.Model(&models.GradeSheet{}).Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("course_id = ? AND tahun_ajaran = ?", courseID, tahunAjaran)
+	if kelasID != nil {
+		query = query.Where("kelas_id = ?", *kelasID)
+	}
+	var statuses []string
+	if err := query.Pluck("status", &statuses).Error; err != nil {
+		return err
+	}
+	for _, status := range statuses {
+		if status != models.GradeSheetDraft {
+			return errGradeSheetLocked
+		}
+	}
+	return nil
+}
+
+// loadGradeSheet memuat lembar nilai beserta mata kuliahnya
+func loadGradeSheet(id string) (models.GradeSheet, error) {
+	var sheet models.GradeSheet
+	err := config.DB.Preload("Course").First(&sheet, "id = ?", id).Error
+	return sheet, err
+}
@@ -0,0 +1,229 @@
+package controllers
+
+import (
+	"SIAku/config"
+	"SIAku/middleware"
+	"SIAku/models"
+	"SIAku/utils"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+type GradeSheetController struct{}
+
+func NewGradeSheetController() *GradeSheetController {
+	return &GradeSheetController{}
+}
+
+// findGradeSheetClass memastikan dosen memegang kelas lembar nilai: dosen pengampu utama, atau dosen kelas paralel.
+// Mata kuliah dengan kelas paralel wajib menyebut kelas_id.
+func findGradeSheetClass(c *gin.Context, dosenID uint, tahunAjaran string, kelasID *uint) (models.Course, uint, bool) {
+	var course models.Course
+	if err := config.DB.Scopes(taughtBy(dosenID)).Where("courses.id = ?", c.Param("courseId")).First(&course).Error; err != nil {
+		utils.ErrorResponse(c, http.StatusForbidden, "You are not teaching this course")
+		return course, 0, false
+	}
+	mainDosen := course.DosenID != nil && *course.DosenID == dosenID
+
+	if kelasID == nil {
+		var kelasCount int64
+		config.DB.Model(&models.Kelas{}).Where("course_id = ? AND tahun_ajaran = ?", course.ID, tahunAjaran).Count(&kelasCount)
+		if kelasCount > 0 {
+			utils.ErrorResponse(c, http.StatusBadRequest, "kelas_id wajib diisi untuk mata kuliah dengan kelas paralel")
+			return course, 0, false
+		}
+		if !mainDosen {
+			utils.ErrorResponse(c, http.StatusForbidden, "Hanya dosen pengampu utama yang dapat mengelola lembar nilai mata kuliah ini")
+			return course, 0, false
+		}
+		return course, 0, true
+	}
+
+	var kelas models.Kelas
+	if err := config.DB.Where("id = ? AND course_id = ? AND tahun_ajaran = ?", *kelasID, course.ID, tahunAjaran).
+		First(&kelas).Error; err != nil {
+		utils.ErrorResponse(c, http.StatusNotFound, "Kelas not found for this course and tahun ajaran")
+		return course, 0, false
+	}
+	if !mainDosen && (kelas.DosenID == nil || *kelas.DosenID != dosenID) {
+		utils.ErrorResponse(c, http.StatusForbidden, "Hanya dosen kelas ini yang dapat mengelola lembar nilainya")
+		return course, 0, false
+	}
+	return course, kelas.ID, true
+}
+
+// GetSheet - Status lembar nilai kelas dan jumlah mahasiswa yang sudah/belum dinilai
+func (gc *GradeSheetController) GetSheet(c *gin.Context) {
+	principal := middleware.GetPrincipal(c)
+	tahunAjaran := c.DefaultQuery("tahun_ajaran", getCurrentAcademicYear())
+
+	var kelasID *uint
+	if value := c.Query("kelas_id"); value != "" {
+		id, err := strconv.ParseUint(value, 10, 32)
+		if err != nil {
+			utils.ErrorResponse(c, http.StatusBadRequest, "Invalid kelas_id")
+			return
+		}
+		parsed := uint(id)
+		kelasID = &parsed
+	}
+
+	course, kelas, ok := findGradeSheetClass(c, principal.DosenID, tahunAjaran, kelasID)
+	if !ok {
+		return
+	}
+
+	sheet, err := gradeSheetFor(config.DB, course.ID, tahunAjaran, kelas, principal.Jurusan)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to fetch grade sheet")
+		return
+	}
+	summary, err := gradeSheetSummary(config.DB, sheet)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to summarize grade sheet")
+		return
+	}
+
+	utils.SuccessResponse(c, summary)
+}
+
+// SubmitSheet - Dosen mengajukan lembar nilai ke kajur; semua mahasiswa kelas harus sudah dinilai
+func (gc *GradeSheetController) SubmitSheet(c *gin.Context) {
+	principal := middleware.GetPrincipal(c)
+
+	var req models.SubmitGradeSheetRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid JSON format")
+		return
+	}
+
+	if err := utils.ValidateStruct(req); err != nil {
+		utils.HandleValidationError(c, err)
+		return
+	}
+
+	course, kelas, ok := findGradeSheetClass(c, principal.DosenID, req.TahunAjaran, req.KelasID)
+	if !ok {
+		return
+	}
+
+	sheet, err := gradeSheetFor(config.DB, course.ID, req.TahunAjaran, kelas, principal.Jurusan)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to fetch grade sheet")
+		return
+	}
+	summary, err := gradeSheetSummary(config.DB, sheet)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to summarize grade sheet")
+		return
+	}
+	if summary.TotalMahasiswa == 0 {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Tidak ada mahasiswa di kelas ini")
+		return
+	}
+	if summary.BelumDinilai > 0 {
+		utils.ErrorResponse(c, http.StatusUnprocessableEntity, strconv.Itoa(summary.BelumDinilai)+" mahasiswa belum dinilai")
+		return
+	}
+
+	err = config.DB.Transaction(func(tx *gorm.DB) error {
+		_, err := transitionGradeSheet(c, tx, &sheet, "dosen", "submit", req.Note)
+		return err
+	})
+	if err != nil {
+		respondGradeSheetError(c, err, "Failed to submit grade sheet")
+		return
+	}
+
+	summary.GradeSheet = sheet
+	utils.SuccessResponse(c, gin.H{
+		"message":     "Grade sheet submitted",
+		"grade_sheet": summary,
+	})
+}
+
+// GetPendingSheets - Lembar nilai jurusan kajur (default yang menunggu verifikasi atau publikasi)
+func (gc *GradeSheetController) GetPendingSheets(c *gin.Context) {
+	principal := middleware.GetPrincipal(c)
+
+	query := config.DB.Preload("Course").Where("jurusan = ?", principal.Jurusan)
+	if status := c.Query("status"); status != "" {
+		query = query.Where("status = ?", status)
+	} else {
+		query = query.Where("status IN ?", []string{models.GradeSheetSubmitted, models.GradeSheetVerified})
+	}
+	if tahunAjaran := c.Query("tahun_ajaran"); tahunAjaran != "" {
+		query = query.Where("tahun_ajaran = ?", tahunAjaran)
+	}
+
+	var sheets []models.GradeSheet
+	if err := query.Order("submitted_at ASC, id ASC").Find(&sheets).Error; err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to fetch grade sheets")
+		return
+	}
+
+	responses := make([]models.GradeSheetResponse, 0, len(sheets))
+	for _, sheet := range sheets {
+		summary, err := gradeSheetSummary(config.DB, sheet)
+		if err != nil {
+			utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to summarize grade sheets")
+			return
+		}
+		responses = append(responses, summary)
+	}
+
+	utils.SuccessResponse(c, gin.H{
+		"total":        len(responses),
+		"grade_sheets": responses,
+	})
+}
+
+// ProcessSheet - Kajur memverifikasi, mempublikasikan atau mengembalikan lembar nilai ke dosen
+func (gc *GradeSheetController) ProcessSheet(c *gin.Context) {
+	principal := middleware.GetPrincipal(c)
+
+	var req models.GradeSheetActionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid JSON format")
+		return
+	}
+
+	if err := utils.ValidateStruct(req); err != nil {
+		utils.HandleValidationError(c, err)
+		return
+	}
+	if req.Action == "return" && req.Note == "" {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Catatan wajib diisi saat mengembalikan lembar nilai")
+		return
+	}
+
+	sheet, err := loadGradeSheet(c.Param("id"))
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusNotFound, "Grade sheet not found")
+		return
+	}
+	if sheet.Jurusan != principal.Jurusan {
+		utils.ErrorResponse(c, http.StatusForbidden, "Lembar nilai bukan dari jurusan Anda")
+		return
+	}
+
+	published := 0
+	err = config.DB.Transaction(func(tx *gorm.DB) error {
+		var err error
+		published, err = transitionGradeSheet(c, tx, &sheet, "kajur", req.Action, req.Note)
+		return err
+	})
+	if err != nil {
+		respondGradeSheetError(c, err, "Failed to process grade sheet")
+		return
+	}
+
+	utils.SuccessResponse(c, gin.H{
+		"message":         "Grade sheet " + sheet.Status,
+		"grade_sheet":     sheet,
+		"nilai_published": published,
+	})
+}
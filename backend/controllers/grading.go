@@ -0,0 +1,293 @@
+package controllers
+
+import (
+	"SIAku/middleware"
+	"SIAku/models"
+	"errors"
+	"fmt"
+	"math"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Helper komponen penilaian: skema per mata kuliah/kelas, perhitungan nilai akhir dan hitung ulang saat skema berubah
+
+var errUnknownKomponen = errors.New("komponen tidak ada di skema penilaian")
+
+// errNilaiPublished - nilai sudah dipublikasikan dan hanya bisa diubah lewat koreksi nilai
+var errNilaiPublished = errors.New("nilai already published")
+
+// gradingScheme memilih skema kelas, lalu skema mata kuliah pada tahun ajaran itu, lalu skema bawaan
+func gradingScheme(db *gorm.DB, courseID uint, tahunAjaran string, kelasID *uint) ([]models.GradingComponent, string, error) {
+	var components []models.GradingComponent
+	if kelasID != nil {
+		if err := db.Where("course_id = ? AND tahun_ajaran = ? AND kelas_id = ?", courseID, tahunAjaran, *kelasID).
+			Order("urutan ASC").Find(&components).Error; err != nil {
+			return nil, "", err
+		}
+		if len(components) > 0 {
+			return components, "kelas", nil
+		}
+	}
+
+	if err := db.Where("course_id = ? AND tahun_ajaran = ? AND kelas_id IS NULL", courseID, tahunAjaran).
+		Order("urutan ASC").Find(&components).Error; err != nil {
+		return nil, "", err
+	}
+	if len(components) > 0 {
+		return components, "course", nil
+	}
+	return models.DefaultGradingComponents, "default", nil
+}
+
+// storedScores mengambil skor komponen yang tersimpan; nilai lama tanpa komponen memakai kolom tugas/UTS/UAS
+func storedScores(nilai models.Nilai) []models.NilaiKomponen {
+	if len(nilai.Komponen) > 0 || nilai.ID == 0 {
+		return nilai.Komponen
+	}
+	return []models.NilaiKomponen{
+		{Kode: "tugas", Nama: "Tugas", Skor: nilai.NilaiTugas},
+		{Kode: "uts", Nama: "UTS", Skor: nilai.NilaiUTS},
+		{Kode: "uas", Nama: "UAS", Skor: nilai.NilaiUAS},
+	}
+}
+
+// validateSchemeComponents menolak kode komponen ganda dan total bobot yang bukan 100
+func validateSchemeComponents(components []models.GradingComponentItem) error {
+	seen := map[string]bool{}
+	total := 0.0
+	for _, component := range components {
+		if seen[component.Kode] {
+			return fmt.Errorf("Duplicate kode %s", component.Kode)
+		}
+		seen[component.Kode] = true
+		total += component.Bobot
+	}
+	if math.Abs(total-100) > 0.001 {
+		return fmt.Errorf("Total bobot komponen harus 100, saat ini %v", total)
+	}
+	return nil
+}
+
+// scoreNilai menggabungkan skor tersimpan dengan input baru, menghitung nilai akhir menurut skema
+// dan mengisi grade menurut skala. Komponen di luar skema tetap dikembalikan dengan bobot 0.
+func scoreNilai(nilai *models.Nilai, scheme []models.GradingComponent, scale models.GradeScale, stored []models.NilaiKomponen, input map[string]float64) []models.NilaiKomponen {
+	byKode := map[string]*models.NilaiKomponen{}
+	var rows []*models.NilaiKomponen
+	for _, s := range stored {
+		row := s
+		row.Bobot = 0
+		byKode[row.Kode] = &row
+		rows = append(rows, &row)
+	}
+	for _, component := range scheme {
+		row, ok := byKode[component.Kode]
+		if !ok {
+			row = &models.NilaiKomponen{Kode: component.Kode}
+			byKode[component.Kode] = row
+			rows = append(rows, row)
+		}
+		row.Nama = component.Nama
+		row.Bobot = component.Bobot
+	}
+	for kode, skor := range input {
+		if row, ok := byKode[kode]; ok {
+			row.Skor = skor
+		}
+	}
+
+	akhir := 0.0
+	result := make([]models.NilaiKomponen, 0, len(rows))
+	for _, row := range rows {
+		akhir += row.Skor * row.Bobot / 100
+		switch row.Kode {
+		case "tugas":
+			nilai.NilaiTugas = row.Skor
+		case "uts":
+			nilai.NilaiUTS = row.Skor
+		case "uas":
+			nilai.NilaiUAS = row.Skor
+		}
+		result = append(result, *row)
+	}
+
+	nilai.NilaiAkhir = math.Round(akhir*100) / 100
+	applyGradeScale(nilai, scale)
+	return result
+}
+
+// rescoreNilai menghitung ulang nilai yang sudah ada dengan skor komponen baru memakai skema kelasnya dan
+// skala yang tercatat pada nilai. KRS dan Mahasiswa nilai harus sudah dimuat.
+func rescoreNilai(db *gorm.DB, nilai *models.Nilai, input map[string]float64) ([]models.NilaiKomponen, error) {
+	var kelasID *uint
+	if nilai.KRS != nil {
+		kelasID = nilai.KRS.KelasID
+	}
+	scheme, _, err := gradingScheme(db, nilai.CourseID, nilai.TahunAjaran, kelasID)
+	if err != nil {
+		return nil, err
+	}
+	inScheme := map[string]bool{}
+	for _, component := range scheme {
+		inScheme[component.Kode] = true
+	}
+	for kode := range input {
+		if !inScheme[kode] {
+			return nil, fmt.Errorf("%w: %s", errUnknownKomponen, kode)
+		}
+	}
+
+	scale, err := newGradeScaleCache(db).forNilai(*nilai, nilai.Mahasiswa)
+	if err != nil {
+		return nil, err
+	}
+	return scoreNilai(nilai, scheme, scale, storedScores(*nilai), input), nil
+}
+
+// attemptNilai mengambil nilai percobaan sesuai KRS (semester dan tahun ajaran yang sama), atau menyiapkan
+// baris nilai baru dengan nomor percobaan berikutnya jika mata kuliah pernah diambil
+func attemptNilai(db *gorm.DB, krs models.KRS) (models.Nilai, bool, error) {
+	var nilai models.Nilai
+	err := db.Preload("Komponen", orderKomponen).
+		Where("mahasiswa_id = ? AND course_id = ? AND semester = ? AND tahun_ajaran = ?",
+			krs.MahasiswaID, krs.CourseID, krs.Semester, krs.TahunAjaran).First(&nilai).Error
+	if err == nil {
+		return nilai, false, nil
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nilai, false, err
+	}
+
+	var previous int64
+	if err := db.Model(&models.Nilai{}).Where("mahasiswa_id = ? AND course_id = ?", krs.MahasiswaID, krs.CourseID).
+		Count(&previous).Error; err != nil {
+		return nilai, false, err
+	}
+	krsID := krs.ID
+	return models.Nilai{
+		MahasiswaID: krs.MahasiswaID,
+		CourseID:    krs.CourseID,
+		Semester:    krs.Semester,
+		TahunAjaran: krs.TahunAjaran,
+		Attempt:     int(previous) + 1,
+		KRSID:       &krsID,
+	}, true, nil
+}
+
+// saveDraftNilai menyimpan nilai berstatus draft beserta skor komponennya dan mencatat audit
+func saveDraftNilai(c *gin.Context, tx *gorm.DB, nilai *models.Nilai, before models.Nilai, isNew bool, komponen []models.NilaiKomponen) error {
+	nilai.Status = models.NilaiDraft
+	if isNew {
+		if err := tx.Omit("Komponen").Create(nilai).Error; err != nil {
+			return err
+		}
+	} else if err := tx.Omit("Komponen").Save(nilai).Error; err != nil {
+		return err
+	}
+	if err := saveNilaiKomponen(tx, nilai.ID, komponen); err != nil {
+		return err
+	}
+	nilai.Komponen = komponen
+	if isNew {
+		return middleware.RecordAudit(c, tx, "create", "nilai", nilai.ID, nil, nilai)
+	}
+	return middleware.RecordAudit(c, tx, "update", "nilai", nilai.ID, before, nilai)
+}
+
+// saveNilaiKomponen menyimpan skor komponen (upsert per nilai dan kode)
+func saveNilaiKomponen(tx *gorm.DB, nilaiID uint, rows []models.NilaiKomponen) error {
+	if len(rows) == 0 {
+		return nil
+	}
+	for i := range rows {
+		rows[i].ID = 0
+		rows[i].NilaiID = nilaiID
+	}
+	return tx.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "nilai_id"}, {Name: "kode"}},
+		DoUpdates: clause.AssignmentColumns([]string{"nama", "bobot", "skor"}),
+	}).Create(&rows).Error
+}
+
+// recomputeCourseNilai menghitung ulang nilai akhir satu mata kuliah pada tahun ajaran setelah skema berubah.
+// Nilai yang sudah dipublikasikan terkunci dan dilewati. Mengembalikan jumlah nilai yang berubah.
+func recomputeCourseNilai(tx *gorm.DB, courseID uint, tahunAjaran string) (int, error) {
+	var nilaiList []models.Nilai
+	if err := tx.Preload("Komponen", orderKomponen).Preload("KRS").Preload("Mahasiswa").
+		Where("course_id = ? AND tahun_ajaran = ? AND status <> ?", courseID, tahunAjaran, models.NilaiPublished).
+		Find(&nilaiList).Error; err != nil {
+		return 0, err
+	}
+
+	schemes := map[uint][]models.GradingComponent{} // 0 = skema tanpa kelas
+	scales := newGradeScaleCache(tx)
+	changed := 0
+	affected := map[uint]bool{}
+	for _, nilai := range nilaiList {
+		var kelasID *uint
+		key := uint(0)
+		if nilai.KRS != nil && nilai.KRS.KelasID != nil {
+			kelasID = nilai.KRS.KelasID
+			key = *kelasID
+		}
+		scheme, ok := schemes[key]
+		if !ok {
+			var err error
+			if scheme, _, err = gradingScheme(tx, courseID, tahunAjaran, kelasID); err != nil {
+				return 0, err
+			}
+			schemes[key] = scheme
+		}
+
+		scale, err := scales.forNilai(nilai, nilai.Mahasiswa)
+		if err != nil {
+			return 0, err
+		}
+
+		before := nilai.NilaiAkhir
+		rows := scoreNilai(&nilai, scheme, scale, storedScores(nilai), nil)
+		if err := tx.Model(&models.Nilai{}).Where("id = ?", nilai.ID).Updates(map[string]interface{}{
+			"nilai_tugas":    nilai.NilaiTugas,
+			"nilai_uts":      nilai.NilaiUTS,
+			"nilai_uas":      nilai.NilaiUAS,
+			"nilai_akhir":    nilai.NilaiAkhir,
+			"grade_huruf":    nilai.GradeHuruf,
+			"grade_point":    nilai.GradePoint,
+			"grade_scale_id": nilai.GradeScaleID,
+		}).Error; err != nil {
+			return 0, err
+		}
+		if err := saveNilaiKomponen(tx, nilai.ID, rows); err != nil {
+			return 0, err
+		}
+		if nilai.NilaiAkhir != before {
+			changed++
+			affected[nilai.MahasiswaID] = true
+		}
+	}
+
+	for mahasiswaID := range affected {
+		if err := refreshMahasiswaIPK(tx, mahasiswaID); err != nil {
+			return 0, err
+		}
+	}
+	return changed, nil
+}
+
+// nilaiKomponenResponse menampilkan komponen yang dihitung (bobot > 0)
+func nilaiKomponenResponse(nilai models.Nilai) []models.NilaiKomponenResponse {
+	var result []models.NilaiKomponenResponse
+	for _, k := range nilai.Komponen {
+		if k.Bobot > 0 {
+			result = append(result, models.NilaiKomponenResponse{Kode: k.Kode, Nama: k.Nama, Bobot: k.Bobot, Skor: k.Skor})
+		}
+	}
+	return result
+}
+
+// orderKomponen mengurutkan komponen sesuai urutan disimpan (mengikuti urutan skema)
+func orderKomponen(db *gorm.DB) *gorm.DB {
+	return db.Order("id ASC")
+}
@@ -0,0 +1,178 @@
+package controllers
+
+import (
+	"SIAku/config"
+	"SIAku/middleware"
+	"SIAku/models"
+	"SIAku/utils"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+type GradingController struct{}
+
+func NewGradingController() *GradingController {
+	return &GradingController{}
+}
+
+// findSchemeCourse memastikan dosen mengajar mata kuliah (dan kelasnya jika kelas_id diisi).
+// Jika manage=true, skema mata kuliah hanya boleh diubah dosen pengampu utama dan skema kelas oleh dosen kelas itu.
+func findSchemeCourse(c *gin.Context, dosenID uint, tahunAjaran string, kelasID *uint, manage bool) (models.Course, bool) {
+	var course models.Course
+	if err := config.DB.Scopes(taughtBy(dosenID)).Where("courses.id = ?", c.Param("courseId")).First(&course).Error; err != nil {
+		utils.ErrorResponse(c, http.StatusForbidden, "You are not teaching this course")
+		return course, false
+	}
+	mainDosen := course.DosenID != nil && *course.DosenID == dosenID
+
+	if kelasID != nil {
+		var kelas models.Kelas
+		if err := config.DB.Where("id = ? AND course_id = ? AND tahun_ajaran = ?", *kelasID, course.ID, tahunAjaran).
+			First(&kelas).Error; err != nil {
+			utils.ErrorResponse(c, http.StatusNotFound, "Kelas not found for this course and tahun ajaran")
+			return course, false
+		}
+		if manage && !mainDosen && (kelas.DosenID == nil || *kelas.DosenID != dosenID) {
+			utils.ErrorResponse(c, http.StatusForbidden, "Hanya dosen kelas ini yang dapat mengatur skemanya")
+			return course, false
+		}
+	} else if manage && !mainDosen {
+		utils.ErrorResponse(c, http.StatusForbidden, "Hanya dosen pengampu utama yang dapat mengatur skema mata kuliah")
+		return course, false
+	}
+	return course, true
+}
+
+// GetScheme - Komponen penilaian yang berlaku (skema kelas, mata kuliah, atau bawaan)
+func (gc *GradingController) GetScheme(c *gin.Context) {
+	dosenID := middleware.GetPrincipal(c).DosenID
+	tahunAjaran := c.DefaultQuery("tahun_ajaran", getCurrentAcademicYear())
+
+	var kelasID *uint
+	if value := c.Query("kelas_id"); value != "" {
+		id, err := strconv.ParseUint(value, 10, 32)
+		if err != nil {
+			utils.ErrorResponse(c, http.StatusBadRequest, "Invalid kelas_id")
+			return
+		}
+		parsed := uint(id)
+		kelasID = &parsed
+	}
+
+	course, ok := findSchemeCourse(c, dosenID, tahunAjaran, kelasID, false)
+	if !ok {
+		return
+	}
+
+	components, source, err := gradingScheme(config.DB, course.ID, tahunAjaran, kelasID)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to fetch grading scheme")
+		return
+	}
+
+	utils.SuccessResponse(c, models.GradingSchemeResponse{
+		CourseID:    course.ID,
+		TahunAjaran: tahunAjaran,
+		KelasID:     kelasID,
+		Source:      source,
+		Components:  components,
+	})
+}
+
+// SetScheme - Ganti komponen penilaian (total bobot 100) lalu hitung ulang nilai akhir tahun ajaran tersebut
+func (gc *GradingController) SetScheme(c *gin.Context) {
+	dosenID := middleware.GetPrincipal(c).DosenID
+
+	var req models.SetGradingSchemeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid JSON format")
+		return
+	}
+
+	if err := utils.ValidateStruct(req); err != nil {
+		utils.HandleValidationError(c, err)
+		return
+	}
+
+	course, ok := findSchemeCourse(c, dosenID, req.TahunAjaran, req.KelasID, true)
+	if !ok {
+		return
+	}
+
+	if err := validateSchemeComponents(req.Components); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	scope := func(db *gorm.DB) *gorm.DB {
+		db = db.Where("course_id = ? AND tahun_ajaran = ?", course.ID, req.TahunAjaran)
+		if req.KelasID != nil {
+			return db.Where("kelas_id = ?", *req.KelasID)
+		}
+		return db.Where("kelas_id IS NULL")
+	}
+
+	var recomputed int
+	err := config.DB.Transaction(func(tx *gorm.DB) error {
+		// Skema tidak boleh diubah setelah lembar nilai kelas diajukan atau dipublikasikan
+		if err := requireDraftSheet(tx, course.ID, req.TahunAjaran, req.KelasID); err != nil {
+			return err
+		}
+
+		var before []models.GradingComponent
+		if err := tx.Scopes(scope).Order("urutan ASC").Find(&before).Error; err != nil {
+			return err
+		}
+
+		if err := tx.Scopes(scope).Delete(&models.GradingComponent{}).Error; err != nil {
+			return err
+		}
+		for i, component := range req.Components {
+			if err := tx.Create(&models.GradingComponent{
+				CourseID:    course.ID,
+				TahunAjaran: req.TahunAjaran,
+				KelasID:     req.KelasID,
+				Kode:        component.Kode,
+				Nama:        component.Nama,
+				Bobot:       component.Bobot,
+				Urutan:      i + 1,
+			}).Error; err != nil {
+				return err
+			}
+		}
+
+		var err error
+		if recomputed, err = recomputeCourseNilai(tx, course.ID, req.TahunAjaran); err != nil {
+			return err
+		}
+
+		previous := make([]models.GradingComponentItem, 0, len(before))
+		for _, component := range before {
+			previous = append(previous, models.GradingComponentItem{Kode: component.Kode, Nama: component.Nama, Bobot: component.Bobot})
+		}
+		return middleware.RecordAudit(c, tx, "set_grading_scheme", "course", course.ID,
+			gin.H{"tahun_ajaran": req.TahunAjaran, "kelas_id": req.KelasID, "components": previous},
+			gin.H{"tahun_ajaran": req.TahunAjaran, "kelas_id": req.KelasID, "components": req.Components})
+	})
+	if err != nil {
+		if errors.Is(err, errGradeSheetLocked) {
+			utils.ErrorResponse(c, http.StatusConflict, "Lembar nilai sudah diajukan atau dipublikasikan, skema penilaian terkunci")
+			return
+		}
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to save grading scheme")
+		return
+	}
+
+	utils.SuccessResponse(c, gin.H{
+		"message":          "Grading scheme updated",
+		"course_id":        course.ID,
+		"tahun_ajaran":     req.TahunAjaran,
+		"kelas_id":         req.KelasID,
+		"components":       req.Components,
+		"nilai_recomputed": recomputed,
+	})
+}
@@ -0,0 +1,107 @@
+package controllers
+
+import (
+	"SIAku/models"
+	"strings"
+	"testing"
+)
+
+func TestValidateSchemeComponents(t *testing.T) {
+	item := func(kode string, bobot float64) models.GradingComponentItem {
+		return models.GradingComponentItem{Kode: kode, Nama: kode, Bobot: bobot}
+	}
+
+	tests := []struct {
+		name       string
+		components []models.GradingComponentItem
+		wantErr    string
+	}{
+		{name: "tepat 100", components: []models.GradingComponentItem{item("tugas", 30), item("uts", 30), item("uas", 40)}},
+		{name: "pecahan berjumlah 100", components: []models.GradingComponentItem{item("a", 33.33), item("b", 33.33), item("c", 33.34)}},
+		{name: "kurang dari 100", components: []models.GradingComponentItem{item("uts", 50), item("uas", 49.5)}, wantErr: "saat ini 99.5"},
+		{name: "lebih dari 100", components: []models.GradingComponentItem{item("uts", 60), item("uas", 41)}, wantErr: "saat ini 101"},
+		{name: "kode ganda", components: []models.GradingComponentItem{item("uts", 50), item("uts", 50)}, wantErr: "Duplicate kode uts"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateSchemeComponents(tt.components)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("got %v, want error containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestScoreNilai(t *testing.T) {
+	scheme := []models.GradingComponent{
+		{Kode: "tugas", Nama: "Tugas", Bobot: 20},
+		{Kode: "uts", Nama: "UTS", Bobot: 30},
+		{Kode: "uas", Nama: "UAS", Bobot: 50},
+	}
+	scale := models.GradeScale{Items: models.DefaultGradeScaleItems}
+
+	tests := []struct {
+		name      string
+		stored    []models.NilaiKomponen
+		input     map[string]float64
+		wantAkhir float64
+		wantHuruf string
+		wantKode  []string
+	}{
+		{
+			name:      "semua komponen baru",
+			input:     map[string]float64{"tugas": 90, "uts": 80, "uas": 85},
+			wantAkhir: 84.5, wantHuruf: "AB",
+			wantKode: []string{"tugas", "uts", "uas"},
+		},
+		{
+			name:      "input sebagian mempertahankan skor tersimpan",
+			stored:    []models.NilaiKomponen{{Kode: "tugas", Skor: 100, Bobot: 20}, {Kode: "uts", Skor: 70, Bobot: 30}},
+			input:     map[string]float64{"uas": 80},
+			wantAkhir: 81, wantHuruf: "AB",
+			wantKode: []string{"tugas", "uts", "uas"},
+		},
+		{
+			name:      "komponen di luar skema tetap ada dengan bobot 0",
+			stored:    []models.NilaiKomponen{{Kode: "kuis", Skor: 100, Bobot: 10}},
+			input:     map[string]float64{"tugas": 85, "uts": 85, "uas": 85},
+			wantAkhir: 85, wantHuruf: "A",
+			wantKode: []string{"kuis", "tugas", "uts", "uas"},
+		},
+		{
+			name:      "pembulatan dua desimal",
+			input:     map[string]float64{"tugas": 33.333, "uts": 33.333, "uas": 33.333},
+			wantAkhir: 33.33, wantHuruf: "E",
+			wantKode: []string{"tugas", "uts", "uas"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var nilai models.Nilai
+			rows := scoreNilai(&nilai, scheme, scale, tt.stored, tt.input)
+			if nilai.NilaiAkhir != tt.wantAkhir || nilai.GradeHuruf != tt.wantHuruf {
+				t.Errorf("got (%v, %s), want (%v, %s)", nilai.NilaiAkhir, nilai.GradeHuruf, tt.wantAkhir, tt.wantHuruf)
+			}
+			if len(rows) != len(tt.wantKode) {
+				t.Fatalf("got %d komponen, want %d", len(rows), len(tt.wantKode))
+			}
+			for i, row := range rows {
+				if row.Kode != tt.wantKode[i] {
+					t.Errorf("komponen %d = %s, want %s", i, row.Kode, tt.wantKode[i])
+				}
+				if row.Kode == "kuis" && (row.Bobot != 0 || row.Skor != 100) {
+					t.Errorf("komponen di luar skema berubah: %+v", row)
+				}
+			}
+			if nilai.NilaiTugas != rows[len(rows)-3].Skor || nilai.NilaiUAS != rows[len(rows)-1].Skor {
+				t.Errorf("kolom tugas/uas lama tidak diisi: %+v", nilai)
+			}
+		})
+	}
+}
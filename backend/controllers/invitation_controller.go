@@ -0,0 +1,434 @@
+package controllers
+
+import (
+	"SIAku/config"
+	"SIAku/middleware"
+	"SIAku/models"
+	"SIAku/utils"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var errInvitationUsed = errors.New("invitation already used")
+
+type InvitationController struct {
+	auth *AuthController
+}
+
+func NewInvitationController() *InvitationController {
+	return &InvitationController{auth: NewAuthController()}
+}
+
+// CreateInvitation - Buat undangan akun staf (kajur: dosen di jurusannya, rektor: semua role staf)
+func (ic *InvitationController) CreateInvitation(c *gin.Context) {
+	var req models.CreateInvitationRequest
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid JSON format")
+		return
+	}
+
+	if err := utils.ValidateStruct(req); err != nil {
+		utils.HandleValidationError(c, err)
+		return
+	}
+
+	principal := middleware.GetPrincipal(c)
+	email := strings.ToLower(strings.TrimSpace(req.Email))
+	jurusan := req.Jurusan
+
+	switch principal.Role {
+	case "kajur":
+		if req.Role != "dosen" {
+			utils.ErrorResponse(c, http.StatusForbidden, "Kajur hanya dapat mengundang dosen")
+			return
+		}
+		jurusan = principal.Jurusan
+	case "rektor":
+		if req.Role == "rektor" {
+			jurusan = ""
+		} else if jurusan == "" {
+			utils.ErrorResponse(c, http.StatusBadRequest, "Jurusan is required for dosen and kajur")
+			return
+		}
+	}
+
+	var existing int64
+	config.DB.Model(&models.Users{}).Where("LOWER(email) = ?", email).Count(&existing)
+	if existing > 0 {
+		utils.ErrorResponse(c, http.StatusConflict, "Email sudah terdaftar sebagai akun")
+		return
+	}
+
+	config.DB.Model(&models.StaffInvitation{}).
+		Where("email = ? AND accepted_at IS NULL AND revoked_at IS NULL AND expires_at > ?", email, time.Now()).
+		Count(&existing)
+	if existing > 0 {
+		utils.ErrorResponse(c, http.StatusConflict, "Masih ada undangan aktif untuk email ini, cabut dulu sebelum membuat yang baru")
+		return
+	}
+
+	token, err := generateInvitationToken()
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to generate invitation token")
+		return
+	}
+
+	invitation := models.StaffInvitation{
+		TokenHash: middleware.HashToken(token),
+		Email:     email,
+		Role:      req.Role,
+		Jurusan:   jurusan,
+		InvitedBy: principal.UserID,
+		ExpiresAt: time.Now().Add(config.AppConfig.InvitationTTL),
+	}
+
+	if err := config.DB.Create(&invitation).Error; err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to create invitation")
+		return
+	}
+
+	body := fmt.Sprintf("Anda diundang membuat akun %s di SIAku.\n\nKode undangan: %s\n\nKode berlaku sampai %s dan hanya bisa dipakai sekali.",
+		invitation.Role, token, invitation.ExpiresAt.Format("02 Jan 2006 15:04"))
+	if err := utils.GetMailer().Send(email, "Undangan akun SIAku", body); err != nil {
+		log.Printf("Failed to send invitation email to %s: %v", email, err)
+	}
+
+	// Token mentah hanya dikembalikan sekali di sini, server hanya menyimpan hash-nya
+	c.JSON(http.StatusCreated, gin.H{
+		"success": true,
+		"message": "Invitation created",
+		"data": gin.H{
+			"invitation": invitationResponse(invitation),
+			"token":      token,
+		},
+	})
+}
+
+// GetInvitations - Daftar undangan (default: pending), kajur hanya melihat jurusannya
+func (ic *InvitationController) GetInvitations(c *gin.Context) {
+	principal := middleware.GetPrincipal(c)
+	page := utils.GetPageParam(c)
+	limit := utils.GetLimitParam(c)
+	status := c.DefaultQuery("status", "pending")
+	offset := (page - 1) * limit
+	now := time.Now()
+
+	query := config.DB.Model(&models.StaffInvitation{})
+	if principal.Role == "kajur" {
+		query = query.Where("jurusan = ?", principal.Jurusan)
+	}
+
+	switch status {
+	case "pending":
+		query = query.Where("accepted_at IS NULL AND revoked_at IS NULL AND expires_at > ?", now)
+	case "accepted":
+		query = query.Where("accepted_at IS NOT NULL")
+	case "revoked":
+		query = query.Where("revoked_at IS NOT NULL")
+	case "expired":
+		query = query.Where("accepted_at IS NULL AND revoked_at IS NULL AND expires_at <= ?", now)
+	case "all":
+	default:
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid status, use pending, accepted, revoked, expired or all")
+		return
+	}
+
+	var invitations []models.StaffInvitation
+	var total int64
+
+	query.Count(&total)
+	if err := query.Order("created_at DESC").Offset(offset).Limit(limit).Find(&invitations).Error; err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to fetch invitations")
+		return
+	}
+
+	responses := make([]models.InvitationResponse, 0, len(invitations))
+	for _, invitation := range invitations {
+		responses = append(responses, invitationResponse(invitation))
+	}
+
+	utils.SuccessResponse(c, gin.H{
+		"invitations": responses,
+		"pagination": gin.H{
+			"page":        page,
+			"limit":       limit,
+			"total":       total,
+			"total_pages": (total + int64(limit) - 1) / int64(limit),
+		},
+	})
+}
+
+// RevokeInvitation - Cabut undangan yang masih pending
+func (ic *InvitationController) RevokeInvitation(c *gin.Context) {
+	principal := middleware.GetPrincipal(c)
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid invitation ID")
+		return
+	}
+
+	var invitation models.StaffInvitation
+	if err := config.DB.Where("id = ?", id).First(&invitation).Error; err != nil {
+		utils.ErrorResponse(c, http.StatusNotFound, "Invitation not found")
+		return
+	}
+
+	if principal.Role == "kajur" && invitation.Jurusan != principal.Jurusan {
+		utils.ErrorResponse(c, http.StatusForbidden, "Undangan bukan dari jurusan Anda")
+		return
+	}
+
+	result := config.DB.Model(&models.StaffInvitation{}).
+		Where("id = ? AND accepted_at IS NULL AND revoked_at IS NULL", invitation.ID).
+		Update("revoked_at", time.Now())
+	if result.Error != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to revoke invitation")
+		return
+	}
+	if result.RowsAffected == 0 {
+		utils.ErrorResponse(c, http.StatusConflict, "Undangan sudah dipakai atau sudah dicabut")
+		return
+	}
+
+	utils.SuccessResponse(c, gin.H{"message": "Invitation revoked"})
+}
+
+// AcceptInvitation - Buat akun staf dari undangan; role, email dan jurusan mengikuti undangan
+func (ic *InvitationController) AcceptInvitation(c *gin.Context) {
+	var req models.AcceptInvitationRequest
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid JSON format")
+		return
+	}
+
+	if err := utils.ValidateStruct(req); err != nil {
+		utils.HandleValidationError(c, err)
+		return
+	}
+
+	var invitation models.StaffInvitation
+	if err := config.DB.Where("token_hash = ?", middleware.HashToken(strings.TrimSpace(req.Token))).First(&invitation).Error; err != nil ||
+		invitation.Status() != "pending" {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Kode undangan tidak valid atau sudah kedaluwarsa")
+		return
+	}
+
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(req.Password), bcrypt.DefaultCost)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to hash password")
+		return
+	}
+
+	user := models.Users{
+		Username: req.Username,
+		Email:    invitation.Email,
+		Password: string(hashedPassword),
+		Role:     invitation.Role,
+		Status:   "aktif",
+	}
+
+	err = config.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&user).Error; err != nil {
+			return err
+		}
+
+		// Update bersyarat supaya undangan yang sama tidak bisa dipakai dua kali
+		result := tx.Model(&models.StaffInvitation{}).
+			Where("id = ? AND accepted_at IS NULL AND revoked_at IS NULL AND expires_at > ?", invitation.ID, time.Now()).
+			Updates(map[string]interface{}{"accepted_at": time.Now(), "accepted_user_id": user.ID})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return errInvitationUsed
+		}
+
+		return createStaffProfile(tx, user, invitation, req)
+	})
+	if err != nil {
+		switch {
+		case errors.Is(err, errInvitationUsed):
+			utils.ErrorResponse(c, http.StatusBadRequest, "Kode undangan tidak valid atau sudah kedaluwarsa")
+		case strings.Contains(err.Error(), "nidn"):
+			utils.ErrorResponse(c, http.StatusConflict, "NIDN sudah terdaftar, silakan gunakan NIDN yang lain")
+		default:
+			respondUserCreateError(c, err)
+		}
+		return
+	}
+
+	tokens, err := ic.auth.issueTokens(c, user, "")
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to generate token")
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"success":       true,
+		"message":       "Registration successful",
+		"data":          ic.auth.buildUserResponse(user),
+		"token":         tokens.AccessToken,
+		"refresh_token": tokens.RefreshToken,
+		"expires_in":    tokens.ExpiresIn,
+	})
+}
+
+// ImportNIMWhitelist - Impor daftar NIM yang boleh registrasi mandiri (NIM yang sudah ada dilewati)
+func (ic *InvitationController) ImportNIMWhitelist(c *gin.Context) {
+	var req models.ImportNIMWhitelistRequest
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid JSON format")
+		return
+	}
+
+	if err := utils.ValidateStruct(req); err != nil {
+		utils.HandleValidationError(c, err)
+		return
+	}
+
+	principal := middleware.GetPrincipal(c)
+	entries := make([]models.NIMWhitelist, 0, len(req.Entries))
+	for _, entry := range req.Entries {
+		if principal.Role == "kajur" && entry.Jurusan != principal.Jurusan {
+			utils.ErrorResponse(c, http.StatusForbidden, "NIM "+entry.NIM+" bukan dari jurusan Anda")
+			return
+		}
+		entries = append(entries, models.NIMWhitelist{
+			NIM:        strings.TrimSpace(entry.NIM),
+			Nama:       entry.Nama,
+			Jurusan:    entry.Jurusan,
+			ImportedBy: principal.UserID,
+		})
+	}
+
+	result := config.DB.Clauses(clause.OnConflict{Columns: []clause.Column{{Name: "nim"}}, DoNothing: true}).Create(&entries)
+	if result.Error != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to import NIM whitelist")
+		return
+	}
+
+	utils.CreatedResponse(c, gin.H{
+		"imported": result.RowsAffected,
+		"skipped":  int64(len(entries)) - result.RowsAffected,
+	})
+}
+
+// GetNIMWhitelist - Daftar NIM whitelist dengan filter jurusan dan status registrasi
+func (ic *InvitationController) GetNIMWhitelist(c *gin.Context) {
+	principal := middleware.GetPrincipal(c)
+	page := utils.GetPageParam(c)
+	limit := utils.GetLimitParam(c)
+	jurusan := c.Query("jurusan")
+	registered := c.Query("registered")
+	offset := (page - 1) * limit
+
+	query := config.DB.Model(&models.NIMWhitelist{})
+	if principal.Role == "kajur" {
+		jurusan = principal.Jurusan
+	}
+	if jurusan != "" {
+		query = query.Where("jurusan = ?", jurusan)
+	}
+	switch registered {
+	case "true":
+		query = query.Where("registered_at IS NOT NULL")
+	case "false":
+		query = query.Where("registered_at IS NULL")
+	}
+
+	var entries []models.NIMWhitelist
+	var total int64
+
+	query.Count(&total)
+	if err := query.Order("nim ASC").Offset(offset).Limit(limit).Find(&entries).Error; err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to fetch NIM whitelist")
+		return
+	}
+
+	utils.SuccessResponse(c, gin.H{
+		"entries": entries,
+		"pagination": gin.H{
+			"page":        page,
+			"limit":       limit,
+			"total":       total,
+			"total_pages": (total + int64(limit) - 1) / int64(limit),
+		},
+	})
+}
+
+// createStaffProfile membuat profil dosen/kajur/rektor yang terhubung ke user baru.
+// Kajur juga dosen pengajar, jadi mendapat profil dosen dan profil kajur.
+func createStaffProfile(tx *gorm.DB, user models.Users, invitation models.StaffInvitation, req models.AcceptInvitationRequest) error {
+	dosen := models.Dosen{
+		UserID:      &user.ID,
+		NIDN:        req.NIDN,
+		Nama:        req.Nama,
+		Email:       user.Email,
+		PhoneNumber: req.PhoneNumber,
+		Jurusan:     invitation.Jurusan,
+		Status:      "aktif",
+	}
+
+	switch invitation.Role {
+	case "dosen":
+		return tx.Create(&dosen).Error
+	case "kajur":
+		if err := tx.Create(&dosen).Error; err != nil {
+			return err
+		}
+		return tx.Create(&models.Kajur{
+			UserID:  &user.ID,
+			NIDN:    req.NIDN,
+			Nama:    req.Nama,
+			Email:   user.Email,
+			Jurusan: invitation.Jurusan,
+			Status:  "aktif",
+		}).Error
+	case "rektor":
+		return tx.Create(&models.Rektor{
+			UserID: &user.ID,
+			NIDN:   req.NIDN,
+			Nama:   req.Nama,
+			Email:  user.Email,
+			Status: "aktif",
+		}).Error
+	}
+	return fmt.Errorf("unsupported invitation role %q", invitation.Role)
+}
+
+func invitationResponse(invitation models.StaffInvitation) models.InvitationResponse {
+	return models.InvitationResponse{
+		ID:        invitation.ID,
+		Email:     invitation.Email,
+		Role:      invitation.Role,
+		Jurusan:   invitation.Jurusan,
+		Status:    invitation.Status(),
+		InvitedBy: invitation.InvitedBy,
+		ExpiresAt: invitation.ExpiresAt,
+		RevokedAt: invitation.RevokedAt,
+		CreatedAt: invitation.CreatedAt,
+	}
+}
+
+func generateInvitationToken() (string, error) {
+	b := make([]byte, 24)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
@@ -2,12 +2,14 @@ package controllers
 
 import (
 	"SIAku/config"
+	"SIAku/middleware"
 	"SIAku/models"
 	"SIAku/utils"
 	"net/http"
 	"strings"
 
 	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
 )
 
 type JadwalController struct{}
@@ -17,13 +19,13 @@ func NewJadwalController() *JadwalController {
 }
 
 func (jc *JadwalController) GetMyJadwal(c *gin.Context) {
-	userID, _ := c.Get("user_id")
+	mahasiswaID := middleware.GetPrincipal(c).MahasiswaID
 	semester := c.DefaultQuery("semester", "")
 	tahunAjaran := c.DefaultQuery("tahun_ajaran", "")
 	hari := c.DefaultQuery("hari", "")
 
 	var krs []models.KRS
-	krsQuery := config.DB.Where("mahasiswa_id = ?", userID)
+	krsQuery := config.DB.Where("mahasiswa_id = ?", mahasiswaID)
 
 	if semester != "" {
 		krsQuery = krsQuery.Where("semester = ?", semester)
@@ -48,7 +50,7 @@ func (jc *JadwalController) GetMyJadwal(c *gin.Context) {
 	}
 
 	var jadwal []models.Jadwal
-	jadwalQuery := config.DB.Preload("Course").Where("course_id IN ?", courseIDs)
+	jadwalQuery := config.DB.Preload("Course").Scopes(krsJadwalScope(krs))
 
 	if hari != "" {
 		jadwalQuery = jadwalQuery.Where("LOWER(hari) = LOWER(?)", hari)
@@ -82,7 +84,7 @@ func (jc *JadwalController) GetMyJadwal(c *gin.Context) {
 }
 
 func (jc *JadwalController) GetJadwalByHari(c *gin.Context) {
-	userID, _ := c.Get("user_id")
+	mahasiswaID := middleware.GetPrincipal(c).MahasiswaID
 	hari := strings.ToLower(c.Param("hari"))
 
 	validHari := map[string]bool{
@@ -96,7 +98,7 @@ func (jc *JadwalController) GetJadwalByHari(c *gin.Context) {
 	}
 
 	var krs []models.KRS
-	if err := config.DB.Where("mahasiswa_id = ?", userID).Find(&krs).Error; err != nil {
+	if err := config.DB.Where("mahasiswa_id = ?", mahasiswaID).Find(&krs).Error; err != nil {
 		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to fetch KRS")
 		return
 	}
@@ -113,7 +115,8 @@ func (jc *JadwalController) GetJadwalByHari(c *gin.Context) {
 
 	var jadwal []models.Jadwal
 	if err := config.DB.Preload("Course").
-		Where("course_id IN ? AND LOWER(hari) = ?", courseIDs, hari).
+		Scopes(krsJadwalScope(krs)).
+		Where("LOWER(hari) = ?", hari).
 		Order("jam_mulai ASC").
 		Find(&jadwal).Error; err != nil {
 		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to fetch jadwal")
@@ -143,10 +146,10 @@ func (jc *JadwalController) GetJadwalByHari(c *gin.Context) {
 }
 
 func (jc *JadwalController) GetJadwalMingguIni(c *gin.Context) {
-	userID, _ := c.Get("user_id")
+	mahasiswaID := middleware.GetPrincipal(c).MahasiswaID
 
 	var krs []models.KRS
-	if err := config.DB.Where("mahasiswa_id = ?", userID).Find(&krs).Error; err != nil {
+	if err := config.DB.Where("mahasiswa_id = ?", mahasiswaID).Find(&krs).Error; err != nil {
 		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to fetch KRS")
 		return
 	}
@@ -163,7 +166,7 @@ func (jc *JadwalController) GetJadwalMingguIni(c *gin.Context) {
 
 	var jadwal []models.Jadwal
 	if err := config.DB.Preload("Course").
-		Where("course_id IN ?", courseIDs).
+		Scopes(krsJadwalScope(krs)).
 		Order("CASE WHEN LOWER(hari) = 'senin' THEN 1 WHEN LOWER(hari) = 'selasa' THEN 2 WHEN LOWER(hari) = 'rabu' THEN 3 WHEN LOWER(hari) = 'kamis' THEN 4 WHEN LOWER(hari) = 'jumat' THEN 5 WHEN LOWER(hari) = 'sabtu' THEN 6 WHEN LOWER(hari) = 'minggu' THEN 7 END, jam_mulai ASC").
 		Find(&jadwal).Error; err != nil {
 		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to fetch jadwal")
@@ -196,3 +199,19 @@ func (jc *JadwalController) GetJadwalMingguIni(c *gin.Context) {
 
 	utils.SuccessResponse(c, jadwalMinggu)
 }
+
+// krsJadwalScope memilih jadwal sesuai KRS: jadwal kelas untuk KRS yang memilih kelas paralel,
+// selain itu jadwal mata kuliah yang tidak terikat kelas
+func krsJadwalScope(krs []models.KRS) func(*gorm.DB) *gorm.DB {
+	var kelasIDs, courseIDs []uint
+	for _, k := range krs {
+		if k.KelasID != nil {
+			kelasIDs = append(kelasIDs, *k.KelasID)
+		} else {
+			courseIDs = append(courseIDs, k.CourseID)
+		}
+	}
+	return func(db *gorm.DB) *gorm.DB {
+		return db.Where("(kelas_id IN ? OR (kelas_id IS NULL AND course_id IN ?))", kelasIDs, courseIDs)
+	}
+}
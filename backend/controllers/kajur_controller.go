@@ -2,13 +2,16 @@ package controllers
 
 import (
 	"SIAku/config"
+	"SIAku/middleware"
 	"SIAku/models"
 	"SIAku/utils"
+	"fmt"
 	"net/http"
 	"strconv"
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
 )
 
 type KajurController struct{}
@@ -19,7 +22,7 @@ func NewKajurController() *KajurController {
 
 // Dashboard Kajur - Overview data jurusan
 func (kc *KajurController) GetDashboard(c *gin.Context) {
-	kajurID, _ := c.Get("user_id")
+	kajurID := middleware.GetPrincipal(c).KajurID
 
 	// Ambil data kajur untuk mendapatkan jurusan
 	var kajur models.Kajur
@@ -78,7 +81,7 @@ func (kc *KajurController) GetDashboard(c *gin.Context) {
 	var pendingKRS int64
 	config.DB.Table("krs").
 		Joins("JOIN mahasiswas ON krs.mahasiswa_id = mahasiswas.id").
-		Where("mahasiswas.jurusan = ? AND krs.approval_status = 'pending'", kajur.Jurusan).
+		Where("mahasiswas.jurusan = ? AND krs.approval_status = ?", kajur.Jurusan, models.KRSApprovedWali).
 		Count(&pendingKRS)
 
 	dashboard := models.KajurDashboardResponse{
@@ -100,7 +103,7 @@ func (kc *KajurController) GetDashboard(c *gin.Context) {
 
 // Lihat semua mahasiswa di jurusan
 func (kc *KajurController) GetMahasiswaDiJurusan(c *gin.Context) {
-	kajurID, _ := c.Get("user_id")
+	kajurID := middleware.GetPrincipal(c).KajurID
 	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
 	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
 	semester := c.Query("semester")
@@ -165,7 +168,7 @@ func (kc *KajurController) GetMahasiswaDiJurusan(c *gin.Context) {
 
 // Lihat semua dosen di jurusan
 func (kc *KajurController) GetDosenDiJurusan(c *gin.Context) {
-	kajurID, _ := c.Get("user_id")
+	kajurID := middleware.GetPrincipal(c).KajurID
 	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
 	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
 	status := c.Query("status")
@@ -230,7 +233,7 @@ func (kc *KajurController) GetDosenDiJurusan(c *gin.Context) {
 
 // Approve/Validasi KRS mahasiswa di jurusan
 func (kc *KajurController) GetPendingKRSValidation(c *gin.Context) {
-	kajurID, _ := c.Get("user_id")
+	kajurID := middleware.GetPrincipal(c).KajurID
 	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
 	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
 	semester := c.Query("semester")
@@ -246,7 +249,7 @@ func (kc *KajurController) GetPendingKRSValidation(c *gin.Context) {
 	offset := (page - 1) * limit
 	query := config.DB.Preload("Mahasiswa").Preload("Course").
 		Joins("JOIN mahasiswas ON krs.mahasiswa_id = mahasiswas.id").
-		Where("mahasiswas.jurusan = ? AND krs.approval_status = 'pending'", kajur.Jurusan)
+		Where("mahasiswas.jurusan = ? AND krs.approval_status = ?", kajur.Jurusan, models.KRSApprovedWali)
 
 	if semester != "" {
 		query = query.Where("krs.semester = ?", semester)
@@ -301,7 +304,7 @@ func (kc *KajurController) GetPendingKRSValidation(c *gin.Context) {
 
 // Validasi KRS (approve/reject) oleh Kajur
 func (kc *KajurController) ProcessKRSValidation(c *gin.Context) {
-	kajurID, _ := c.Get("user_id")
+	kajurID := middleware.GetPrincipal(c).KajurID
 	krsID := c.Param("krsId")
 
 	var req models.KRSApprovalRequest
@@ -335,44 +338,126 @@ func (kc *KajurController) ProcessKRSValidation(c *gin.Context) {
 		return
 	}
 
-	// Update status approval
-	now := time.Now()
-	kajurIDUint := kajurID.(uint)
-	if req.Action == "approve" {
-		krs.ApprovalStatus = "approved"
-		krs.Status = "diambil"
-		krs.ApprovedBy = &kajurIDUint
-		krs.ApprovedAt = &now
-		krs.RejectionReason = ""
-	} else {
-		krs.ApprovalStatus = "rejected"
-		krs.Status = "ditolak"
-		krs.ApprovedBy = &kajurIDUint
-		krs.ApprovedAt = &now
-		krs.RejectionReason = req.RejectionReason
+	// Persetujuan hanya pada masa persetujuan KRS atau perubahan rencana studi
+	if !requireKRSWindow(c, krs.MahasiswaID, krs.Semester, krs.TahunAjaran, models.WindowApproval, models.WindowAddDrop) {
+		return
 	}
 
-	if err := config.DB.Save(&krs).Error; err != nil {
-		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to process KRS validation")
+	// Transisi dijaga state machine; penolakan melepas kursi kelas
+	var seatReleased bool
+	err := config.DB.Transaction(func(tx *gorm.DB) error {
+		var err error
+		seatReleased, err = transitionKRS(c, tx, &krs, "kajur", req.Action, req.RejectionReason)
+		return err
+	})
+	if err != nil {
+		respondKRSTransitionError(c, err, "Failed to process KRS validation")
 		return
 	}
+	if seatReleased {
+		promoteWaitlist(*krs.KelasID)
+	}
 
 	utils.SuccessResponse(c, gin.H{
-		"message": "KRS " + req.Action + "d successfully by department head",
-		"krs": gin.H{
-			"id":               krs.ID,
-			"approval_status":  krs.ApprovalStatus,
-			"status":           krs.Status,
-			"approved_by":      krs.ApprovedBy,
-			"approved_at":      krs.ApprovedAt,
-			"rejection_reason": krs.RejectionReason,
-		},
+		"message": "KRS " + req.Action + " processed by department head",
+		"krs":     krsApprovalSummary(krs),
+	})
+}
+
+// BulkValidateKRS - Validasi banyak paket KRS sekaligus dalam satu transaksi (semua berhasil atau tidak sama sekali)
+func (kc *KajurController) BulkValidateKRS(c *gin.Context) {
+	kajurID := middleware.GetPrincipal(c).KajurID
+
+	var req models.BulkKRSValidationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid JSON format")
+		return
+	}
+
+	if err := utils.ValidateStruct(req); err != nil {
+		utils.HandleValidationError(c, err)
+		return
+	}
+
+	var kajur models.Kajur
+	if err := config.DB.Where("id = ?", kajurID).First(&kajur).Error; err != nil {
+		utils.ErrorResponse(c, http.StatusNotFound, "Kajur not found")
+		return
+	}
+
+	// Semua paket harus milik jurusan kajur dan berada di masa persetujuan sebelum ada yang diproses
+	seen := map[string]bool{}
+	for _, pkg := range req.Packages {
+		key := fmt.Sprintf("%d/%d/%s", pkg.MahasiswaID, pkg.Semester, pkg.TahunAjaran)
+		if seen[key] {
+			utils.ErrorResponse(c, http.StatusBadRequest, "Duplicate package for mahasiswa "+strconv.Itoa(int(pkg.MahasiswaID)))
+			return
+		}
+		seen[key] = true
+
+		var mahasiswa models.Mahasiswa
+		if err := config.DB.Where("id = ?", pkg.MahasiswaID).First(&mahasiswa).Error; err != nil {
+			utils.ErrorResponse(c, http.StatusNotFound, "Mahasiswa "+strconv.Itoa(int(pkg.MahasiswaID))+" not found")
+			return
+		}
+		if mahasiswa.Jurusan != kajur.Jurusan {
+			utils.ErrorResponse(c, http.StatusForbidden, "Mahasiswa "+mahasiswa.NIM+" is not in your department")
+			return
+		}
+
+		open, _, err := openKRSWindow(pkg.MahasiswaID, pkg.Semester, pkg.TahunAjaran, models.WindowApproval, models.WindowAddDrop)
+		if err != nil {
+			utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to check academic calendar")
+			return
+		}
+		if open == "" {
+			utils.ErrorResponse(c, http.StatusForbidden, "Di luar jadwal persetujuan KRS untuk mahasiswa "+mahasiswa.NIM+" tahun ajaran "+pkg.TahunAjaran)
+			return
+		}
+	}
+
+	results := make([]gin.H, 0, len(req.Packages))
+	var released []uint
+	err := config.DB.Transaction(func(tx *gorm.DB) error {
+		for _, pkg := range req.Packages {
+			entries, err := loadKRSPackage(tx, pkg.MahasiswaID, pkg.Semester, pkg.TahunAjaran)
+			if err != nil {
+				return err
+			}
+			kelasIDs, err := transitionKRSPackage(c, tx, entries, "kajur", req.Action, req.Note, nil)
+			if err != nil {
+				return fmt.Errorf("mahasiswa %d semester %d: %w", pkg.MahasiswaID, pkg.Semester, err)
+			}
+			released = append(released, kelasIDs...)
+
+			items := make([]gin.H, 0, len(entries))
+			for _, entry := range entries {
+				items = append(items, krsApprovalSummary(entry))
+			}
+			results = append(results, gin.H{
+				"mahasiswa_id": pkg.MahasiswaID,
+				"semester":     pkg.Semester,
+				"tahun_ajaran": pkg.TahunAjaran,
+				"krs":          items,
+			})
+		}
+		return nil
+	})
+	if err != nil {
+		respondKRSTransitionError(c, err, "Failed to validate KRS packages")
+		return
+	}
+	promoteReleasedKelas(released)
+
+	utils.SuccessResponse(c, gin.H{
+		"message":  "KRS packages " + req.Action + " processed by department head",
+		"packages": results,
 	})
 }
 
 // Monitoring nilai & absensi dosen di jurusan
 func (kc *KajurController) GetMonitoringDosenPerformance(c *gin.Context) {
-	kajurID, _ := c.Get("user_id")
+	kajurID := middleware.GetPrincipal(c).KajurID
 	semester := c.DefaultQuery("semester", "")
 	tahunAjaran := c.DefaultQuery("tahun_ajaran", getCurrentAcademicYear())
 
@@ -435,7 +520,7 @@ func (kc *KajurController) GetMonitoringDosenPerformance(c *gin.Context) {
 			// Hitung jumlah mahasiswa terdaftar
 			var mahasiswaCount int64
 			config.DB.Model(&models.KRS{}).
-				Where("course_id = ? AND approval_status = 'approved'", course.ID).
+				Where("course_id = ? AND approval_status = ?", course.ID, models.KRSValidatedKajur).
 				Count(&mahasiswaCount)
 
 			// Hitung tingkat kehadiran rata-rata
@@ -493,7 +578,7 @@ func (kc *KajurController) GetMonitoringDosenPerformance(c *gin.Context) {
 
 // Generate laporan jurusan
 func (kc *KajurController) GenerateLaporanJurusan(c *gin.Context) {
-	kajurID, _ := c.Get("user_id")
+	kajurID := middleware.GetPrincipal(c).KajurID
 	tahunAjaran := c.DefaultQuery("tahun_ajaran", getCurrentAcademicYear())
 
 	// Ambil data kajur untuk mendapatkan jurusan
@@ -622,7 +707,7 @@ func (kc *KajurController) GenerateLaporanJurusan(c *gin.Context) {
 			AVG(n.nilai_akhir) as rata_nilai,
 			CASE 
 				WHEN COUNT(n.id) > 0 THEN 
-					CAST(SUM(CASE WHEN n.grade_huruf != 'E' THEN 1 ELSE 0 END) AS FLOAT) / COUNT(n.id) * 100
+					CAST(SUM(CASE WHEN n.grade_point > 0 THEN 1 ELSE 0 END) AS FLOAT) / COUNT(n.id) * 100
 				ELSE 0 
 			END as tingkat_lulus,
 			CASE 
@@ -632,13 +717,13 @@ func (kc *KajurController) GenerateLaporanJurusan(c *gin.Context) {
 			END as rata_kehadiran
 		FROM courses c
 		JOIN dosens d ON c.dosen_id = d.id
-		LEFT JOIN krs k ON c.id = k.course_id AND k.approval_status = 'approved'
+		LEFT JOIN krs k ON c.id = k.course_id AND k.approval_status = ?
 		LEFT JOIN nilais n ON c.id = n.course_id
 		LEFT JOIN absensis a ON c.id = a.course_id
 		WHERE d.jurusan = ?
 		GROUP BY c.id, c.code, c.name, d.nama
 		ORDER BY c.code
-	`, kajur.Jurusan).Rows()
+	`, models.KRSValidatedKajur, kajur.Jurusan).Rows()
 
 	if err == nil {
 		defer rows.Close()
@@ -670,7 +755,7 @@ func (kc *KajurController) GenerateLaporanJurusan(c *gin.Context) {
 
 // Manage mata kuliah - Lihat semua mata kuliah di jurusan
 func (kc *KajurController) GetMataKuliahDiJurusan(c *gin.Context) {
-	kajurID, _ := c.Get("user_id")
+	kajurID := middleware.GetPrincipal(c).KajurID
 	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
 	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
 	semester := c.Query("semester")
@@ -709,7 +794,7 @@ func (kc *KajurController) GetMataKuliahDiJurusan(c *gin.Context) {
 		// Hitung jumlah mahasiswa terdaftar
 		var mahasiswaCount int64
 		config.DB.Model(&models.KRS{}).
-			Where("course_id = ? AND approval_status = 'approved'", course.ID).
+			Where("course_id = ? AND approval_status = ?", course.ID, models.KRSValidatedKajur).
 			Count(&mahasiswaCount)
 
 		// Cek apakah ada jadwal aktif
@@ -756,7 +841,7 @@ func (kc *KajurController) GetMataKuliahDiJurusan(c *gin.Context) {
 
 // Buka/Tutup Kelas baru - Update status mata kuliah
 func (kc *KajurController) UpdateStatusMataKuliah(c *gin.Context) {
-	kajurID, _ := c.Get("user_id")
+	kajurID := middleware.GetPrincipal(c).KajurID
 	courseID := c.Param("courseId")
 
 	var req struct {
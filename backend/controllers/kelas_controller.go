@@ -0,0 +1,327 @@
+package controllers
+
+import (
+	"SIAku/config"
+	"SIAku/middleware"
+	"SIAku/models"
+	"SIAku/utils"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+type KelasController struct{}
+
+func NewKelasController() *KelasController {
+	return &KelasController{}
+}
+
+// CreateKelas - Kajur membuka kelas paralel beserta dosen, kapasitas dan jadwalnya
+func (kc *KelasController) CreateKelas(c *gin.Context) {
+	var req models.KelasRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid JSON format")
+		return
+	}
+
+	if err := utils.ValidateStruct(req); err != nil {
+		utils.HandleValidationError(c, err)
+		return
+	}
+
+	course, ok := findDepartmentCourse(c, strconv.FormatUint(uint64(req.CourseID), 10))
+	if !ok {
+		return
+	}
+
+	dosen, ok := findDepartmentDosen(c, req.DosenID)
+	if !ok {
+		return
+	}
+
+	for _, item := range req.Jadwal {
+		start, _ := utils.ParseJam(item.JamMulai)
+		end, _ := utils.ParseJam(item.JamSelesai)
+		if end <= start {
+			utils.ErrorResponse(c, http.StatusBadRequest, "jam_selesai must be after jam_mulai")
+			return
+		}
+	}
+
+	kelas := models.Kelas{
+		CourseID:    course.ID,
+		TahunAjaran: req.TahunAjaran,
+		Nama:        strings.ToUpper(strings.TrimSpace(req.Nama)),
+		DosenID:     &dosen.ID,
+		Kapasitas:   req.Kapasitas,
+	}
+
+	var exists int64
+	config.DB.Model(&models.Kelas{}).
+		Where("course_id = ? AND tahun_ajaran = ? AND nama = ?", kelas.CourseID, kelas.TahunAjaran, kelas.Nama).
+		Count(&exists)
+	if exists > 0 {
+		utils.ErrorResponse(c, http.StatusConflict, "Kelas "+kelas.Nama+" already exists for this course and academic year")
+		return
+	}
+
+	err := config.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&kelas).Error; err != nil {
+			return err
+		}
+		for _, item := range req.Jadwal {
+			jadwal := models.Jadwal{
+				CourseID:    course.ID,
+				KelasID:     &kelas.ID,
+				Hari:        item.Hari,
+				JamMulai:    item.JamMulai,
+				JamSelesai:  item.JamSelesai,
+				Ruangan:     item.Ruangan,
+				Dosen:       dosen.Nama,
+				TipeKelas:   item.TipeKelas,
+				Semester:    course.Semester,
+				TahunAjaran: kelas.TahunAjaran,
+			}
+			if err := tx.Create(&jadwal).Error; err != nil {
+				return err
+			}
+			kelas.Jadwal = append(kelas.Jadwal, jadwal)
+		}
+		return middleware.RecordAudit(c, tx, "create", "kelas", kelas.ID, nil, kelas)
+	})
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to create kelas")
+		return
+	}
+
+	kelas.Dosen = &dosen
+	utils.CreatedResponse(c, kelasResponse(kelas, 0))
+}
+
+// UpdateKelas - Ganti dosen atau kapasitas kelas; tambahan kapasitas langsung mempromosikan antrian
+func (kc *KelasController) UpdateKelas(c *gin.Context) {
+	var req models.UpdateKelasRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid JSON format")
+		return
+	}
+
+	if err := utils.ValidateStruct(req); err != nil {
+		utils.HandleValidationError(c, err)
+		return
+	}
+
+	kelas, ok := findDepartmentKelas(c, c.Param("id"))
+	if !ok {
+		return
+	}
+
+	before := kelas
+	updates := map[string]interface{}{}
+	if req.DosenID != 0 {
+		dosen, ok := findDepartmentDosen(c, req.DosenID)
+		if !ok {
+			return
+		}
+		updates["dosen_id"] = dosen.ID
+		kelas.DosenID = &dosen.ID
+	}
+	if req.Kapasitas != 0 {
+		updates["kapasitas"] = req.Kapasitas
+		kelas.Kapasitas = req.Kapasitas
+	}
+	if len(updates) == 0 {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Nothing to update")
+		return
+	}
+
+	// Kapasitas tidak boleh di bawah kursi yang sudah terpakai (dicek bersyarat terhadap nilai terisi terbaru)
+	err := config.DB.Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&models.Kelas{}).Where("id = ? AND terisi <= ?", kelas.ID, kelas.Kapasitas).Updates(updates)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return errKelasCapacityTooLow
+		}
+		return middleware.RecordAudit(c, tx, "update", "kelas", kelas.ID, before, kelas)
+	})
+	if errors.Is(err, errKelasCapacityTooLow) {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Kapasitas tidak boleh kurang dari jumlah mahasiswa terdaftar")
+		return
+	}
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to update kelas")
+		return
+	}
+
+	promoteWaitlist(kelas.ID)
+
+	config.DB.Preload("Dosen").Preload("Jadwal").Where("id = ?", kelas.ID).First(&kelas)
+	var waiting int64
+	config.DB.Model(&models.KelasWaitlist{}).Where("kelas_id = ? AND status = ?", kelas.ID, "menunggu").Count(&waiting)
+	utils.SuccessResponse(c, kelasResponse(kelas, waiting))
+}
+
+// GetKelas - Daftar kelas paralel di jurusan kajur, filter course_id dan tahun_ajaran
+func (kc *KelasController) GetKelas(c *gin.Context) {
+	tahunAjaran := c.DefaultQuery("tahun_ajaran", getCurrentAcademicYear())
+
+	query := config.DB.Preload("Dosen").Preload("Jadwal").Preload("Course").
+		Joins("JOIN courses ON courses.id = kelas.course_id").
+		Joins("JOIN dosens ON dosens.id = courses.dosen_id").
+		Where("dosens.jurusan = ? AND kelas.tahun_ajaran = ?", middleware.GetPrincipal(c).Jurusan, tahunAjaran)
+	if courseID := c.Query("course_id"); courseID != "" {
+		query = query.Where("kelas.course_id = ?", courseID)
+	}
+
+	var kelas []models.Kelas
+	if err := query.Order("kelas.course_id ASC, kelas.nama ASC").Find(&kelas).Error; err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to fetch kelas")
+		return
+	}
+
+	responses := make([]gin.H, 0, len(kelas))
+	for _, k := range kelas {
+		var waiting int64
+		config.DB.Model(&models.KelasWaitlist{}).Where("kelas_id = ? AND status = ?", k.ID, "menunggu").Count(&waiting)
+		responses = append(responses, gin.H{
+			"course_code": k.Course.Code,
+			"course_name": k.Course.Name,
+			"kelas":       kelasResponse(k, waiting),
+		})
+	}
+
+	utils.SuccessResponse(c, responses)
+}
+
+// GetKelasWaitlist - Antrian kelas sesuai urutan FIFO
+func (kc *KelasController) GetKelasWaitlist(c *gin.Context) {
+	kelas, ok := findDepartmentKelas(c, c.Param("id"))
+	if !ok {
+		return
+	}
+
+	query := config.DB.Preload("Mahasiswa").Where("kelas_id = ?", kelas.ID)
+	if status := c.Query("status"); status != "" {
+		query = query.Where("status = ?", status)
+	}
+
+	var entries []models.KelasWaitlist
+	if err := query.Order("id ASC").Find(&entries).Error; err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to fetch waitlist")
+		return
+	}
+
+	var waitlist []gin.H
+	position := 0
+	for _, entry := range entries {
+		item := gin.H{
+			"id":           entry.ID,
+			"mahasiswa_id": entry.MahasiswaID,
+			"nim":          entry.Mahasiswa.NIM,
+			"nama":         entry.Mahasiswa.Nama,
+			"status":       entry.Status,
+			"note":         entry.Note,
+			"promoted_at":  entry.PromotedAt,
+			"created_at":   entry.CreatedAt,
+		}
+		if entry.Status == "menunggu" {
+			position++
+			item["position"] = position
+		}
+		waitlist = append(waitlist, item)
+	}
+
+	utils.SuccessResponse(c, gin.H{
+		"kelas":    kelasResponse(kelas, int64(position)),
+		"waitlist": waitlist,
+	})
+}
+
+// GetMyWaitlist - Antrian kelas milik mahasiswa beserta posisinya
+func (kc *KelasController) GetMyWaitlist(c *gin.Context) {
+	mahasiswaID := middleware.GetPrincipal(c).MahasiswaID
+
+	var entries []models.KelasWaitlist
+	if err := config.DB.Preload("Kelas.Course").Where("mahasiswa_id = ?", mahasiswaID).
+		Order("created_at DESC").Find(&entries).Error; err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to fetch waitlist")
+		return
+	}
+
+	responses := make([]models.WaitlistResponse, 0, len(entries))
+	for _, entry := range entries {
+		response := models.WaitlistResponse{
+			ID:          entry.ID,
+			KelasID:     entry.KelasID,
+			KelasNama:   entry.Kelas.Nama,
+			CourseID:    entry.Kelas.CourseID,
+			CourseCode:  entry.Kelas.Course.Code,
+			CourseName:  entry.Kelas.Course.Name,
+			TahunAjaran: entry.Kelas.TahunAjaran,
+			Status:      entry.Status,
+			Note:        entry.Note,
+			PromotedAt:  entry.PromotedAt,
+			CreatedAt:   entry.CreatedAt,
+		}
+		if entry.Status == "menunggu" {
+			response.Position = waitlistPosition(config.DB, entry)
+		}
+		responses = append(responses, response)
+	}
+
+	utils.SuccessResponse(c, responses)
+}
+
+// CancelWaitlist - Mahasiswa keluar dari antrian kelas
+func (kc *KelasController) CancelWaitlist(c *gin.Context) {
+	mahasiswaID := middleware.GetPrincipal(c).MahasiswaID
+
+	result := config.DB.Model(&models.KelasWaitlist{}).
+		Where("id = ? AND mahasiswa_id = ? AND status = ?", c.Param("id"), mahasiswaID, "menunggu").
+		Update("status", "dibatalkan")
+	if result.Error != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to cancel waitlist")
+		return
+	}
+	if result.RowsAffected == 0 {
+		utils.ErrorResponse(c, http.StatusNotFound, "Waitlist entry not found")
+		return
+	}
+
+	utils.SuccessResponse(c, gin.H{"message": "Waitlist entry cancelled"})
+}
+
+// findDepartmentKelas memuat kelas dan memastikan mata kuliahnya di jurusan kajur
+func findDepartmentKelas(c *gin.Context, kelasID string) (models.Kelas, bool) {
+	var kelas models.Kelas
+	if err := config.DB.Preload("Dosen").Preload("Jadwal").Where("id = ?", kelasID).First(&kelas).Error; err != nil {
+		utils.ErrorResponse(c, http.StatusNotFound, "Kelas not found")
+		return kelas, false
+	}
+
+	if _, ok := findDepartmentCourse(c, strconv.FormatUint(uint64(kelas.CourseID), 10)); !ok {
+		return kelas, false
+	}
+	return kelas, true
+}
+
+// findDepartmentDosen memastikan dosen pengajar kelas berasal dari jurusan kajur
+func findDepartmentDosen(c *gin.Context, dosenID uint) (models.Dosen, bool) {
+	var dosen models.Dosen
+	if err := config.DB.Where("id = ?", dosenID).First(&dosen).Error; err != nil {
+		utils.ErrorResponse(c, http.StatusNotFound, "Dosen not found")
+		return dosen, false
+	}
+
+	if dosen.Jurusan != middleware.GetPrincipal(c).Jurusan {
+		utils.ErrorResponse(c, http.StatusForbidden, "Dosen must be from your department")
+		return dosen, false
+	}
+	return dosen, true
+}
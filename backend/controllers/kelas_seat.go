@@ -0,0 +1,277 @@
+package controllers
+
+import (
+	"SIAku/config"
+	"SIAku/models"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Helper kursi kelas dan antrian waitlist untuk KRSController, KelasController dan approval KRS.
+// Kursi hanya diubah lewat update bersyarat supaya pendaftaran paralel tidak melebihi kapasitas.
+// Urutan lock selalu baris mahasiswa dulu, baru baris kelas.
+
+var (
+	errKelasFull           = errors.New("kelas is full")
+	errAlreadyWaitlisted   = errors.New("already on the waitlist")
+	errKelasCapacityTooLow = errors.New("kapasitas below enrolled students")
+)
+
+// claimKelasSeat memakai satu kursi; errKelasFull jika kapasitas sudah habis
+func claimKelasSeat(tx *gorm.DB, kelasID uint) error {
+	result := tx.Model(&models.Kelas{}).
+		Where("id = ? AND terisi < kapasitas", kelasID).
+		UpdateColumn("terisi", gorm.Expr("terisi + 1"))
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return errKelasFull
+	}
+	return nil
+}
+
+// releaseKelasSeat mengembalikan satu kursi; antrian dipromosikan setelah transaksi selesai lewat promoteWaitlist
+func releaseKelasSeat(tx *gorm.DB, kelasID uint) error {
+	return tx.Model(&models.Kelas{}).
+		Where("id = ? AND terisi > 0", kelasID).
+		UpdateColumn("terisi", gorm.Expr("terisi - 1")).Error
+}
+
+// syncKelasSeat menyesuaikan kursi saat status KRS berubah dari/ke ditolak.
+// Mengembalikan true jika kursi dilepas sehingga antrian perlu dipromosikan.
+func syncKelasSeat(tx *gorm.DB, before, after models.KRS) (bool, error) {
+	if after.KelasID == nil {
+		return false, nil
+	}
+	wasRejected := before.Status == "ditolak"
+	isRejected := after.Status == "ditolak"
+
+	switch {
+	case !wasRejected && isRejected:
+		return true, releaseKelasSeat(tx, *after.KelasID)
+	case wasRejected && !isRejected:
+		return false, claimKelasSeat(tx, *after.KelasID)
+	}
+	return false, nil
+}
+
+// waitlistPosition menghitung posisi antrian (1 = berikutnya dipromosikan)
+func waitlistPosition(db *gorm.DB, entry models.KelasWaitlist) int64 {
+	var position int64
+	db.Model(&models.KelasWaitlist{}).
+		Where("kelas_id = ? AND status = ? AND id <= ?", entry.KelasID, "menunggu", entry.ID).
+		Count(&position)
+	return position
+}
+
+// promoteWaitlist mengisi kursi kosong dari antrian FIFO. Mahasiswa yang sudah tidak memenuhi syarat
+// (sudah mengambil mata kuliah, melebihi batas SKS atau jadwal bentrok) dilewati dengan catatan.
+func promoteWaitlist(kelasID uint) {
+	for {
+		var entry models.KelasWaitlist
+		err := config.DB.Where("kelas_id = ? AND status = ?", kelasID, "menunggu").Order("id ASC").First(&entry).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return
+		}
+		if err != nil {
+			log.Printf("Failed to load waitlist of kelas %d: %v", kelasID, err)
+			return
+		}
+
+		err = config.DB.Transaction(func(tx *gorm.DB) error {
+			return promoteWaitlistEntry(tx, entry)
+		})
+		if errors.Is(err, errKelasFull) {
+			return
+		}
+		if err != nil {
+			log.Printf("Failed to promote waitlist entry %d: %v", entry.ID, err)
+			return
+		}
+	}
+}
+
+func promoteWaitlistEntry(tx *gorm.DB, entry models.KelasWaitlist) error {
+	var mahasiswa models.Mahasiswa
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("id = ?", entry.MahasiswaID).First(&mahasiswa).Error; err != nil {
+		return err
+	}
+
+	// Entri bisa saja sudah dibatalkan mahasiswa atau dipromosikan proses lain
+	var current models.KelasWaitlist
+	err := tx.Where("id = ? AND status = ?", entry.ID, "menunggu").First(&current).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	var kelas models.Kelas
+	if err := tx.Preload("Course").Where("id = ?", entry.KelasID).First(&kelas).Error; err != nil {
+		return err
+	}
+
+	skip := func(note string) error {
+		return tx.Model(&current).Updates(map[string]interface{}{"status": "dilewati", "note": note}).Error
+	}
+
+	var existing int64
+	tx.Model(&models.KRS{}).
+		Where("mahasiswa_id = ? AND course_id = ? AND tahun_ajaran = ? AND approval_status <> ?",
+			mahasiswa.ID, kelas.CourseID, kelas.TahunAjaran, models.KRSRejected).
+		Count(&existing)
+	if existing > 0 {
+		return skip("Mata kuliah sudah ada di KRS")
+	}
+
+	// Prasyarat dicek ulang: nilai prasyarat bisa berubah (koreksi/sanggah) selama mahasiswa menunggu
+	unmet, err := evaluatePrerequisites(tx, mahasiswa.ID, []uint{kelas.CourseID})
+	if err != nil {
+		return err
+	}
+	if len(unmet[kelas.CourseID]) > 0 {
+		return skip("Prasyarat belum terpenuhi: " + describeUnmetPrerequisites(unmet[kelas.CourseID]))
+	}
+
+	quota, err := sksQuota(tx, mahasiswa, current.Semester, kelas.TahunAjaran)
+	if err != nil {
+		return err
+	}
+	if exceedsSKSQuota(quota, kelas.Course.Credits) {
+		return skip(fmt.Sprintf("Batas SKS terlampaui (%d dari %d SKS)", quota.TotalSKS, quota.MaxSKS))
+	}
+
+	candidates, err := candidateJadwal(tx, kelas.CourseID, &kelas.ID, kelas.TahunAjaran)
+	if err != nil {
+		return err
+	}
+	clashes, err := findScheduleClashes(tx, mahasiswa.ID, kelas.TahunAjaran, candidates)
+	if err != nil {
+		return err
+	}
+	if len(clashes) > 0 {
+		return skip("Jadwal bentrok dengan: " + describeScheduleClashes(clashes))
+	}
+
+	if err := claimKelasSeat(tx, kelas.ID); err != nil {
+		return err
+	}
+
+	krs := models.KRS{
+		MahasiswaID:    mahasiswa.ID,
+		CourseID:       kelas.CourseID,
+		KelasID:        &kelas.ID,
+		Semester:       current.Semester,
+		TahunAjaran:    kelas.TahunAjaran,
+		Status:         "diambil",
+		ApprovalStatus: models.KRSDraft,
+	}
+	if err := tx.Create(&krs).Error; err != nil {
+		return err
+	}
+
+	now := time.Now()
+	return tx.Model(&current).Updates(map[string]interface{}{
+		"status":      "dipromosikan",
+		"krs_id":      krs.ID,
+		"promoted_at": now,
+	}).Error
+}
+
+// taughtBy membatasi query courses ke mata kuliah yang diampu dosen, baik sebagai pengampu utama maupun dosen kelas paralel
+func taughtBy(dosenID uint) func(*gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		return db.Where("(courses.dosen_id = ? OR courses.id IN (?))", dosenID,
+			config.DB.Model(&models.Kelas{}).Select("course_id").Where("dosen_id = ?", dosenID))
+	}
+}
+
+// dosenKelasScope membatasi KRS ke kelas yang diajar dosen jika dosen bukan pengampu utama mata kuliah
+func dosenKelasScope(course models.Course, dosenID uint) func(*gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		if course.DosenID != nil && *course.DosenID == dosenID {
+			return db
+		}
+		return db.Where("kelas_id IN (?)",
+			config.DB.Model(&models.Kelas{}).Select("id").Where("course_id = ? AND dosen_id = ?", course.ID, dosenID))
+	}
+}
+
+// kelasOptions menyusun daftar kelas per mata kuliah lengkap dengan jadwal, sisa kursi dan panjang antrian
+func kelasOptions(tahunAjaran string, courseIDs []uint, jadwal []models.Jadwal, clashes map[uint][]models.ScheduleClash) (map[uint][]models.KelasResponse, error) {
+	result := map[uint][]models.KelasResponse{}
+	if len(courseIDs) == 0 {
+		return result, nil
+	}
+
+	var kelas []models.Kelas
+	if err := config.DB.Preload("Dosen").Where("tahun_ajaran = ? AND course_id IN ?", tahunAjaran, courseIDs).
+		Order("nama ASC").Find(&kelas).Error; err != nil {
+		return nil, err
+	}
+	if len(kelas) == 0 {
+		return result, nil
+	}
+
+	kelasIDs := make([]uint, 0, len(kelas))
+	for _, k := range kelas {
+		kelasIDs = append(kelasIDs, k.ID)
+	}
+	var counts []struct {
+		KelasID uint
+		Total   int64
+	}
+	if err := config.DB.Model(&models.KelasWaitlist{}).Select("kelas_id, COUNT(*) AS total").
+		Where("kelas_id IN ? AND status = ?", kelasIDs, "menunggu").
+		Group("kelas_id").Scan(&counts).Error; err != nil {
+		return nil, err
+	}
+	waiting := map[uint]int64{}
+	for _, count := range counts {
+		waiting[count.KelasID] = count.Total
+	}
+
+	jadwalByKelas := map[uint][]models.Jadwal{}
+	for _, j := range jadwal {
+		if j.KelasID != nil {
+			jadwalByKelas[*j.KelasID] = append(jadwalByKelas[*j.KelasID], j)
+		}
+	}
+
+	for _, k := range kelas {
+		response := kelasResponse(k, waiting[k.ID])
+		response.Jadwal = jadwalByKelas[k.ID]
+		response.ScheduleClashes = clashes[k.ID]
+		result[k.CourseID] = append(result[k.CourseID], response)
+	}
+	return result, nil
+}
+
+func kelasResponse(k models.Kelas, waitlist int64) models.KelasResponse {
+	response := models.KelasResponse{
+		ID:          k.ID,
+		CourseID:    k.CourseID,
+		TahunAjaran: k.TahunAjaran,
+		Nama:        k.Nama,
+		DosenID:     k.DosenID,
+		Kapasitas:   k.Kapasitas,
+		Terisi:      k.Terisi,
+		SisaKursi:   k.Kapasitas - k.Terisi,
+		Waitlist:    waitlist,
+		Jadwal:      k.Jadwal,
+	}
+	if k.Dosen != nil {
+		response.DosenNama = k.Dosen.Nama
+	}
+	if response.SisaKursi < 0 {
+		response.SisaKursi = 0
+	}
+	return response
+}
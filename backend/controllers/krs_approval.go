@@ -0,0 +1,114 @@
+package controllers
+
+import (
+	"SIAku/middleware"
+	"SIAku/models"
+	"SIAku/utils"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// Helper state machine persetujuan KRS untuk mahasiswa, dosen wali dan kajur
+
+var (
+	errIllegalKRSTransition = errors.New("illegal KRS transition")
+	errKRSStatusChanged     = errors.New("KRS status changed by another request")
+)
+
+// transitionKRS menerapkan aksi role pada KRS: update bersyarat terhadap status sekarang, riwayat transisi,
+// kursi kelas dan audit. Mengembalikan true jika kursi kelas dilepas sehingga antrian perlu dipromosikan.
+func transitionKRS(c *gin.Context, tx *gorm.DB, krs *models.KRS, role, action, note string) (bool, error) {
+	next, err := models.NextKRSStatus(krs.ApprovalStatus, role, action)
+	if err != nil {
+		return false, fmt.Errorf("%w: %v", errIllegalKRSTransition, err)
+	}
+
+	principal := middleware.GetPrincipal(c)
+	before := *krs
+	now := time.Now()
+
+	updates := map[string]interface{}{"approval_status": next}
+	switch next {
+	case models.KRSSubmitted:
+		updates["submitted_at"] = now
+		updates["revision_note"] = ""
+	case models.KRSApprovedWali:
+		updates["wali_approved_by"] = principal.DosenID
+		updates["wali_approved_at"] = now
+	case models.KRSValidatedKajur:
+		updates["validated_by"] = principal.KajurID
+		updates["validated_at"] = now
+	case models.KRSRejected:
+		updates["status"] = "ditolak"
+		updates["rejection_reason"] = note
+	case models.KRSRevisionRequested:
+		updates["revision_note"] = note
+	}
+
+	result := tx.Model(&models.KRS{}).Where("id = ? AND approval_status = ?", krs.ID, krs.ApprovalStatus).Updates(updates)
+	if result.Error != nil {
+		return false, result.Error
+	}
+	if result.RowsAffected == 0 {
+		return false, errKRSStatusChanged
+	}
+
+	if err := tx.Where("id = ?", krs.ID).First(krs).Error; err != nil {
+		return false, err
+	}
+
+	history := models.KRSApprovalHistory{
+		KRSID:      krs.ID,
+		FromStatus: before.ApprovalStatus,
+		ToStatus:   next,
+		Action:     action,
+		ActorID:    principal.UserID,
+		ActorRole:  role,
+		Note:       note,
+	}
+	if err := tx.Create(&history).Error; err != nil {
+		return false, err
+	}
+
+	seatReleased, err := syncKelasSeat(tx, before, *krs)
+	if err != nil {
+		return false, err
+	}
+
+	return seatReleased, middleware.RecordAudit(c, tx, action, "krs", krs.ID, before, *krs)
+}
+
+// respondKRSTransitionError memetakan error transitionKRS ke response HTTP
+func respondKRSTransitionError(c *gin.Context, err error, fallback string) {
+	switch {
+	case errors.Is(err, errIllegalKRSTransition):
+		utils.ErrorResponse(c, http.StatusConflict, err.Error())
+	case errors.Is(err, errKRSStatusChanged):
+		utils.ErrorResponse(c, http.StatusConflict, "KRS sudah diproses oleh request lain, muat ulang data")
+	case errors.Is(err, errKelasFull):
+		utils.ErrorResponse(c, http.StatusConflict, "Kelas sudah penuh")
+	default:
+		utils.ErrorResponse(c, http.StatusInternalServerError, fallback)
+	}
+}
+
+// krsApprovalSummary - ringkasan status persetujuan untuk response endpoint approval
+func krsApprovalSummary(krs models.KRS) gin.H {
+	return gin.H{
+		"id":               krs.ID,
+		"approval_status":  krs.ApprovalStatus,
+		"status":           krs.Status,
+		"submitted_at":     krs.SubmittedAt,
+		"wali_approved_by": krs.WaliApprovedBy,
+		"wali_approved_at": krs.WaliApprovedAt,
+		"validated_by":     krs.ValidatedBy,
+		"validated_at":     krs.ValidatedAt,
+		"rejection_reason": krs.RejectionReason,
+		"revision_note":    krs.RevisionNote,
+	}
+}
@@ -2,12 +2,18 @@ package controllers
 
 import (
 	"SIAku/config"
+	"SIAku/middleware"
 	"SIAku/models"
 	"SIAku/utils"
+	"errors"
+	"fmt"
 	"net/http"
 	"strconv"
+	"time"
 
 	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type KRSController struct{}
@@ -17,12 +23,12 @@ func NewKRSController() *KRSController {
 }
 
 func (kc *KRSController) GetMyKRS(c *gin.Context) {
-	userID, _ := c.Get("user_id")
+	mahasiswaID := middleware.GetPrincipal(c).MahasiswaID
 	semester := c.DefaultQuery("semester", "")
 	tahunAjaran := c.DefaultQuery("tahun_ajaran", "")
 
 	var krs []models.KRS
-	query := config.DB.Preload("Course").Where("mahasiswa_id = ?", userID)
+	query := config.DB.Preload("Course").Preload("Kelas").Where("mahasiswa_id = ?", mahasiswaID)
 
 	if semester != "" {
 		query = query.Where("semester = ?", semester)
@@ -36,26 +42,40 @@ func (kc *KRSController) GetMyKRS(c *gin.Context) {
 		return
 	}
 
+	var mahasiswa models.Mahasiswa
+	if err := config.DB.Where("id = ?", mahasiswaID).First(&mahasiswa).Error; err != nil {
+		utils.ErrorResponse(c, http.StatusNotFound, "Mahasiswa not found")
+		return
+	}
+
+	// Kuota SKS untuk semester yang diminta, default semester berjalan mahasiswa
+	quotaSemester := mahasiswa.Semester
+	if semester != "" {
+		quotaSemester, _ = strconv.Atoi(semester)
+	}
+	if tahunAjaran == "" {
+		tahunAjaran = getCurrentAcademicYear()
+	}
+
+	quota, err := sksQuota(config.DB, mahasiswa, quotaSemester, tahunAjaran)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to calculate SKS quota")
+		return
+	}
+
 	var responses []models.KRSResponse
 	for _, k := range krs {
-		responses = append(responses, models.KRSResponse{
-			ID:          k.ID,
-			CourseID:    k.CourseID,
-			CourseName:  k.Course.Name,
-			CourseCode:  k.Course.Code,
-			Credits:     k.Course.Credits,
-			Semester:    k.Semester,
-			TahunAjaran: k.TahunAjaran,
-			Status:      k.Status,
-			CreatedAt:   k.CreatedAt,
-		})
+		responses = append(responses, krsResponse(k))
 	}
 
-	utils.SuccessResponse(c, responses)
+	utils.SuccessResponse(c, gin.H{
+		"krs": responses,
+		"sks": quota,
+	})
 }
 
 func (kc *KRSController) AddCourseToKRS(c *gin.Context) {
-	userID, _ := c.Get("user_id")
+	mahasiswaID := middleware.GetPrincipal(c).MahasiswaID
 	var req models.KRSRequest
 
 	if err := c.ShouldBindJSON(&req); err != nil {
@@ -68,6 +88,11 @@ func (kc *KRSController) AddCourseToKRS(c *gin.Context) {
 		return
 	}
 
+	// Mata kuliah hanya bisa ditambah pada masa pengisian KRS atau perubahan rencana studi
+	if !requireKRSWindow(c, mahasiswaID, req.Semester, req.TahunAjaran, models.WindowKRS, models.WindowAddDrop) {
+		return
+	}
+
 	var course models.Course
 	if err := config.DB.Where("id = ?", req.CourseID).First(&course).Error; err != nil {
 		utils.ErrorResponse(c, http.StatusNotFound, "Course not found")
@@ -75,54 +100,220 @@ func (kc *KRSController) AddCourseToKRS(c *gin.Context) {
 	}
 
 	var existingKRS models.KRS
-	if err := config.DB.Where("mahasiswa_id = ? AND course_id = ? AND semester = ? AND tahun_ajaran = ?",
-		userID, req.CourseID, req.Semester, req.TahunAjaran).First(&existingKRS).Error; err == nil {
+	if err := config.DB.Where("mahasiswa_id = ? AND course_id = ? AND semester = ? AND tahun_ajaran = ? AND approval_status <> ?",
+		mahasiswaID, req.CourseID, req.Semester, req.TahunAjaran, models.KRSRejected).First(&existingKRS).Error; err == nil {
 		utils.ErrorResponse(c, http.StatusConflict, "Course already added to KRS")
 		return
 	}
 
+	// Mata kuliah dengan kelas paralel di tahun ajaran ini harus memilih kelas
+	var kelas *models.Kelas
+	if req.KelasID != 0 {
+		var found models.Kelas
+		if err := config.DB.Where("id = ? AND course_id = ? AND tahun_ajaran = ?", req.KelasID, course.ID, req.TahunAjaran).
+			First(&found).Error; err != nil {
+			utils.ErrorResponse(c, http.StatusNotFound, "Kelas not found for this course and academic year")
+			return
+		}
+		kelas = &found
+	} else {
+		var sections int64
+		config.DB.Model(&models.Kelas{}).Where("course_id = ? AND tahun_ajaran = ?", course.ID, req.TahunAjaran).Count(&sections)
+		if sections > 0 {
+			utils.ErrorResponse(c, http.StatusBadRequest, "kelas_id is required: this course has parallel classes")
+			return
+		}
+	}
+
+	// Prasyarat dicek terhadap riwayat nilai mahasiswa
+	unmet, err := evaluatePrerequisites(config.DB, mahasiswaID, []uint{course.ID})
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to check prerequisites")
+		return
+	}
+	if len(unmet[course.ID]) > 0 {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{
+			"success":             false,
+			"error":               "Prasyarat belum terpenuhi: " + describeUnmetPrerequisites(unmet[course.ID]),
+			"unmet_prerequisites": unmet[course.ID],
+		})
+		return
+	}
+
 	krs := models.KRS{
-		MahasiswaID: userID.(uint),
-		CourseID:    req.CourseID,
-		Semester:    req.Semester,
-		TahunAjaran: req.TahunAjaran,
-		Status:      "diambil",
+		MahasiswaID:    mahasiswaID,
+		CourseID:       req.CourseID,
+		Semester:       req.Semester,
+		TahunAjaran:    req.TahunAjaran,
+		Status:         "diambil",
+		ApprovalStatus: models.KRSDraft,
+	}
+	var kelasID *uint
+	if kelas != nil {
+		kelasID = &kelas.ID
+		krs.KelasID = kelasID
 	}
 
-	if err := config.DB.Create(&krs).Error; err != nil {
+	// Batas SKS dicek dan KRS dibuat dalam satu transaksi; baris mahasiswa dikunci
+	// supaya dua request paralel tidak sama-sama lolos dari batas
+	var quota models.SKSQuota
+	var clashes []models.ScheduleClash
+	var waitlist *models.KelasWaitlist
+	err = config.DB.Transaction(func(tx *gorm.DB) error {
+		var mahasiswa models.Mahasiswa
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("id = ?", mahasiswaID).First(&mahasiswa).Error; err != nil {
+			return err
+		}
+
+		var err error
+		quota, err = sksQuota(tx, mahasiswa, req.Semester, req.TahunAjaran)
+		if err != nil {
+			return err
+		}
+		if exceedsSKSQuota(quota, course.Credits) {
+			return errSKSLimitExceeded
+		}
+
+		// Bentrok jadwal dicek terhadap KRS di tahun ajaran yang sama
+		candidates, err := candidateJadwal(tx, course.ID, kelasID, req.TahunAjaran)
+		if err != nil {
+			return err
+		}
+		if clashes, err = findScheduleClashes(tx, mahasiswaID, req.TahunAjaran, candidates); err != nil {
+			return err
+		}
+		if len(clashes) > 0 {
+			return errScheduleClash
+		}
+
+		// Kelas penuh: mahasiswa masuk antrian dan dipromosikan otomatis saat ada kursi kosong
+		if kelas != nil {
+			err := claimKelasSeat(tx, kelas.ID)
+			if errors.Is(err, errKelasFull) {
+				var waiting int64
+				tx.Model(&models.KelasWaitlist{}).
+					Where("kelas_id = ? AND mahasiswa_id = ? AND status = ?", kelas.ID, mahasiswaID, "menunggu").
+					Count(&waiting)
+				if waiting > 0 {
+					return errAlreadyWaitlisted
+				}
+
+				waitlist = &models.KelasWaitlist{
+					KelasID:     kelas.ID,
+					MahasiswaID: mahasiswaID,
+					Semester:    req.Semester,
+					Status:      "menunggu",
+				}
+				return tx.Create(waitlist).Error
+			}
+			if err != nil {
+				return err
+			}
+		}
+
+		return tx.Create(&krs).Error
+	})
+	if errors.Is(err, errSKSLimitExceeded) {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{
+			"success": false,
+			"error":   fmt.Sprintf("Batas SKS terlampaui: %d + %d SKS melebihi batas %d SKS", quota.TotalSKS, course.Credits, quota.MaxSKS),
+			"sks":     quota,
+		})
+		return
+	}
+	if errors.Is(err, errScheduleClash) {
+		c.JSON(http.StatusConflict, gin.H{
+			"success":          false,
+			"error":            "Jadwal bentrok dengan: " + describeScheduleClashes(clashes),
+			"schedule_clashes": clashes,
+		})
+		return
+	}
+	if errors.Is(err, errAlreadyWaitlisted) {
+		utils.ErrorResponse(c, http.StatusConflict, "Already on the waitlist for this class")
+		return
+	}
+	if err != nil {
 		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to add course to KRS")
 		return
 	}
 
+	if waitlist != nil {
+		c.JSON(http.StatusAccepted, gin.H{
+			"success": true,
+			"message": "Kelas " + kelas.Nama + " penuh, Anda masuk daftar tunggu",
+			"data": models.WaitlistResponse{
+				ID:          waitlist.ID,
+				KelasID:     kelas.ID,
+				KelasNama:   kelas.Nama,
+				CourseID:    course.ID,
+				CourseCode:  course.Code,
+				CourseName:  course.Name,
+				TahunAjaran: kelas.TahunAjaran,
+				Position:    waitlistPosition(config.DB, *waitlist),
+				Status:      waitlist.Status,
+				CreatedAt:   waitlist.CreatedAt,
+			},
+		})
+		return
+	}
+
 	response := models.KRSResponse{
-		ID:          krs.ID,
-		CourseID:    course.ID,
-		CourseName:  course.Name,
-		CourseCode:  course.Code,
-		Credits:     course.Credits,
-		Semester:    krs.Semester,
-		TahunAjaran: krs.TahunAjaran,
-		Status:      krs.Status,
-		CreatedAt:   krs.CreatedAt,
+		ID:             krs.ID,
+		CourseID:       course.ID,
+		CourseName:     course.Name,
+		CourseCode:     course.Code,
+		KelasID:        krs.KelasID,
+		Credits:        course.Credits,
+		Semester:       krs.Semester,
+		TahunAjaran:    krs.TahunAjaran,
+		Status:         krs.Status,
+		ApprovalStatus: krs.ApprovalStatus,
+		CreatedAt:      krs.CreatedAt,
+	}
+	if kelas != nil {
+		response.KelasNama = kelas.Nama
 	}
 
 	utils.CreatedResponse(c, response)
 }
 
 func (kc *KRSController) RemoveCourseFromKRS(c *gin.Context) {
-	userID, _ := c.Get("user_id")
+	mahasiswaID := middleware.GetPrincipal(c).MahasiswaID
 	krsID := c.Param("id")
 
 	var krs models.KRS
-	if err := config.DB.Where("id = ? AND mahasiswa_id = ?", krsID, userID).First(&krs).Error; err != nil {
+	if err := config.DB.Where("id = ? AND mahasiswa_id = ?", krsID, mahasiswaID).First(&krs).Error; err != nil {
 		utils.ErrorResponse(c, http.StatusNotFound, "KRS entry not found")
 		return
 	}
 
-	if err := config.DB.Delete(&krs).Error; err != nil {
+	// KRS yang sudah disetujui dosen wali hanya bisa di-drop pada masa perubahan rencana studi
+	windows := []string{models.WindowKRS, models.WindowAddDrop}
+	if krs.ApprovalStatus == models.KRSApprovedWali || krs.ApprovalStatus == models.KRSValidatedKajur {
+		windows = []string{models.WindowAddDrop}
+	}
+	if !requireKRSWindow(c, mahasiswaID, krs.Semester, krs.TahunAjaran, windows...) {
+		return
+	}
+
+	// Kursi kelas dikembalikan lalu antrian kelas dipromosikan
+	err := config.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Delete(&krs).Error; err != nil {
+			return err
+		}
+		if krs.KelasID != nil && krs.Status != "ditolak" {
+			return releaseKelasSeat(tx, *krs.KelasID)
+		}
+		return nil
+	})
+	if err != nil {
 		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to remove course from KRS")
 		return
 	}
+	if krs.KelasID != nil {
+		promoteWaitlist(*krs.KelasID)
+	}
 
 	c.JSON(http.StatusOK, gin.H{
 		"success": true,
@@ -130,8 +321,103 @@ func (kc *KRSController) RemoveCourseFromKRS(c *gin.Context) {
 	})
 }
 
+// SubmitKRS - Mahasiswa mengajukan KRS draft atau hasil revisi ke dosen wali
+func (kc *KRSController) SubmitKRS(c *gin.Context) {
+	mahasiswaID := middleware.GetPrincipal(c).MahasiswaID
+
+	var krs models.KRS
+	if err := config.DB.Where("id = ? AND mahasiswa_id = ?", c.Param("id"), mahasiswaID).First(&krs).Error; err != nil {
+		utils.ErrorResponse(c, http.StatusNotFound, "KRS entry not found")
+		return
+	}
+
+	if !requireKRSWindow(c, mahasiswaID, krs.Semester, krs.TahunAjaran, models.WindowKRS, models.WindowAddDrop) {
+		return
+	}
+
+	err := config.DB.Transaction(func(tx *gorm.DB) error {
+		_, err := transitionKRS(c, tx, &krs, "mahasiswa", "submit", "")
+		return err
+	})
+	if err != nil {
+		respondKRSTransitionError(c, err, "Failed to submit KRS")
+		return
+	}
+
+	utils.SuccessResponse(c, gin.H{
+		"message": "KRS submitted to dosen wali",
+		"krs":     krsApprovalSummary(krs),
+	})
+}
+
+// SubmitKRSPackage - Mahasiswa mengajukan seluruh KRS draft/revisi satu semester sebagai satu paket
+func (kc *KRSController) SubmitKRSPackage(c *gin.Context) {
+	mahasiswaID := middleware.GetPrincipal(c).MahasiswaID
+
+	var req models.KRSPackageRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid JSON format")
+		return
+	}
+
+	if err := utils.ValidateStruct(req); err != nil {
+		utils.HandleValidationError(c, err)
+		return
+	}
+
+	if !requireKRSWindow(c, mahasiswaID, req.Semester, req.TahunAjaran, models.WindowKRS, models.WindowAddDrop) {
+		return
+	}
+
+	var entries []models.KRS
+	err := config.DB.Transaction(func(tx *gorm.DB) error {
+		var err error
+		if entries, err = loadKRSPackage(tx, mahasiswaID, req.Semester, req.TahunAjaran); err != nil {
+			return err
+		}
+		_, err = transitionKRSPackage(c, tx, entries, "mahasiswa", "submit", "", nil)
+		return err
+	})
+	if err != nil {
+		respondKRSTransitionError(c, err, "Failed to submit KRS")
+		return
+	}
+
+	responses := make([]models.KRSResponse, 0, len(entries))
+	for _, entry := range entries {
+		responses = append(responses, krsResponse(entry))
+	}
+
+	utils.SuccessResponse(c, gin.H{
+		"message": "KRS package submitted to dosen wali",
+		"krs":     responses,
+	})
+}
+
+// GetKRSHistory - Riwayat transisi persetujuan satu KRS milik mahasiswa
+func (kc *KRSController) GetKRSHistory(c *gin.Context) {
+	mahasiswaID := middleware.GetPrincipal(c).MahasiswaID
+
+	var krs models.KRS
+	if err := config.DB.Where("id = ? AND mahasiswa_id = ?", c.Param("id"), mahasiswaID).First(&krs).Error; err != nil {
+		utils.ErrorResponse(c, http.StatusNotFound, "KRS entry not found")
+		return
+	}
+
+	var history []models.KRSApprovalHistory
+	if err := config.DB.Where("krs_id = ?", krs.ID).Order("created_at ASC, id ASC").Find(&history).Error; err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to fetch KRS history")
+		return
+	}
+
+	utils.SuccessResponse(c, gin.H{
+		"krs":     krsApprovalSummary(krs),
+		"history": history,
+	})
+}
+
 func (kc *KRSController) GetAvailableCourses(c *gin.Context) {
-	userID, _ := c.Get("user_id")
+	mahasiswaID := middleware.GetPrincipal(c).MahasiswaID
 	semester, _ := strconv.Atoi(c.DefaultQuery("semester", "1"))
 	tahunAjaran := c.DefaultQuery("tahun_ajaran", "")
 
@@ -143,14 +429,214 @@ func (kc *KRSController) GetAvailableCourses(c *gin.Context) {
 		return
 	}
 
-	var availableCourses []models.Course
+	// Mata kuliah yang sudah ada di KRS semester ini diambil dalam satu query
+	var inKRS []uint
+	if err := config.DB.Model(&models.KRS{}).
+		Where("mahasiswa_id = ? AND semester = ? AND tahun_ajaran = ?", mahasiswaID, semester, tahunAjaran).
+		Pluck("course_id", &inKRS).Error; err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to fetch KRS")
+		return
+	}
+	taken := map[uint]bool{}
+	for _, id := range inKRS {
+		taken[id] = true
+	}
+
+	var offered []models.Course
 	for _, course := range courses {
-		var existingKRS models.KRS
-		if err := config.DB.Where("mahasiswa_id = ? AND course_id = ? AND semester = ? AND tahun_ajaran = ?",
-			userID, course.ID, semester, tahunAjaran).First(&existingKRS).Error; err != nil {
-			availableCourses = append(availableCourses, course)
+		if !taken[course.ID] {
+			offered = append(offered, course)
 		}
 	}
 
+	courseIDs := make([]uint, 0, len(offered))
+	for _, course := range offered {
+		courseIDs = append(courseIDs, course.ID)
+	}
+	unmet, err := evaluatePrerequisites(config.DB, mahasiswaID, courseIDs)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to check prerequisites")
+		return
+	}
+
+	// Bentrok jadwal hanya peringatan; mata kuliah tetap ditampilkan
+	clashTahunAjaran := tahunAjaran
+	if clashTahunAjaran == "" {
+		clashTahunAjaran = getCurrentAcademicYear()
+	}
+
+	var candidates []models.Jadwal
+	if err := config.DB.Where("tahun_ajaran = ? AND course_id IN ?", clashTahunAjaran, courseIDs).Find(&candidates).Error; err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to fetch jadwal")
+		return
+	}
+	clashes, err := findScheduleClashes(config.DB, mahasiswaID, clashTahunAjaran, candidates)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to check schedule clashes")
+		return
+	}
+
+	courseClashes := map[uint][]models.ScheduleClash{}
+	kelasClashes := map[uint][]models.ScheduleClash{}
+	for _, clash := range clashes {
+		if clash.KelasID != nil {
+			kelasClashes[*clash.KelasID] = append(kelasClashes[*clash.KelasID], clash)
+		} else {
+			courseClashes[clash.CourseID] = append(courseClashes[clash.CourseID], clash)
+		}
+	}
+
+	// Kelas paralel beserta sisa kursi dan panjang antrian
+	kelasByCourse, err := kelasOptions(clashTahunAjaran, courseIDs, candidates, kelasClashes)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to fetch kelas")
+		return
+	}
+
+	availableCourses := make([]models.AvailableCourseResponse, 0, len(offered))
+	for _, course := range offered {
+		availableCourses = append(availableCourses, models.AvailableCourseResponse{
+			Course:             course,
+			Eligible:           len(unmet[course.ID]) == 0,
+			UnmetPrerequisites: unmet[course.ID],
+			ScheduleClashes:    courseClashes[course.ID],
+			Kelas:              kelasByCourse[course.ID],
+		})
+	}
+
 	utils.SuccessResponse(c, availableCourses)
 }
+
+// GetRecommendations - Saran mata kuliah KRS berurutan skor: kurikulum, wajib tertinggal, mengulang,
+// prasyarat, kecocokan jadwal dan sisa SKS, masing-masing dengan alasannya
+func (kc *KRSController) GetRecommendations(c *gin.Context) {
+	mahasiswaID := middleware.GetPrincipal(c).MahasiswaID
+
+	var mahasiswa models.Mahasiswa
+	if err := config.DB.Where("id = ?", mahasiswaID).First(&mahasiswa).Error; err != nil {
+		utils.ErrorResponse(c, http.StatusNotFound, "Mahasiswa not found")
+		return
+	}
+
+	semester, ok := semesterQuery(c, mahasiswa.Semester)
+	if !ok {
+		return
+	}
+	tahunAjaran := c.DefaultQuery("tahun_ajaran", getCurrentAcademicYear())
+
+	recommendations, err := recommendCourses(mahasiswa, semester, tahunAjaran)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to build course recommendations")
+		return
+	}
+
+	utils.SuccessResponse(c, recommendations)
+}
+
+// krsStatusLabels - label status persetujuan pada KRS cetak
+var krsStatusLabels = map[string]string{
+	models.KRSDraft:             "Draft",
+	models.KRSSubmitted:         "Diajukan",
+	models.KRSApprovedWali:      "Disetujui Wali",
+	models.KRSValidatedKajur:    "Disahkan",
+	models.KRSRevisionRequested: "Perlu Revisi",
+}
+
+// PrintKRS - KRS satu semester dalam PDF untuk dicetak dan ditandatangani
+func (kc *KRSController) PrintKRS(c *gin.Context) {
+	mahasiswaID := middleware.GetPrincipal(c).MahasiswaID
+
+	var mahasiswa models.Mahasiswa
+	if err := config.DB.Preload("DosenWali").Where("id = ?", mahasiswaID).First(&mahasiswa).Error; err != nil {
+		utils.ErrorResponse(c, http.StatusNotFound, "Mahasiswa not found")
+		return
+	}
+
+	semester, ok := semesterQuery(c, mahasiswa.Semester)
+	if !ok {
+		return
+	}
+	tahunAjaran := c.DefaultQuery("tahun_ajaran", getCurrentAcademicYear())
+
+	var entries []models.KRS
+	if err := config.DB.Preload("Course").Preload("Kelas").Preload("WaliApprover").Preload("Validator").
+		Where("mahasiswa_id = ? AND semester = ? AND tahun_ajaran = ? AND approval_status <> ?",
+			mahasiswaID, semester, tahunAjaran, models.KRSRejected).
+		Order("id ASC").Find(&entries).Error; err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to fetch KRS")
+		return
+	}
+	if len(entries) == 0 {
+		utils.ErrorResponse(c, http.StatusNotFound, "Belum ada KRS untuk semester ini")
+		return
+	}
+
+	quota, err := sksQuota(config.DB, mahasiswa, semester, tahunAjaran)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to calculate SKS")
+		return
+	}
+	ipk, hasIPK, err := cumulativeIPK(config.DB, mahasiswa.ID, semester-1)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to calculate IPK")
+		return
+	}
+
+	// Penyetuju: dosen wali/kajur yang memproses KRS, atau pejabat saat ini jika belum diproses
+	wali := printSignature{role: "Dosen Wali"}
+	if mahasiswa.DosenWali != nil {
+		wali.name, wali.id = mahasiswa.DosenWali.Nama, "NIDN "+mahasiswa.DosenWali.NIDN
+	}
+	kajur := printSignature{role: "Ketua Jurusan"}
+	if k := activeKajur(mahasiswa.Jurusan); k != nil {
+		kajur.name, kajur.id = k.Nama, "NIDN "+k.NIDN
+	}
+
+	rows := make([][]string, 0, len(entries))
+	totalSKS := 0
+	for i, entry := range entries {
+		if entry.WaliApprover != nil {
+			wali.name, wali.id = entry.WaliApprover.Nama, "NIDN "+entry.WaliApprover.NIDN
+		}
+		if entry.Validator != nil {
+			kajur.name, kajur.id = entry.Validator.Nama, "NIDN "+entry.Validator.NIDN
+		}
+		kelas := "-"
+		if entry.Kelas != nil {
+			kelas = entry.Kelas.Nama
+		}
+		totalSKS += entry.Course.Credits
+		rows = append(rows, []string{
+			strconv.Itoa(i + 1), entry.Course.Code, entry.Course.Name, kelas,
+			strconv.Itoa(entry.Course.Credits), krsStatusLabels[entry.ApprovalStatus],
+		})
+	}
+	rows = append(rows, []string{"", "", "Jumlah SKS", "", strconv.Itoa(totalSKS), ""})
+
+	doc := newPrintDocument("KRS " + mahasiswa.NIM)
+	doc.heading("KARTU RENCANA STUDI (KRS)", "Semester "+strconv.Itoa(semester)+" - Tahun Ajaran "+tahunAjaran)
+	doc.identity(
+		[][2]string{{"NIM", mahasiswa.NIM}, {"Nama", mahasiswa.Nama}, {"Jurusan", mahasiswa.Jurusan}},
+		[][2]string{{"Semester", strconv.Itoa(semester)}, {"Dosen Wali", wali.name}, {"IPK", formatIndeks(ipk, hasIPK)}},
+	)
+	doc.table([]printColumn{
+		{"No", 28, 'C'}, {"Kode", 62, 'L'}, {"Mata Kuliah", 205, 'L'},
+		{"Kelas", 45, 'C'}, {"SKS", 35, 'C'}, {"Status", 120, 'L'},
+	}, rows)
+
+	ips := "-"
+	if quota.IPSSebelumnya != nil {
+		ips = fmt.Sprintf("%.2f", *quota.IPSSebelumnya)
+	}
+	doc.summary([][2]string{
+		{"Total SKS", strconv.Itoa(totalSKS)},
+		{"Batas SKS", strconv.Itoa(quota.MaxSKS)},
+		{"IPS semester lalu", ips},
+	})
+	doc.signatures([]printSignature{
+		{role: "Mahasiswa", name: mahasiswa.Nama, id: "NIM " + mahasiswa.NIM},
+		wali,
+		kajur,
+	}, time.Now())
+	doc.send(c, fmt.Sprintf("KRS_%s_semester_%d.pdf", mahasiswa.NIM, semester))
+}
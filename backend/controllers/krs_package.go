@@ -0,0 +1,149 @@
+package controllers
+
+import (
+	"SIAku/config"
+	"SIAku/models"
+	"errors"
+	"fmt"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// Helper paket KRS (seluruh mata kuliah mahasiswa dalam satu semester) untuk pengajuan dan persetujuan sekaligus
+
+var errKRSNotInPackage = errors.New("KRS does not belong to this package")
+
+// loadKRSPackage memuat seluruh KRS mahasiswa pada semester dan tahun ajaran tersebut
+func loadKRSPackage(db *gorm.DB, mahasiswaID uint, semester int, tahunAjaran string) ([]models.KRS, error) {
+	var entries []models.KRS
+	err := db.Preload("Course").Preload("Kelas").
+		Where("mahasiswa_id = ? AND semester = ? AND tahun_ajaran = ?", mahasiswaID, semester, tahunAjaran).
+		Order("id ASC").Find(&entries).Error
+	return entries, err
+}
+
+// transitionKRSPackage menerapkan aksi ke semua KRS paket yang statusnya bisa diproses role tersebut.
+// notes berisi catatan per KRS yang menggantikan catatan paket. Mengembalikan kelas yang kursinya dilepas.
+func transitionKRSPackage(c *gin.Context, tx *gorm.DB, entries []models.KRS, role, action, note string, notes map[uint]string) ([]uint, error) {
+	var released []uint
+	processed := 0
+	for i := range entries {
+		if _, err := models.NextKRSStatus(entries[i].ApprovalStatus, role, action); err != nil {
+			continue
+		}
+
+		entryNote := note
+		if n, ok := notes[entries[i].ID]; ok {
+			entryNote = n
+		}
+		if action != "approve" && action != "submit" && entryNote == "" {
+			return nil, fmt.Errorf("%w: note is required for %s (krs %d)", errIllegalKRSTransition, action, entries[i].ID)
+		}
+
+		seatReleased, err := transitionKRS(c, tx, &entries[i], role, action, entryNote)
+		if err != nil {
+			return nil, err
+		}
+		if seatReleased {
+			released = append(released, *entries[i].KelasID)
+		}
+		processed++
+	}
+
+	if processed == 0 {
+		return nil, fmt.Errorf("%w: no KRS in this package can be processed by %s", errIllegalKRSTransition, role)
+	}
+	return released, nil
+}
+
+// packageScheduleClashes mencari pasangan mata kuliah dalam paket yang jadwalnya bentrok (setiap pasangan sekali)
+func packageScheduleClashes(db *gorm.DB, mahasiswaID uint, tahunAjaran string) ([]models.ScheduleClash, error) {
+	jadwal, err := takenJadwal(db, mahasiswaID, tahunAjaran)
+	if err != nil {
+		return nil, err
+	}
+
+	var clashes []models.ScheduleClash
+	for _, clash := range clashesBetween(jadwal, jadwal) {
+		if clash.CourseID < clash.ClashCourseID {
+			clashes = append(clashes, clash)
+		}
+	}
+	return clashes, nil
+}
+
+// krsResponse mengubah KRS (dengan Course dan Kelas ter-preload) menjadi response
+func krsResponse(k models.KRS) models.KRSResponse {
+	response := models.KRSResponse{
+		ID:              k.ID,
+		CourseID:        k.CourseID,
+		CourseName:      k.Course.Name,
+		CourseCode:      k.Course.Code,
+		KelasID:         k.KelasID,
+		Credits:         k.Course.Credits,
+		Semester:        k.Semester,
+		TahunAjaran:     k.TahunAjaran,
+		Status:          k.Status,
+		ApprovalStatus:  k.ApprovalStatus,
+		SubmittedAt:     k.SubmittedAt,
+		WaliApprovedBy:  k.WaliApprovedBy,
+		WaliApprovedAt:  k.WaliApprovedAt,
+		ValidatedBy:     k.ValidatedBy,
+		ValidatedAt:     k.ValidatedAt,
+		RejectionReason: k.RejectionReason,
+		RevisionNote:    k.RevisionNote,
+		CreatedAt:       k.CreatedAt,
+	}
+	if k.Kelas != nil {
+		response.KelasNama = k.Kelas.Nama
+	}
+	return response
+}
+
+// promoteReleasedKelas mempromosikan antrian kelas yang kursinya dilepas setelah transaksi selesai
+func promoteReleasedKelas(kelasIDs []uint) {
+	seen := map[uint]bool{}
+	for _, id := range kelasIDs {
+		if !seen[id] {
+			seen[id] = true
+			promoteWaitlist(id)
+		}
+	}
+}
+
+// krsPackageReview menyusun ringkasan paket: kuota SKS, IPS sebelumnya, status dan bentrok jadwal
+func krsPackageReview(mahasiswa models.Mahasiswa, semester int, tahunAjaran string) (models.KRSPackageReview, error) {
+	review := models.KRSPackageReview{
+		Mahasiswa: models.MahasiswaResponse{
+			ID:        mahasiswa.ID,
+			NIM:       mahasiswa.NIM,
+			Nama:      mahasiswa.Nama,
+			Jurusan:   mahasiswa.Jurusan,
+			CreatedAt: mahasiswa.CreatedAt,
+			UpdatedAt: mahasiswa.UpdatedAt,
+		},
+		IPK:         mahasiswa.IPK,
+		Semester:    semester,
+		TahunAjaran: tahunAjaran,
+		Status:      map[string]int{},
+	}
+
+	entries, err := loadKRSPackage(config.DB, mahasiswa.ID, semester, tahunAjaran)
+	if err != nil {
+		return review, err
+	}
+	review.Items = make([]models.KRSResponse, 0, len(entries))
+	for _, entry := range entries {
+		review.Items = append(review.Items, krsResponse(entry))
+		review.Status[entry.ApprovalStatus]++
+	}
+
+	if review.SKS, err = sksQuota(config.DB, mahasiswa, semester, tahunAjaran); err != nil {
+		return review, err
+	}
+	if review.ScheduleClashes, err = packageScheduleClashes(config.DB, mahasiswa.ID, tahunAjaran); err != nil {
+		return review, err
+	}
+	return review, nil
+}
@@ -0,0 +1,110 @@
+package controllers
+
+import (
+	"SIAku/config"
+	"SIAku/models"
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Helper proteksi brute-force untuk AuthController.Login
+
+// loginIPBlocked mengecek apakah IP sudah melewati batas login gagal dalam window
+func loginIPBlocked(ip string) bool {
+	var count int64
+	config.DB.Model(&models.LoginAttempt{}).
+		Where("ip_address = ? AND success = false AND created_at > ?", ip, time.Now().Add(-config.AppConfig.LoginAttemptWindow)).
+		Count(&count)
+	return int(count) >= config.AppConfig.LoginIPMaxAttempts
+}
+
+// loginIdentifierBlocked mengecek login gagal per identifier (username/email), terdaftar maupun tidak
+func loginIdentifierBlocked(identifier string) bool {
+	var count int64
+	config.DB.Model(&models.LoginAttempt{}).
+		Where("identifier = ? AND success = false AND created_at > ?", identifier, time.Now().Add(-config.AppConfig.LoginAttemptWindow)).
+		Count(&count)
+	return int(count) >= config.AppConfig.LoginMaxAttempts
+}
+
+func recordLoginAttempt(identifier, ip string, userID *uint, success bool) {
+	config.DB.Create(&models.LoginAttempt{
+		Identifier: identifier,
+		IPAddress:  ip,
+		UserID:     userID,
+		Success:    success,
+	})
+}
+
+// lockoutDuration - exponential backoff: base, 2x base, 4x base, ... dibatasi LoginLockoutMax
+func lockoutDuration(lockoutCount int) time.Duration {
+	duration := config.AppConfig.LoginLockoutBase
+	for i := 1; i < lockoutCount; i++ {
+		duration *= 2
+		if duration >= config.AppConfig.LoginLockoutMax {
+			return config.AppConfig.LoginLockoutMax
+		}
+	}
+	return duration
+}
+
+// registerFailedLogin menambah counter gagal di tabel users dan mengunci akun jika melewati batas.
+// Mengembalikan waktu akhir lockout jika akun baru saja dikunci.
+func registerFailedLogin(user *models.Users, ip string) *time.Time {
+	var lockedUntil *time.Time
+
+	config.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&models.Users{}).Where("id = ?", user.ID).
+			UpdateColumn("failed_login_count", gorm.Expr("failed_login_count + 1")).Error; err != nil {
+			return err
+		}
+		if err := tx.Select("failed_login_count", "lockout_count").Where("id = ?", user.ID).First(user).Error; err != nil {
+			return err
+		}
+
+		if user.FailedLoginCount < config.AppConfig.LoginMaxAttempts {
+			return nil
+		}
+
+		failedAttempts := user.FailedLoginCount
+		until := time.Now().Add(lockoutDuration(user.LockoutCount + 1))
+		if err := tx.Model(&models.Users{}).Where("id = ?", user.ID).Updates(map[string]interface{}{
+			"failed_login_count": 0,
+			"lockout_count":      gorm.Expr("lockout_count + 1"),
+			"locked_until":       until,
+		}).Error; err != nil {
+			return err
+		}
+
+		event := models.AccountLockoutEvent{
+			UserID:         user.ID,
+			Event:          "locked",
+			Reason:         fmt.Sprintf("%d percobaan login gagal berturut-turut", failedAttempts),
+			IPAddress:      ip,
+			FailedAttempts: failedAttempts,
+			LockedUntil:    &until,
+		}
+		if err := tx.Create(&event).Error; err != nil {
+			return err
+		}
+
+		lockedUntil = &until
+		return nil
+	})
+
+	return lockedUntil
+}
+
+// resetFailedLogins membersihkan counter gagal setelah login berhasil
+func resetFailedLogins(user models.Users) {
+	if user.FailedLoginCount == 0 && user.LockoutCount == 0 && user.LockedUntil == nil {
+		return
+	}
+	config.DB.Model(&models.Users{}).Where("id = ?", user.ID).Updates(map[string]interface{}{
+		"failed_login_count": 0,
+		"lockout_count":      0,
+		"locked_until":       nil,
+	})
+}
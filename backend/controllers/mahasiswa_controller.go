@@ -2,6 +2,7 @@ package controllers
 
 import (
 	"SIAku/config"
+	"SIAku/middleware"
 	"SIAku/models"
 	"SIAku/utils"
 	"net/http"
@@ -201,9 +202,9 @@ func (mc *MahasiswaController) UnbindPhoneNumber(c *gin.Context) {
 
 func (mc *MahasiswaController) UpdateMahasiswa(c *gin.Context) {
 	id := c.Param("id")
-	userID, _ := c.Get("user_id")
+	mahasiswaID := middleware.GetPrincipal(c).MahasiswaID
 
-	if strconv.Itoa(int(userID.(uint))) != id {
+	if strconv.Itoa(int(mahasiswaID)) != id {
 		utils.ErrorResponse(c, http.StatusForbidden, "You can only update your own data")
 		return
 	}
@@ -256,9 +257,9 @@ func (mc *MahasiswaController) UpdateMahasiswa(c *gin.Context) {
 
 func (mc *MahasiswaController) DeleteMahasiswa(c *gin.Context) {
 	id := c.Param("id")
-	userID, _ := c.Get("user_id")
+	mahasiswaID := middleware.GetPrincipal(c).MahasiswaID
 
-	if strconv.Itoa(int(userID.(uint))) != id {
+	if strconv.Itoa(int(mahasiswaID)) != id {
 		utils.ErrorResponse(c, http.StatusForbidden, "You can only delete your own data")
 		return
 	}
@@ -2,6 +2,7 @@ package controllers
 
 import (
 	"SIAku/config"
+	"SIAku/middleware"
 	"SIAku/models"
 	"SIAku/utils"
 	"net/http"
@@ -17,7 +18,7 @@ func NewMateriController() *MateriController {
 
 // Upload/Create Materi Kuliah
 func (mc *MateriController) CreateMateri(c *gin.Context) {
-	dosenID, _ := c.Get("user_id")
+	dosenID := middleware.GetPrincipal(c).DosenID
 	var req models.MateriRequest
 
 	if err := c.ShouldBindJSON(&req); err != nil {
@@ -32,7 +33,7 @@ func (mc *MateriController) CreateMateri(c *gin.Context) {
 
 	// Verifikasi dosen mengajar mata kuliah ini
 	var course models.Course
-	if err := config.DB.Where("id = ? AND dosen_id = ?", req.CourseID, dosenID).First(&course).Error; err != nil {
+	if err := config.DB.Scopes(taughtBy(dosenID)).Where("courses.id = ?", req.CourseID).First(&course).Error; err != nil {
 		utils.ErrorResponse(c, http.StatusForbidden, "You are not authorized to upload material for this course")
 		return
 	}
@@ -78,13 +79,13 @@ func (mc *MateriController) CreateMateri(c *gin.Context) {
 
 // Get Materi by Course
 func (mc *MateriController) GetMateriByCourse(c *gin.Context) {
-	dosenID, _ := c.Get("user_id")
+	dosenID := middleware.GetPrincipal(c).DosenID
 	courseID := c.Param("courseId")
 	pertemuan := c.Query("pertemuan")
 
 	// Verifikasi dosen mengajar mata kuliah ini
 	var course models.Course
-	if err := config.DB.Where("id = ? AND dosen_id = ?", courseID, dosenID).First(&course).Error; err != nil {
+	if err := config.DB.Scopes(taughtBy(dosenID)).Where("courses.id = ?", courseID).First(&course).Error; err != nil {
 		utils.ErrorResponse(c, http.StatusForbidden, "You are not authorized to view materials for this course")
 		return
 	}
@@ -134,7 +135,7 @@ func (mc *MateriController) GetMateriByCourse(c *gin.Context) {
 
 // Update Materi
 func (mc *MateriController) UpdateMateri(c *gin.Context) {
-	dosenID, _ := c.Get("user_id")
+	dosenID := middleware.GetPrincipal(c).DosenID
 	materiID := c.Param("materiId")
 
 	var req models.MateriRequest
@@ -156,7 +157,7 @@ func (mc *MateriController) UpdateMateri(c *gin.Context) {
 	}
 
 	// Verifikasi dosen mengajar mata kuliah ini
-	if materi.Course.DosenID == nil || *materi.Course.DosenID != dosenID.(uint) {
+	if materi.Course.DosenID == nil || *materi.Course.DosenID != dosenID {
 		utils.ErrorResponse(c, http.StatusForbidden, "You are not authorized to update this material")
 		return
 	}
@@ -195,7 +196,7 @@ func (mc *MateriController) UpdateMateri(c *gin.Context) {
 
 // Delete Materi (soft delete - set status to inactive)
 func (mc *MateriController) DeleteMateri(c *gin.Context) {
-	dosenID, _ := c.Get("user_id")
+	dosenID := middleware.GetPrincipal(c).DosenID
 	materiID := c.Param("materiId")
 
 	// Ambil materi dan verifikasi kepemilikan
@@ -206,7 +207,7 @@ func (mc *MateriController) DeleteMateri(c *gin.Context) {
 	}
 
 	// Verifikasi dosen mengajar mata kuliah ini
-	if materi.Course.DosenID == nil || *materi.Course.DosenID != dosenID.(uint) {
+	if materi.Course.DosenID == nil || *materi.Course.DosenID != dosenID {
 		utils.ErrorResponse(c, http.StatusForbidden, "You are not authorized to delete this material")
 		return
 	}
@@ -226,10 +227,10 @@ func (mc *MateriController) DeleteMateri(c *gin.Context) {
 
 // Get All My Courses (untuk dosen)
 func (mc *MateriController) GetMyCourses(c *gin.Context) {
-	dosenID, _ := c.Get("user_id")
+	dosenID := middleware.GetPrincipal(c).DosenID
 
 	var courses []models.Course
-	if err := config.DB.Where("dosen_id = ?", dosenID).Find(&courses).Error; err != nil {
+	if err := config.DB.Scopes(taughtBy(dosenID)).Find(&courses).Error; err != nil {
 		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to fetch courses")
 		return
 	}
@@ -238,7 +239,7 @@ func (mc *MateriController) GetMyCourses(c *gin.Context) {
 	for _, course := range courses {
 		// Hitung jumlah mahasiswa terdaftar
 		var studentCount int64
-		config.DB.Model(&models.KRS{}).Where("course_id = ? AND approval_status = 'approved'", course.ID).Count(&studentCount)
+		config.DB.Model(&models.KRS{}).Where("course_id = ? AND approval_status = ?", course.ID, models.KRSValidatedKajur).Count(&studentCount)
 
 		// Hitung jumlah materi
 		var materialCount int64
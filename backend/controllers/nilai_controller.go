@@ -2,9 +2,13 @@ package controllers
 
 import (
 	"SIAku/config"
+	"SIAku/middleware"
 	"SIAku/models"
 	"SIAku/utils"
+	"fmt"
 	"net/http"
+	"strconv"
+	"time"
 
 	"github.com/gin-gonic/gin"
 )
@@ -16,12 +20,12 @@ func NewNilaiController() *NilaiController {
 }
 
 func (nc *NilaiController) GetMyNilai(c *gin.Context) {
-	userID, _ := c.Get("user_id")
+	mahasiswaID := middleware.GetPrincipal(c).MahasiswaID
 	semester := c.DefaultQuery("semester", "")
 	tahunAjaran := c.DefaultQuery("tahun_ajaran", "")
 
 	var nilai []models.Nilai
-	query := config.DB.Preload("Course").Where("mahasiswa_id = ?", userID)
+	query := config.DB.Preload("Course").Preload("Komponen", orderKomponen).Scopes(publishedNilai).Where("mahasiswa_id = ?", mahasiswaID)
 	
 	if semester != "" {
 		query = query.Where("semester = ?", semester)
@@ -38,20 +42,23 @@ func (nc *NilaiController) GetMyNilai(c *gin.Context) {
 	var responses []models.NilaiResponse
 	for _, n := range nilai {
 		responses = append(responses, models.NilaiResponse{
-			ID:          n.ID,
-			CourseName:  n.Course.Name,
-			CourseCode:  n.Course.Code,
-			Credits:     n.Course.Credits,
-			Semester:    n.Semester,
-			TahunAjaran: n.TahunAjaran,
-			NilaiTugas:  n.NilaiTugas,
-			NilaiUTS:    n.NilaiUTS,
-			NilaiUAS:    n.NilaiUAS,
-			NilaiAkhir:  n.NilaiAkhir,
-			GradeHuruf:  n.GradeHuruf,
-			GradePoint:  n.GradePoint,
-			Status:      n.Status,
-			CreatedAt:   n.CreatedAt,
+			ID:           n.ID,
+			CourseName:   n.Course.Name,
+			CourseCode:   n.Course.Code,
+			Credits:      n.Course.Credits,
+			Semester:     n.Semester,
+			TahunAjaran:  n.TahunAjaran,
+			NilaiTugas:   n.NilaiTugas,
+			NilaiUTS:     n.NilaiUTS,
+			NilaiUAS:     n.NilaiUAS,
+			NilaiAkhir:   n.NilaiAkhir,
+			GradeHuruf:   n.GradeHuruf,
+			GradePoint:   n.GradePoint,
+			Status:       n.Status,
+			Attempt:      n.Attempt,
+			GradeScaleID: n.GradeScaleID,
+			Komponen:     nilaiKomponenResponse(n),
+			CreatedAt:    n.CreatedAt,
 		})
 	}
 
@@ -59,16 +66,17 @@ func (nc *NilaiController) GetMyNilai(c *gin.Context) {
 }
 
 func (nc *NilaiController) GetTranskrip(c *gin.Context) {
-	userID, _ := c.Get("user_id")
+	mahasiswaID := middleware.GetPrincipal(c).MahasiswaID
 
 	var mahasiswa models.Mahasiswa
-	if err := config.DB.Where("id = ?", userID).First(&mahasiswa).Error; err != nil {
+	if err := config.DB.Where("id = ?", mahasiswaID).First(&mahasiswa).Error; err != nil {
 		utils.ErrorResponse(c, http.StatusNotFound, "Mahasiswa not found")
 		return
 	}
 
 	var nilaiList []models.Nilai
-	if err := config.DB.Preload("Course").Where("mahasiswa_id = ?", userID).Find(&nilaiList).Error; err != nil {
+	if err := config.DB.Preload("Course").Preload("Komponen", orderKomponen).Scopes(publishedNilai).Where("mahasiswa_id = ?", mahasiswaID).
+		Order("semester ASC, attempt ASC, id ASC").Find(&nilaiList).Error; err != nil {
 		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to fetch transkrip")
 		return
 	}
@@ -82,42 +90,47 @@ func (nc *NilaiController) GetTranskrip(c *gin.Context) {
 		UpdatedAt: mahasiswa.UpdatedAt,
 	}
 
+	// Percobaan yang digantikan (mengulang) tetap ditampilkan tetapi tidak dihitung di SKS dan IPK
+	policy := retakePolicy()
+	counted := countedAttempts(nilaiList, policy)
+
 	var riwayatNilai []models.NilaiResponse
 	totalSKS := 0
 	totalSKSLulus := 0
-	totalPoin := 0.0
 
 	for _, nilai := range nilaiList {
-		totalSKS += nilai.Course.Credits
-		if nilai.GradeHuruf != "E" {
-			totalSKSLulus += nilai.Course.Credits
+		if counted[nilai.ID] {
+			totalSKS += nilai.Course.Credits
+			if nilai.GradePoint > 0 {
+				totalSKSLulus += nilai.Course.Credits
+			}
 		}
-		totalPoin += nilai.GradePoint * float64(nilai.Course.Credits)
-		
+
 		nilaiResp := models.NilaiResponse{
-			ID:          nilai.ID,
-			CourseName:  nilai.Course.Name,
-			CourseCode:  nilai.Course.Code,
-			Credits:     nilai.Course.Credits,
-			Semester:    nilai.Semester,
-			TahunAjaran: nilai.TahunAjaran,
-			NilaiTugas:  nilai.NilaiTugas,
-			NilaiUTS:    nilai.NilaiUTS,
-			NilaiUAS:    nilai.NilaiUAS,
-			NilaiAkhir:  nilai.NilaiAkhir,
-			GradeHuruf:  nilai.GradeHuruf,
-			GradePoint:  nilai.GradePoint,
-			Status:      nilai.Status,
-			CreatedAt:   nilai.CreatedAt,
+			ID:           nilai.ID,
+			CourseName:   nilai.Course.Name,
+			CourseCode:   nilai.Course.Code,
+			Credits:      nilai.Course.Credits,
+			Semester:     nilai.Semester,
+			TahunAjaran:  nilai.TahunAjaran,
+			NilaiTugas:   nilai.NilaiTugas,
+			NilaiUTS:     nilai.NilaiUTS,
+			NilaiUAS:     nilai.NilaiUAS,
+			NilaiAkhir:   nilai.NilaiAkhir,
+			GradeHuruf:   nilai.GradeHuruf,
+			GradePoint:   nilai.GradePoint,
+			Status:       nilai.Status,
+			Attempt:      nilai.Attempt,
+			Superseded:   isSuperseded(nilai, counted),
+			GradeScaleID: nilai.GradeScaleID,
+			Komponen:     nilaiKomponenResponse(nilai),
+			CreatedAt:    nilai.CreatedAt,
 		}
-		
+
 		riwayatNilai = append(riwayatNilai, nilaiResp)
 	}
 
-	ipk := 0.0
-	if totalSKS > 0 {
-		ipk = totalPoin / float64(totalSKS)
-	}
+	ipk, _ := gradeIndex(nilaiList, counted)
 
 	statusKelulusan := "Aktif"
 	if mahasiswa.StatusAkademik == "lulus" {
@@ -128,6 +141,7 @@ func (nc *NilaiController) GetTranskrip(c *gin.Context) {
 
 	transkrip := models.TranskripResponse{
 		Mahasiswa:       mahasiswaResponse,
+		RetakePolicy:    policy,
 		TotalSKS:        totalSKS,
 		TotalSKSLulus:   totalSKSLulus,
 		IPKKumulatif:    ipk,
@@ -139,16 +153,20 @@ func (nc *NilaiController) GetTranskrip(c *gin.Context) {
 }
 
 func (nc *NilaiController) GetStatistikNilai(c *gin.Context) {
-	userID, _ := c.Get("user_id")
+	mahasiswaID := middleware.GetPrincipal(c).MahasiswaID
 
 	var nilaiList []models.Nilai
-	if err := config.DB.Preload("Course").Where("mahasiswa_id = ?", userID).Find(&nilaiList).Error; err != nil {
+	if err := config.DB.Preload("Course").Scopes(publishedNilai).Where("mahasiswa_id = ?", mahasiswaID).Find(&nilaiList).Error; err != nil {
 		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to fetch statistik nilai")
 		return
 	}
 
+	// Statistik hanya memakai percobaan yang dihitung menurut kebijakan mengulang
+	policy := retakePolicy()
+	counted := countedAttempts(nilaiList, policy)
+
 	statistik := map[string]interface{}{
-		"total_mata_kuliah": len(nilaiList),
+		"total_mata_kuliah": len(counted),
 		"grade_distribution": map[string]int{
 			"A":  0,
 			"AB": 0,
@@ -170,19 +188,123 @@ func (nc *NilaiController) GetStatistikNilai(c *gin.Context) {
 		"A": 0, "AB": 0, "B": 0, "BC": 0, "C": 0, "D": 0, "E": 0,
 	}
 
+	superseded := 0
 	for _, nilai := range nilaiList {
+		if !counted[nilai.ID] {
+			if isSuperseded(nilai, counted) {
+				superseded++
+			}
+			continue
+		}
 		totalNilai += nilai.NilaiAkhir
 		totalPoin += nilai.GradePoint * float64(nilai.Course.Credits)
 		totalSKS += nilai.Course.Credits
 		gradeCount[nilai.GradeHuruf]++
 	}
 
-	if len(nilaiList) > 0 {
-		statistik["rata_rata_nilai"] = totalNilai / float64(len(nilaiList))
+	if len(counted) > 0 {
+		statistik["rata_rata_nilai"] = totalNilai / float64(len(counted))
 	}
+	statistik["retake_policy"] = policy
+	statistik["percobaan_digantikan"] = superseded
 	statistik["total_poin"] = totalPoin
 	statistik["total_sks"] = totalSKS
 	statistik["grade_distribution"] = gradeCount
 
 	utils.SuccessResponse(c, statistik)
-}
\ No newline at end of file
+}
+
+// PrintKHS - Kartu Hasil Studi satu semester dalam PDF beserta IPS, IPK dan tanda tangan pejabat
+func (nc *NilaiController) PrintKHS(c *gin.Context) {
+	mahasiswaID := middleware.GetPrincipal(c).MahasiswaID
+
+	var mahasiswa models.Mahasiswa
+	if err := config.DB.Preload("DosenWali").Where("id = ?", mahasiswaID).First(&mahasiswa).Error; err != nil {
+		utils.ErrorResponse(c, http.StatusNotFound, "Mahasiswa not found")
+		return
+	}
+
+	semester, ok := semesterQuery(c, mahasiswa.Semester)
+	if !ok {
+		return
+	}
+
+	query := config.DB.Preload("Course").Scopes(publishedNilai).Where("mahasiswa_id = ? AND semester = ?", mahasiswaID, semester)
+	if tahunAjaran := c.Query("tahun_ajaran"); tahunAjaran != "" {
+		query = query.Where("tahun_ajaran = ?", tahunAjaran)
+	}
+	var nilaiList []models.Nilai
+	if err := query.Order("id ASC").Find(&nilaiList).Error; err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to fetch nilai")
+		return
+	}
+	if len(nilaiList) == 0 {
+		utils.ErrorResponse(c, http.StatusNotFound, "Belum ada nilai untuk semester ini")
+		return
+	}
+
+	ips, hasIPS, err := semesterIPS(config.DB, mahasiswa.ID, semester)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to calculate IPS")
+		return
+	}
+	ipk, hasIPK, err := cumulativeIPK(config.DB, mahasiswa.ID, semester)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to calculate IPK")
+		return
+	}
+
+	rows := make([][]string, 0, len(nilaiList)+1)
+	totalSKS, sksLulus := 0, 0
+	totalMutu := 0.0
+	for i, nilai := range nilaiList {
+		credits := nilai.Course.Credits
+		totalSKS += credits
+		akhir, huruf, bobot, mutu := "-", "-", "-", "-"
+		if nilai.Status == models.NilaiPublished {
+			if nilai.GradePoint > 0 {
+				sksLulus += credits
+			}
+			totalMutu += nilai.GradePoint * float64(credits)
+			akhir = fmt.Sprintf("%.2f", nilai.NilaiAkhir)
+			huruf = nilai.GradeHuruf
+			bobot = fmt.Sprintf("%.2f", nilai.GradePoint)
+			mutu = fmt.Sprintf("%.2f", nilai.GradePoint*float64(credits))
+		}
+		name := nilai.Course.Name
+		if nilai.Attempt > 1 {
+			name += " (ulang)"
+		}
+		rows = append(rows, []string{
+			strconv.Itoa(i + 1), nilai.Course.Code, name, strconv.Itoa(credits), akhir, huruf, bobot, mutu,
+		})
+	}
+	rows = append(rows, []string{"", "", "Jumlah", strconv.Itoa(totalSKS), "", "", "", fmt.Sprintf("%.2f", totalMutu)})
+
+	wali := printSignature{role: "Dosen Wali"}
+	if mahasiswa.DosenWali != nil {
+		wali.name, wali.id = mahasiswa.DosenWali.Nama, "NIDN "+mahasiswa.DosenWali.NIDN
+	}
+	kajur := printSignature{role: "Ketua Jurusan"}
+	if k := activeKajur(mahasiswa.Jurusan); k != nil {
+		kajur.name, kajur.id = k.Nama, "NIDN "+k.NIDN
+	}
+
+	doc := newPrintDocument("KHS " + mahasiswa.NIM)
+	doc.heading("KARTU HASIL STUDI (KHS)", "Semester "+strconv.Itoa(semester)+" - Tahun Ajaran "+nilaiList[0].TahunAjaran)
+	doc.identity(
+		[][2]string{{"NIM", mahasiswa.NIM}, {"Nama", mahasiswa.Nama}, {"Jurusan", mahasiswa.Jurusan}},
+		[][2]string{{"Semester", strconv.Itoa(semester)}, {"Dosen Wali", wali.name}},
+	)
+	doc.table([]printColumn{
+		{"No", 25, 'C'}, {"Kode", 55, 'L'}, {"Mata Kuliah", 185, 'L'}, {"SKS", 30, 'C'},
+		{"Nilai", 45, 'R'}, {"Huruf", 40, 'C'}, {"Bobot", 45, 'R'}, {"Mutu", 70, 'R'},
+	}, rows)
+	doc.summary([][2]string{
+		{"SKS diambil / lulus", fmt.Sprintf("%d / %d", totalSKS, sksLulus)},
+		{"Indeks Prestasi Semester", formatIndeks(ips, hasIPS)},
+		{"Indeks Prestasi Kumulatif", formatIndeks(ipk, hasIPK)},
+	})
+	doc.signatures([]printSignature{wali, kajur}, time.Now())
+	doc.send(c, fmt.Sprintf("KHS_%s_semester_%d.pdf", mahasiswa.NIM, semester))
+}
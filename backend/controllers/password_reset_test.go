@@ -0,0 +1,152 @@
+package controllers
+
+import (
+	"SIAku/config"
+	"SIAku/models"
+	"SIAku/utils"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"golang.org/x/crypto/bcrypt"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// setupResetDB memakai SQLite sementara sebagai config.DB untuk alur reset password
+func setupResetDB(t *testing.T) {
+	t.Helper()
+	db, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "reset.db")), &gorm.Config{Logger: logger.Discard})
+	if err != nil {
+		t.Fatalf("open sqlite: %v", err)
+	}
+	if err := db.AutoMigrate(&models.Users{}, &models.PasswordReset{}, &models.UserSession{}); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+
+	previousDB, previousConfig := config.DB, config.AppConfig
+	config.DB = db
+	config.AppConfig.PasswordResetTTL = 10 * time.Minute
+	config.AppConfig.PasswordResetTries = 3
+	t.Cleanup(func() {
+		config.DB, config.AppConfig = previousDB, previousConfig
+		utils.SetMailer(nil)
+		if sqlDB, err := db.DB(); err == nil {
+			sqlDB.Close()
+		}
+	})
+}
+
+var resetCodePattern = regexp.MustCompile(`\b\d{6}\b`)
+
+func TestForgotAndResetPassword(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	setupResetDB(t)
+	mailer := &utils.MemoryMailer{}
+	utils.SetMailer(mailer)
+
+	hashed, _ := bcrypt.GenerateFromPassword([]byte("lama123"), bcrypt.MinCost)
+	user := models.Users{Username: "ani", Email: "ani@kampus.ac.id", Password: string(hashed), Role: "mahasiswa", Status: "aktif", FailedLoginCount: 2}
+	if err := config.DB.Create(&user).Error; err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 2; i++ {
+		config.DB.Create(&models.UserSession{
+			UserID: user.ID, RefreshTokenHash: fmt.Sprintf("hash-%d", i), ExpiresAt: time.Now().Add(time.Hour), LastUsedAt: time.Now(),
+		})
+	}
+
+	ac := NewAuthController()
+	r := gin.New()
+	r.POST("/forgot-password", ac.ForgotPassword)
+	r.POST("/reset-password", ac.ResetPassword)
+	post := func(path string, body gin.H) (int, map[string]interface{}) {
+		payload, _ := json.Marshal(body)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodPost, path, bytes.NewReader(payload)))
+		var resp map[string]interface{}
+		json.Unmarshal(w.Body.Bytes(), &resp)
+		return w.Code, resp
+	}
+	requestCode := func() string {
+		t.Helper()
+		sent := len(mailer.Messages())
+		if code, _ := post("/forgot-password", gin.H{"identifier": "ani", "channel": "email"}); code != http.StatusOK {
+			t.Fatalf("forgot-password status %d", code)
+		}
+		messages := mailer.Messages()
+		if len(messages) != sent+1 {
+			t.Fatalf("got %d emails, want %d", len(messages), sent+1)
+		}
+		msg := messages[len(messages)-1]
+		if msg.To != user.Email {
+			t.Errorf("email sent to %q, want %q", msg.To, user.Email)
+		}
+		code := resetCodePattern.FindString(msg.Body)
+		if code == "" {
+			t.Fatalf("no reset code in email body %q", msg.Body)
+		}
+		return code
+	}
+	wrongCode := func(code string) string {
+		n, _ := strconv.Atoi(code)
+		return fmt.Sprintf("%06d", (n+1)%1000000)
+	}
+	reset := func(code, password string) (int, map[string]interface{}) {
+		return post("/reset-password", gin.H{"identifier": "ani", "code": code, "new_password": password})
+	}
+
+	// Akun yang tidak terdaftar mendapat respons yang sama tanpa email
+	if code, _ := post("/forgot-password", gin.H{"identifier": "tidak-ada", "channel": "email"}); code != http.StatusOK || len(mailer.Messages()) != 0 {
+		t.Fatalf("unknown account: status %d, %d emails", code, len(mailer.Messages()))
+	}
+
+	code := requestCode()
+
+	// Kode salah mengurangi sisa percobaan sampai habis, lalu kode yang benar pun ditolak
+	for remaining := 2; remaining >= 0; remaining-- {
+		status, resp := reset(wrongCode(code), "baru123")
+		if status != http.StatusBadRequest || resp["remaining_attempts"] != float64(remaining) {
+			t.Fatalf("wrong code: status %d, resp %v, want remaining_attempts %d", status, resp, remaining)
+		}
+	}
+	if status, resp := reset(code, "baru123"); status != http.StatusBadRequest || resp["error"] != "Kode reset sudah terlalu banyak dicoba, silakan minta kode baru" {
+		t.Fatalf("locked code: status %d, resp %v", status, resp)
+	}
+
+	// Kode baru (setelah jeda kirim ulang) bisa dipakai tepat sekali
+	config.DB.Model(&models.PasswordReset{}).Where("user_id = ?", user.ID).Update("created_at", time.Now().Add(-2*time.Minute))
+	code = requestCode()
+	if status, resp := reset(code, "baru123"); status != http.StatusOK {
+		t.Fatalf("valid code: status %d, resp %v", status, resp)
+	}
+	if status, _ := reset(code, "lagi123"); status != http.StatusBadRequest {
+		t.Fatalf("reused code: status %d, want 400", status)
+	}
+
+	var updated models.Users
+	config.DB.First(&updated, user.ID)
+	if bcrypt.CompareHashAndPassword([]byte(updated.Password), []byte("baru123")) != nil {
+		t.Error("password was not changed to the new password")
+	}
+	if updated.FailedLoginCount != 0 {
+		t.Errorf("failed_login_count = %d, want 0", updated.FailedLoginCount)
+	}
+
+	var active int64
+	config.DB.Model(&models.UserSession{}).Where("user_id = ? AND revoked_at IS NULL", user.ID).Count(&active)
+	var revoked int64
+	config.DB.Model(&models.UserSession{}).Where("user_id = ? AND revoked_reason = ?", user.ID, "password_reset").Count(&revoked)
+	if active != 0 || revoked != 2 {
+		t.Errorf("sessions: %d active, %d revoked by password_reset; want 0 and 2", active, revoked)
+	}
+}
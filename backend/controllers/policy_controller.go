@@ -0,0 +1,416 @@
+package controllers
+
+import (
+	"SIAku/config"
+	"SIAku/middleware"
+	"SIAku/models"
+	"SIAku/utils"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+const policyAttachmentMaxSize = 10 << 20 // 10 MB
+
+var errPolicyStatusChanged = errors.New("policy status changed")
+
+type PolicyController struct{}
+
+func NewPolicyController() *PolicyController {
+	return &PolicyController{}
+}
+
+// CreatePolicy - Kajur membuat draft kebijakan untuk jurusannya
+func (pc *PolicyController) CreatePolicy(c *gin.Context) {
+	var req models.PolicyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid JSON format")
+		return
+	}
+
+	if err := utils.ValidateStruct(req); err != nil {
+		utils.HandleValidationError(c, err)
+		return
+	}
+
+	principal := middleware.GetPrincipal(c)
+	policy := models.Policy{
+		Title:       req.Title,
+		Description: req.Description,
+		Content:     req.Content,
+		Department:  principal.Jurusan,
+		Faculty:     req.Faculty,
+		Status:      models.PolicyDraft,
+		CreatedBy:   principal.UserID,
+	}
+
+	if err := config.DB.Create(&policy).Error; err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to create policy")
+		return
+	}
+
+	utils.CreatedResponse(c, policy)
+}
+
+// UpdatePolicy - Ubah isi kebijakan selama masih draft atau diminta revisi
+func (pc *PolicyController) UpdatePolicy(c *gin.Context) {
+	policy, ok := pc.findOwnPolicy(c)
+	if !ok {
+		return
+	}
+
+	var req models.PolicyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid JSON format")
+		return
+	}
+
+	if err := utils.ValidateStruct(req); err != nil {
+		utils.HandleValidationError(c, err)
+		return
+	}
+
+	result := config.DB.Model(&models.Policy{}).
+		Where("id = ? AND status IN ?", policy.ID, []string{models.PolicyDraft, models.PolicyRevisionRequested}).
+		Updates(map[string]interface{}{
+			"title":       req.Title,
+			"description": req.Description,
+			"content":     req.Content,
+			"faculty":     req.Faculty,
+		})
+	if result.Error != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to update policy")
+		return
+	}
+	if result.RowsAffected == 0 {
+		utils.ErrorResponse(c, http.StatusConflict, "Kebijakan hanya bisa diubah saat draft atau diminta revisi")
+		return
+	}
+
+	config.DB.Where("id = ?", policy.ID).First(&policy)
+	utils.SuccessResponse(c, policy)
+}
+
+// UploadAttachment - Unggah lampiran (multipart field "file") untuk versi yang akan diajukan
+func (pc *PolicyController) UploadAttachment(c *gin.Context) {
+	policy, ok := pc.findOwnPolicy(c)
+	if !ok {
+		return
+	}
+
+	if policy.Status != models.PolicyDraft && policy.Status != models.PolicyRevisionRequested {
+		utils.ErrorResponse(c, http.StatusConflict, "Lampiran hanya bisa ditambahkan saat draft atau diminta revisi")
+		return
+	}
+
+	file, err := c.FormFile("file")
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "File is required")
+		return
+	}
+	if file.Size > policyAttachmentMaxSize {
+		utils.ErrorResponse(c, http.StatusBadRequest, "File size exceeds 10 MB")
+		return
+	}
+
+	dir := filepath.Join(config.AppConfig.UploadDir, "policies", strconv.Itoa(int(policy.ID)))
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to store attachment")
+		return
+	}
+
+	// Nama file di disk dibuat server, nama asli hanya disimpan sebagai metadata
+	storedName := fmt.Sprintf("%d_%s", time.Now().UnixNano(), filepath.Base(file.Filename))
+	path := filepath.Join(dir, storedName)
+	if err := c.SaveUploadedFile(file, path); err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to store attachment")
+		return
+	}
+
+	attachment := models.PolicyAttachment{
+		PolicyID:   policy.ID,
+		Version:    policy.CurrentVersion + 1,
+		FileName:   filepath.Base(file.Filename),
+		FilePath:   path,
+		FileSize:   file.Size,
+		MimeType:   file.Header.Get("Content-Type"),
+		UploadedBy: middleware.GetPrincipal(c).UserID,
+	}
+	if err := config.DB.Create(&attachment).Error; err != nil {
+		os.Remove(path)
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to save attachment")
+		return
+	}
+
+	utils.CreatedResponse(c, attachment)
+}
+
+// SubmitPolicy - Ajukan kebijakan ke rektor; setiap pengajuan menyimpan revisi baru
+func (pc *PolicyController) SubmitPolicy(c *gin.Context) {
+	policy, ok := pc.findOwnPolicy(c)
+	if !ok {
+		return
+	}
+
+	var req models.PolicySubmitRequest
+	if err := c.ShouldBindJSON(&req); err != nil && c.Request.ContentLength > 0 {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid JSON format")
+		return
+	}
+
+	if err := utils.ValidateStruct(req); err != nil {
+		utils.HandleValidationError(c, err)
+		return
+	}
+
+	next, err := models.NextPolicyStatus(policy.Status, "submit")
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusConflict, err.Error())
+		return
+	}
+
+	principal := middleware.GetPrincipal(c)
+	now := time.Now()
+	version := policy.CurrentVersion + 1
+
+	err = config.DB.Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&models.Policy{}).
+			Where("id = ? AND status = ?", policy.ID, policy.Status).
+			Updates(map[string]interface{}{
+				"status":          next,
+				"current_version": version,
+				"submitted_at":    now,
+				"decided_by":      nil,
+				"decided_at":      nil,
+			})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return errPolicyStatusChanged
+		}
+
+		return tx.Create(&models.PolicyRevision{
+			PolicyID:    policy.ID,
+			Version:     version,
+			Title:       policy.Title,
+			Description: policy.Description,
+			Content:     policy.Content,
+			ChangeNote:  req.ChangeNote,
+			SubmittedBy: principal.UserID,
+		}).Error
+	})
+	if err != nil {
+		if errors.Is(err, errPolicyStatusChanged) {
+			utils.ErrorResponse(c, http.StatusConflict, "Status kebijakan sudah berubah, silakan muat ulang")
+			return
+		}
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to submit policy")
+		return
+	}
+
+	config.DB.Where("id = ?", policy.ID).First(&policy)
+	utils.SuccessResponse(c, policy)
+}
+
+// ReviewPolicy - Rektor menyetujui, menolak, atau meminta revisi kebijakan yang diajukan
+func (pc *PolicyController) ReviewPolicy(c *gin.Context) {
+	var req models.PolicyApprovalRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid JSON format")
+		return
+	}
+
+	if err := utils.ValidateStruct(req); err != nil {
+		utils.HandleValidationError(c, err)
+		return
+	}
+
+	var policy models.Policy
+	if err := config.DB.Where("id = ?", c.Param("id")).First(&policy).Error; err != nil {
+		utils.ErrorResponse(c, http.StatusNotFound, "Policy not found")
+		return
+	}
+
+	next, err := models.NextPolicyStatus(policy.Status, req.Action)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusConflict, err.Error())
+		return
+	}
+
+	reviewerID := middleware.GetPrincipal(c).UserID
+	now := time.Now()
+	before := policy
+
+	err = config.DB.Transaction(func(tx *gorm.DB) error {
+		updates := map[string]interface{}{"status": next}
+		if next == models.PolicyApproved || next == models.PolicyRejected {
+			updates["decided_by"] = reviewerID
+			updates["decided_at"] = now
+		}
+
+		result := tx.Model(&models.Policy{}).Where("id = ? AND status = ?", policy.ID, policy.Status).Updates(updates)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return errPolicyStatusChanged
+		}
+
+		if err := tx.Create(&models.PolicyReview{
+			PolicyID:   policy.ID,
+			Version:    policy.CurrentVersion,
+			Action:     req.Action,
+			Comments:   req.Comments,
+			ReviewerID: reviewerID,
+		}).Error; err != nil {
+			return err
+		}
+
+		if err := tx.Where("id = ?", policy.ID).First(&policy).Error; err != nil {
+			return err
+		}
+		return middleware.RecordAudit(c, tx, req.Action, "policy", policy.ID, before, policy)
+	})
+	if err != nil {
+		if errors.Is(err, errPolicyStatusChanged) {
+			utils.ErrorResponse(c, http.StatusConflict, "Status kebijakan sudah berubah, silakan muat ulang")
+			return
+		}
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to review policy")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Policy " + next,
+		"data":    policy,
+	})
+}
+
+// GetPolicies - Daftar kebijakan dengan filter status, department dan faculty (kajur hanya jurusannya)
+func (pc *PolicyController) GetPolicies(c *gin.Context) {
+	pc.listPolicies(c, c.Query("status"))
+}
+
+// GetPendingPolicies - Kebijakan yang menunggu keputusan rektor
+func (pc *PolicyController) GetPendingPolicies(c *gin.Context) {
+	pc.listPolicies(c, models.PolicySubmitted)
+}
+
+// GetPolicyDetail - Detail kebijakan beserta revisi, lampiran dan riwayat review
+func (pc *PolicyController) GetPolicyDetail(c *gin.Context) {
+	var policy models.Policy
+	query := config.DB.Preload("Revisions", func(db *gorm.DB) *gorm.DB { return db.Order("version ASC") }).
+		Preload("Attachments").
+		Preload("Reviews", func(db *gorm.DB) *gorm.DB { return db.Order("created_at ASC") })
+	if err := query.Where("id = ?", c.Param("id")).First(&policy).Error; err != nil {
+		utils.ErrorResponse(c, http.StatusNotFound, "Policy not found")
+		return
+	}
+
+	if !pc.canView(c, policy) {
+		utils.ErrorResponse(c, http.StatusForbidden, "Kebijakan bukan dari jurusan Anda")
+		return
+	}
+
+	utils.SuccessResponse(c, policy)
+}
+
+// DownloadAttachment - Unduh lampiran kebijakan
+func (pc *PolicyController) DownloadAttachment(c *gin.Context) {
+	var policy models.Policy
+	if err := config.DB.Where("id = ?", c.Param("id")).First(&policy).Error; err != nil {
+		utils.ErrorResponse(c, http.StatusNotFound, "Policy not found")
+		return
+	}
+
+	if !pc.canView(c, policy) {
+		utils.ErrorResponse(c, http.StatusForbidden, "Kebijakan bukan dari jurusan Anda")
+		return
+	}
+
+	var attachment models.PolicyAttachment
+	if err := config.DB.Where("id = ? AND policy_id = ?", c.Param("attachmentId"), policy.ID).First(&attachment).Error; err != nil {
+		utils.ErrorResponse(c, http.StatusNotFound, "Attachment not found")
+		return
+	}
+
+	c.FileAttachment(attachment.FilePath, attachment.FileName)
+}
+
+func (pc *PolicyController) listPolicies(c *gin.Context, status string) {
+	principal := middleware.GetPrincipal(c)
+	page := utils.GetPageParam(c)
+	limit := utils.GetLimitParam(c)
+	department := c.Query("department")
+	faculty := c.Query("faculty")
+	offset := (page - 1) * limit
+
+	query := config.DB.Model(&models.Policy{})
+	if principal.Role == "kajur" {
+		department = principal.Jurusan
+	} else {
+		// Draft belum diajukan, jadi tidak terlihat oleh rektor
+		query = query.Where("status <> ?", models.PolicyDraft)
+	}
+
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+	if department != "" {
+		query = query.Where("department = ?", department)
+	}
+	if faculty != "" {
+		query = query.Where("faculty = ?", faculty)
+	}
+
+	var policies []models.Policy
+	var total int64
+
+	query.Count(&total)
+	if err := query.Order("updated_at DESC").Offset(offset).Limit(limit).Find(&policies).Error; err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to fetch policies")
+		return
+	}
+
+	utils.SuccessResponse(c, gin.H{
+		"policies": policies,
+		"pagination": gin.H{
+			"page":        page,
+			"limit":       limit,
+			"total":       total,
+			"total_pages": (total + int64(limit) - 1) / int64(limit),
+		},
+	})
+}
+
+// findOwnPolicy memuat kebijakan milik jurusan kajur yang sedang login
+func (pc *PolicyController) findOwnPolicy(c *gin.Context) (models.Policy, bool) {
+	var policy models.Policy
+	if err := config.DB.Where("id = ?", c.Param("id")).First(&policy).Error; err != nil {
+		utils.ErrorResponse(c, http.StatusNotFound, "Policy not found")
+		return policy, false
+	}
+
+	if policy.Department != middleware.GetPrincipal(c).Jurusan {
+		utils.ErrorResponse(c, http.StatusForbidden, "Kebijakan bukan dari jurusan Anda")
+		return policy, false
+	}
+	return policy, true
+}
+
+func (pc *PolicyController) canView(c *gin.Context, policy models.Policy) bool {
+	principal := middleware.GetPrincipal(c)
+	if principal.Role == "kajur" {
+		return policy.Department == principal.Jurusan
+	}
+	return policy.Status != models.PolicyDraft
+}
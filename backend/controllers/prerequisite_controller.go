@@ -0,0 +1,349 @@
+package controllers
+
+import (
+	"SIAku/config"
+	"SIAku/middleware"
+	"SIAku/models"
+	"SIAku/utils"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// Nilai minimum default jika teks prasyarat lama tidak menyebutkan nilai
+const defaultPrerequisiteGrade = "D"
+
+type PrerequisiteController struct{}
+
+func NewPrerequisiteController() *PrerequisiteController {
+	return &PrerequisiteController{}
+}
+
+// GetCoursePrerequisites - Daftar prasyarat sebuah mata kuliah
+func (pc *PrerequisiteController) GetCoursePrerequisites(c *gin.Context) {
+	var course models.Course
+	if err := config.DB.Where("id = ?", c.Param("id")).First(&course).Error; err != nil {
+		utils.ErrorResponse(c, http.StatusNotFound, "Course not found")
+		return
+	}
+
+	var prerequisites []models.CoursePrerequisite
+	if err := config.DB.Preload("Prerequisite").Where("course_id = ?", course.ID).Find(&prerequisites).Error; err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to fetch prerequisites")
+		return
+	}
+
+	responses := make([]models.PrerequisiteResponse, 0, len(prerequisites))
+	for _, p := range prerequisites {
+		responses = append(responses, models.PrerequisiteResponse{
+			PrerequisiteID: p.PrerequisiteID,
+			CourseCode:     p.Prerequisite.Code,
+			CourseName:     p.Prerequisite.Name,
+			MinGrade:       p.MinGrade,
+		})
+	}
+
+	utils.SuccessResponse(c, gin.H{
+		"course_id":     course.ID,
+		"prasyarat":     course.Prasyarat,
+		"prerequisites": responses,
+	})
+}
+
+// SetCoursePrerequisites - Kajur mengganti seluruh daftar prasyarat mata kuliah di jurusannya
+func (pc *PrerequisiteController) SetCoursePrerequisites(c *gin.Context) {
+	var req models.SetPrerequisitesRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid JSON format")
+		return
+	}
+
+	if err := utils.ValidateStruct(req); err != nil {
+		utils.HandleValidationError(c, err)
+		return
+	}
+
+	course, ok := findDepartmentCourse(c, c.Param("courseId"))
+	if !ok {
+		return
+	}
+
+	prerequisiteIDs := make([]uint, 0, len(req.Prerequisites))
+	seen := map[uint]bool{}
+	for _, item := range req.Prerequisites {
+		if item.PrerequisiteID == course.ID {
+			utils.ErrorResponse(c, http.StatusBadRequest, "Mata kuliah tidak boleh menjadi prasyarat dirinya sendiri")
+			return
+		}
+		if seen[item.PrerequisiteID] {
+			utils.ErrorResponse(c, http.StatusBadRequest, "Duplicate prerequisite_id "+strconv.FormatUint(uint64(item.PrerequisiteID), 10))
+			return
+		}
+		seen[item.PrerequisiteID] = true
+		prerequisiteIDs = append(prerequisiteIDs, item.PrerequisiteID)
+	}
+
+	var found int64
+	config.DB.Model(&models.Course{}).Where("id IN ?", prerequisiteIDs).Count(&found)
+	if int(found) != len(prerequisiteIDs) {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Prerequisite course not found")
+		return
+	}
+
+	if cyclic, err := prerequisiteCreatesCycle(course.ID, prerequisiteIDs); err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to validate prerequisites")
+		return
+	} else if cyclic {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Prasyarat membentuk siklus dengan mata kuliah ini")
+		return
+	}
+
+	err := config.DB.Transaction(func(tx *gorm.DB) error {
+		return replacePrerequisites(c, tx, course.ID, req.Prerequisites)
+	})
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to save prerequisites")
+		return
+	}
+
+	utils.SuccessResponse(c, gin.H{
+		"message":       "Prerequisites updated",
+		"course_id":     course.ID,
+		"prerequisites": req.Prerequisites,
+	})
+}
+
+// MigratePrasyarat - Ubah teks Course.Prasyarat lama menjadi relasi prasyarat untuk mata kuliah di jurusan kajur
+func (pc *PrerequisiteController) MigratePrasyarat(c *gin.Context) {
+	var req models.MigratePrasyaratRequest
+	if err := c.ShouldBindJSON(&req); err != nil && c.Request.ContentLength > 0 {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid JSON format")
+		return
+	}
+
+	if err := utils.ValidateStruct(req); err != nil {
+		utils.HandleValidationError(c, err)
+		return
+	}
+
+	defaultGrade := req.DefaultMinGrade
+	if defaultGrade == "" {
+		defaultGrade = defaultPrerequisiteGrade
+	}
+
+	var courses []models.Course
+	if err := config.DB.Joins("JOIN dosens ON courses.dosen_id = dosens.id").
+		Where("dosens.jurusan = ? AND courses.prasyarat <> ''", middleware.GetPrincipal(c).Jurusan).
+		Find(&courses).Error; err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to fetch courses")
+		return
+	}
+
+	// Referensi dicocokkan ke kode atau nama mata kuliah (case-insensitive) di seluruh katalog
+	var catalog []models.Course
+	config.DB.Select("id", "code", "name").Find(&catalog)
+	byReference := map[string]models.Course{}
+	for _, course := range catalog {
+		byReference[strings.ToLower(course.Code)] = course
+		byReference[strings.ToLower(course.Name)] = course
+	}
+	known := func(reference string) bool {
+		_, ok := byReference[strings.ToLower(reference)]
+		return ok
+	}
+
+	var results []gin.H
+	migrated := 0
+	for _, course := range courses {
+		var items []models.PrerequisiteItem
+		var unmatched []string
+		for _, token := range utils.ParsePrasyarat(course.Prasyarat, known) {
+			target, ok := byReference[strings.ToLower(token.Reference)]
+			if !ok || target.ID == course.ID {
+				unmatched = append(unmatched, token.Reference)
+				continue
+			}
+			minGrade := token.MinGrade
+			if minGrade == "" {
+				minGrade = defaultGrade
+			}
+			items = append(items, models.PrerequisiteItem{PrerequisiteID: target.ID, MinGrade: minGrade})
+		}
+
+		result := gin.H{
+			"course_id":     course.ID,
+			"course_code":   course.Code,
+			"prasyarat":     course.Prasyarat,
+			"prerequisites": items,
+			"unmatched":     unmatched,
+		}
+
+		// Mata kuliah dengan referensi yang tidak dikenali dilewati supaya kajur bisa memperbaikinya manual
+		if !req.DryRun && len(unmatched) == 0 && len(items) > 0 {
+			ids := make([]uint, 0, len(items))
+			for _, item := range items {
+				ids = append(ids, item.PrerequisiteID)
+			}
+			if cyclic, err := prerequisiteCreatesCycle(course.ID, ids); err != nil || cyclic {
+				result["error"] = "prasyarat membentuk siklus"
+			} else if err := config.DB.Transaction(func(tx *gorm.DB) error {
+				return replacePrerequisites(c, tx, course.ID, items)
+			}); err != nil {
+				result["error"] = "gagal menyimpan prasyarat"
+			} else {
+				result["migrated"] = true
+				migrated++
+			}
+		}
+
+		results = append(results, result)
+	}
+
+	utils.SuccessResponse(c, gin.H{
+		"dry_run":  req.DryRun,
+		"total":    len(courses),
+		"migrated": migrated,
+		"courses":  results,
+	})
+}
+
+// evaluatePrerequisites mengecek prasyarat beberapa mata kuliah sekaligus terhadap riwayat nilai mahasiswa.
+// Hasilnya map course_id -> daftar prasyarat yang belum terpenuhi (kosong berarti boleh diambil).
+func evaluatePrerequisites(db *gorm.DB, mahasiswaID uint, courseIDs []uint) (map[uint][]models.UnmetPrerequisite, error) {
+	unmet := map[uint][]models.UnmetPrerequisite{}
+	if len(courseIDs) == 0 {
+		return unmet, nil
+	}
+
+	var prerequisites []models.CoursePrerequisite
+	if err := db.Preload("Prerequisite").Where("course_id IN ?", courseIDs).Find(&prerequisites).Error; err != nil {
+		return nil, err
+	}
+	if len(prerequisites) == 0 {
+		return unmet, nil
+	}
+
+	prerequisiteIDs := make([]uint, 0, len(prerequisites))
+	for _, p := range prerequisites {
+		prerequisiteIDs = append(prerequisiteIDs, p.PrerequisiteID)
+	}
+
+	var nilaiList []models.Nilai
+	if err := db.Select("course_id", "grade_huruf", "grade_point").
+		Where("mahasiswa_id = ? AND course_id IN ? AND status = ?", mahasiswaID, prerequisiteIDs, models.NilaiPublished).
+		Find(&nilaiList).Error; err != nil {
+		return nil, err
+	}
+
+	// Nilai terbaik per mata kuliah (jika pernah mengulang), dibandingkan lewat bobot karena skala bisa berbeda
+	best := map[uint]models.Nilai{}
+	for _, n := range nilaiList {
+		if current, ok := best[n.CourseID]; !ok || n.GradePoint > current.GradePoint {
+			best[n.CourseID] = n
+		}
+	}
+
+	for _, p := range prerequisites {
+		achieved, graded := best[p.PrerequisiteID]
+		if graded && models.MeetsMinimumPoint(achieved.GradePoint, p.MinGrade) {
+			continue
+		}
+		unmet[p.CourseID] = append(unmet[p.CourseID], models.UnmetPrerequisite{
+			CourseID:      p.PrerequisiteID,
+			CourseCode:    p.Prerequisite.Code,
+			CourseName:    p.Prerequisite.Name,
+			MinGrade:      p.MinGrade,
+			AchievedGrade: achieved.GradeHuruf,
+		})
+	}
+	return unmet, nil
+}
+
+// describeUnmetPrerequisites membuat pesan seperti "IF101 Algoritma 1 (min C, nilai D)"
+func describeUnmetPrerequisites(unmet []models.UnmetPrerequisite) string {
+	parts := make([]string, 0, len(unmet))
+	for _, u := range unmet {
+		achieved := "belum lulus"
+		if u.AchievedGrade != "" {
+			achieved = "nilai " + u.AchievedGrade
+		}
+		parts = append(parts, u.CourseCode+" "+u.CourseName+" (min "+u.MinGrade+", "+achieved+")")
+	}
+	return strings.Join(parts, "; ")
+}
+
+// prerequisiteCreatesCycle mengecek apakah courseID dapat dicapai lagi dari calon prasyaratnya
+func prerequisiteCreatesCycle(courseID uint, prerequisiteIDs []uint) (bool, error) {
+	var edges []models.CoursePrerequisite
+	if err := config.DB.Select("course_id", "prerequisite_id").Where("course_id <> ?", courseID).Find(&edges).Error; err != nil {
+		return false, err
+	}
+
+	graph := map[uint][]uint{}
+	for _, e := range edges {
+		graph[e.CourseID] = append(graph[e.CourseID], e.PrerequisiteID)
+	}
+
+	visited := map[uint]bool{}
+	stack := append([]uint{}, prerequisiteIDs...)
+	for len(stack) > 0 {
+		current := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		if current == courseID {
+			return true, nil
+		}
+		if visited[current] {
+			continue
+		}
+		visited[current] = true
+		stack = append(stack, graph[current]...)
+	}
+	return false, nil
+}
+
+func replacePrerequisites(c *gin.Context, tx *gorm.DB, courseID uint, items []models.PrerequisiteItem) error {
+	var before []models.CoursePrerequisite
+	tx.Where("course_id = ?", courseID).Find(&before)
+
+	if err := tx.Where("course_id = ?", courseID).Delete(&models.CoursePrerequisite{}).Error; err != nil {
+		return err
+	}
+	for _, item := range items {
+		if err := tx.Create(&models.CoursePrerequisite{
+			CourseID:       courseID,
+			PrerequisiteID: item.PrerequisiteID,
+			MinGrade:       item.MinGrade,
+		}).Error; err != nil {
+			return err
+		}
+	}
+
+	return middleware.RecordAudit(c, tx, "set_prerequisites", "course", courseID,
+		gin.H{"prerequisites": prerequisiteSnapshot(before)}, gin.H{"prerequisites": items})
+}
+
+func prerequisiteSnapshot(list []models.CoursePrerequisite) []models.PrerequisiteItem {
+	items := make([]models.PrerequisiteItem, 0, len(list))
+	for _, p := range list {
+		items = append(items, models.PrerequisiteItem{PrerequisiteID: p.PrerequisiteID, MinGrade: p.MinGrade})
+	}
+	return items
+}
+
+// findDepartmentCourse memuat mata kuliah dan memastikan dosen pengampunya di jurusan kajur
+func findDepartmentCourse(c *gin.Context, courseID string) (models.Course, bool) {
+	var course models.Course
+	if err := config.DB.Preload("Dosen").Where("id = ?", courseID).First(&course).Error; err != nil {
+		utils.ErrorResponse(c, http.StatusNotFound, "Course not found")
+		return course, false
+	}
+
+	if course.Dosen == nil || course.Dosen.Jurusan != middleware.GetPrincipal(c).Jurusan {
+		utils.ErrorResponse(c, http.StatusForbidden, "You can only manage courses in your department")
+		return course, false
+	}
+	return course, true
+}
@@ -2,13 +2,16 @@ package controllers
 
 import (
 	"SIAku/config"
+	"SIAku/middleware"
 	"SIAku/models"
 	"SIAku/utils"
+	"errors"
+	"log"
 	"net/http"
-	"strconv"
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
 )
 
 type RektorController struct{}
@@ -19,12 +22,6 @@ func NewRektorController() *RektorController {
 
 // GetUniversityDashboard - Dashboard summary seluruh universitas
 func (rc *RektorController) GetUniversityDashboard(c *gin.Context) {
-	userRole := c.GetString("user_role")
-	if userRole != "rektor" {
-		utils.ErrorResponse(c, http.StatusForbidden, "Access denied: Rektor role required")
-		return
-	}
-
 	semester := c.DefaultQuery("semester", "ganjil_2024_2025")
 	academicYear := c.DefaultQuery("academic_year", "2024-2025")
 
@@ -161,18 +158,17 @@ func (rc *RektorController) GetUniversityDashboard(c *gin.Context) {
 
 // GetFacultyReport - Lihat laporan per fakultas/jurusan
 func (rc *RektorController) GetFacultyReport(c *gin.Context) {
-	userRole := c.GetString("user_role")
-	if userRole != "rektor" {
-		utils.ErrorResponse(c, http.StatusForbidden, "Access denied: Rektor role required")
-		return
-	}
-
 	facultyName := c.Param("faculty")
 	if facultyName == "" {
 		utils.ErrorResponse(c, http.StatusBadRequest, "Faculty name is required")
 		return
 	}
 
+	utils.SuccessResponse(c, buildFacultyReport(facultyName))
+}
+
+// buildFacultyReport menyusun laporan satu fakultas (dipakai rektor dan dekan)
+func buildFacultyReport(facultyName string) models.FacultyReport {
 	// For simplicity, treating jurusan as faculty
 	var totalStudents int64
 	var totalLecturers int64
@@ -197,7 +193,7 @@ func (rc *RektorController) GetFacultyReport(c *gin.Context) {
 		HeadOfDept:     "Prof. Head " + facultyName,
 	}
 
-	facultyReport := models.FacultyReport{
+	return models.FacultyReport{
 		FacultyID:        1,
 		FacultyName:      "Faculty of " + facultyName,
 		TotalStudents:    int(totalStudents),
@@ -214,18 +210,10 @@ func (rc *RektorController) GetFacultyReport(c *gin.Context) {
 			EmployabilityRate:   90.0,
 		},
 	}
-
-	utils.SuccessResponse(c, facultyReport)
 }
 
-// AssignRole - Mengatur role (otorisasi global)
+// AssignRole - Menugaskan jabatan (dosen/kajur/dekan) yang berlaku pada effective_date
 func (rc *RektorController) AssignRole(c *gin.Context) {
-	userRole := c.GetString("user_role")
-	if userRole != "rektor" {
-		utils.ErrorResponse(c, http.StatusForbidden, "Access denied: Rektor role required")
-		return
-	}
-
 	var req models.RoleAssignmentRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
 		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid JSON format")
@@ -237,223 +225,337 @@ func (rc *RektorController) AssignRole(c *gin.Context) {
 		return
 	}
 
+	effectiveDate, _ := time.ParseInLocation("2006-01-02", req.EffectiveDate, time.Local)
+	var endDate *time.Time
+	if req.EndDate != "" {
+		end, _ := time.ParseInLocation("2006-01-02", req.EndDate, time.Local)
+		if !end.After(effectiveDate) {
+			utils.ErrorResponse(c, http.StatusBadRequest, "end_date must be after effective_date")
+			return
+		}
+		endDate = &end
+	}
+
 	// Find the user to be assigned
 	var dosen models.Dosen
-	if err := config.DB.Where("id = ?", req.UserID).First(&dosen).Error; err != nil {
+	if err := config.DB.Where("id = ?", req.DosenID).First(&dosen).Error; err != nil {
+		utils.ErrorResponse(c, http.StatusNotFound, "Dosen not found")
+		return
+	}
+	if dosen.UserID == nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Dosen belum memiliki akun user")
+		return
+	}
+
+	var user models.Users
+	if err := config.DB.Where("id = ?", *dosen.UserID).First(&user).Error; err != nil {
 		utils.ErrorResponse(c, http.StatusNotFound, "User not found")
 		return
 	}
 
-	oldRole := "dosen" // Default role from context
+	assignment := models.RoleAssignment{
+		UserID:        user.ID,
+		DosenID:       dosen.ID,
+		PreviousRole:  user.Role,
+		Role:          req.NewRole,
+		EffectiveDate: effectiveDate,
+		EndDate:       endDate,
+		Status:        "scheduled",
+		Reason:        req.Reason,
+		AssignedBy:    middleware.GetPrincipal(c).UserID,
+	}
+	switch req.NewRole {
+	case "kajur":
+		assignment.Jurusan = req.Department
+	case "dekan":
+		assignment.Faculty = req.Faculty
+	}
 
-	// Update role based on new assignment
-	if req.NewRole == "kajur" {
-		// Create Kajur record
-		kajur := models.Kajur{
-			NIDN:    dosen.NIDN,
-			Nama:    dosen.Nama,
-			Email:   dosen.Email,
-			Jurusan: req.Department,
-			Status:  "active",
+	// Penugasan dibuat dan (jika tanggal berlaku sudah tiba) langsung diterapkan dalam satu transaksi,
+	// supaya kegagalan penerapan tidak meninggalkan penugasan terjadwal yang nanti diterapkan scheduler
+	now := time.Now()
+	var affected []uint
+	err := config.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&assignment).Error; err != nil {
+			return err
 		}
-
-		if err := config.DB.Create(&kajur).Error; err != nil {
-			utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to create kajur role")
-			return
+		if err := middleware.RecordAudit(c, tx, "assign_role", "role_assignment", assignment.ID, nil, assignment); err != nil {
+			return err
 		}
-
-		// Update dosen status
-		config.DB.Model(&dosen).Update("status", "promoted_to_kajur")
-	} else {
-		// Update existing dosen status
-		config.DB.Model(&dosen).Update("status", "active")
-	}
-
-	effectiveDate, _ := time.Parse("2006-01-02", req.EffectiveDate)
-	assignedBy := c.GetString("user_name")
-
-	response := models.RoleAssignmentResponse{
-		AssignmentID:  uint(time.Now().Unix()),
-		UserID:        req.UserID,
-		UserName:      dosen.Nama,
-		UserNIDN:      dosen.NIDN,
-		OldRole:       oldRole,
-		NewRole:       req.NewRole,
-		Department:    req.Department,
-		Faculty:       req.Faculty,
-		EffectiveDate: effectiveDate,
-		AssignedBy:    assignedBy,
-		Reason:        req.Reason,
-		Status:        "active",
-		CreatedAt:     time.Now(),
+		if effectiveDate.After(now) {
+			return nil
+		}
+		var err error
+		affected, err = applyRoleAssignment(tx, assignment, now)
+		return err
+	})
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to create role assignment")
+		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{
+	revokeSessionsAfterRoleChange(affected)
+
+	config.DB.Preload("Dosen").Preload("Assigner").Where("id = ?", assignment.ID).First(&assignment)
+
+	c.JSON(http.StatusCreated, gin.H{
 		"success": true,
-		"message": "Role assigned successfully",
-		"data":    response,
+		"message": "Role assignment " + assignment.Status,
+		"data":    roleAssignmentResponse(assignment),
 	})
 }
 
-// ApprovePolicy - Approve/reject kebijakan besar
-func (rc *RektorController) ApprovePolicy(c *gin.Context) {
-	userRole := c.GetString("user_role")
-	if userRole != "rektor" {
-		utils.ErrorResponse(c, http.StatusForbidden, "Access denied: Rektor role required")
-		return
+// GetRoleAssignments - Riwayat penugasan jabatan (filter: status, role, user_id, jurusan, faculty)
+func (rc *RektorController) GetRoleAssignments(c *gin.Context) {
+	page := utils.GetPageParam(c)
+	limit := utils.GetLimitParam(c)
+	offset := (page - 1) * limit
+
+	query := config.DB.Model(&models.RoleAssignment{})
+	for _, filter := range []string{"status", "role", "user_id", "jurusan", "faculty"} {
+		if value := c.Query(filter); value != "" {
+			query = query.Where(filter+" = ?", value)
+		}
 	}
 
-	policyIDStr := c.Param("id")
-	policyID, err := strconv.ParseUint(policyIDStr, 10, 32)
-	if err != nil {
-		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid policy ID")
+	var records []models.RoleAssignment
+	var total int64
+
+	query.Count(&total)
+	if err := query.Preload("Dosen").Preload("Assigner").
+		Order("effective_date DESC, id DESC").Offset(offset).Limit(limit).Find(&records).Error; err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to fetch role assignments")
 		return
 	}
 
-	var req models.PolicyApprovalRequest
+	assignments := make([]models.RoleAssignmentResponse, 0, len(records))
+	for _, record := range records {
+		assignments = append(assignments, roleAssignmentResponse(record))
+	}
+
+	response := gin.H{
+		"assignments": assignments,
+		"pagination": gin.H{
+			"page":        page,
+			"limit":       limit,
+			"total":       int(total),
+			"total_pages": (int(total) + limit - 1) / limit,
+		},
+	}
+
+	utils.SuccessResponse(c, response)
+}
+
+// RevokeRoleAssignment - Batalkan penugasan terjadwal atau cabut jabatan yang sedang aktif
+func (rc *RektorController) RevokeRoleAssignment(c *gin.Context) {
+	var req models.RevokeRoleAssignmentRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
 		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid JSON format")
 		return
 	}
 
-	req.PolicyID = uint(policyID)
-
 	if err := utils.ValidateStruct(req); err != nil {
 		utils.HandleValidationError(c, err)
 		return
 	}
 
-	approvalDate, _ := time.Parse("2006-01-02", req.ApprovalDate)
-	approvedBy := c.GetString("user_name")
-
-	// Simulate policy approval (in real app, would update policy table)
-	response := models.PolicyApprovalResponse{
-		PolicyID:     req.PolicyID,
-		PolicyTitle:  "Academic Policy Update " + policyIDStr,
-		SubmittedBy:  "Kajur Teknik Informatika",
-		Department:   "Teknik Informatika",
-		Faculty:      "Faculty of Engineering",
-		Action:       req.Action,
-		ApprovedBy:   approvedBy,
-		Comments:     req.Comments,
-		ApprovalDate: approvalDate,
-		Status:       req.Action + "d",
+	var assignment models.RoleAssignment
+	if err := config.DB.Where("id = ?", c.Param("id")).First(&assignment).Error; err != nil {
+		utils.ErrorResponse(c, http.StatusNotFound, "Role assignment not found")
+		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{
-		"success": true,
-		"message": "Policy " + req.Action + "d successfully",
-		"data":    response,
-	})
-}
+	actorID := middleware.GetPrincipal(c).UserID
+	now := time.Now()
+	before := assignment
+	var affected []uint
+
+	err := config.DB.Transaction(func(tx *gorm.DB) error {
+		switch assignment.Status {
+		case "scheduled":
+			result := tx.Model(&models.RoleAssignment{}).
+				Where("id = ? AND status = ?", assignment.ID, "scheduled").
+				Updates(map[string]interface{}{"status": "revoked", "ended_at": now, "end_reason": req.Reason, "revoked_by": actorID})
+			if result.Error != nil {
+				return result.Error
+			}
+			if result.RowsAffected == 0 {
+				return errAssignmentNotPending
+			}
+		case "active":
+			users, err := endRoleAssignment(tx, assignment, "revoked", req.Reason, &actorID, now)
+			if err != nil {
+				return err
+			}
+			if len(users) == 0 {
+				return errAssignmentNotPending
+			}
+			affected = users
+		default:
+			return errAssignmentNotPending
+		}
 
-// GetPendingPolicies - View policies awaiting approval
-func (rc *RektorController) GetPendingPolicies(c *gin.Context) {
-	userRole := c.GetString("user_role")
-	if userRole != "rektor" {
-		utils.ErrorResponse(c, http.StatusForbidden, "Access denied: Rektor role required")
+		if err := tx.Where("id = ?", assignment.ID).First(&assignment).Error; err != nil {
+			return err
+		}
+		return middleware.RecordAudit(c, tx, "revoke_role", "role_assignment", assignment.ID, before, assignment)
+	})
+	if err != nil {
+		if errors.Is(err, errAssignmentNotPending) {
+			utils.ErrorResponse(c, http.StatusConflict, "Penugasan sudah berakhir atau sudah dicabut")
+			return
+		}
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to revoke role assignment")
 		return
 	}
 
-	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
-	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "10"))
-	offset := (page - 1) * limit
+	revokeSessionsAfterRoleChange(affected)
 
-	// Simulate pending policies (in real app, would query policy table)
-	policies := []models.PolicyApprovalResponse{
-		{
-			PolicyID:    1,
-			PolicyTitle: "New Academic Curriculum 2025",
-			SubmittedBy: "Kajur Teknik Informatika",
-			Department:  "Teknik Informatika",
-			Faculty:     "Faculty of Engineering",
-			Status:      "pending",
-		},
-		{
-			PolicyID:    2,
-			PolicyTitle: "Student Exchange Program Guidelines",
-			SubmittedBy: "Kajur Manajemen",
-			Department:  "Manajemen",
-			Faculty:     "Faculty of Economics",
-			Status:      "pending",
-		},
-		{
-			PolicyID:    3,
-			PolicyTitle: "Research Grant Allocation Policy",
-			SubmittedBy: "Kajur Teknik Sipil",
-			Department:  "Teknik Sipil",
-			Faculty:     "Faculty of Engineering",
-			Status:      "pending",
-		},
-	}
+	config.DB.Preload("Dosen").Preload("Assigner").Where("id = ?", assignment.ID).First(&assignment)
+	utils.SuccessResponse(c, roleAssignmentResponse(assignment))
+}
 
-	// Apply pagination
-	start := offset
-	end := offset + limit
-	if start > len(policies) {
-		start = len(policies)
+func roleAssignmentResponse(assignment models.RoleAssignment) models.RoleAssignmentResponse {
+	return models.RoleAssignmentResponse{
+		AssignmentID:  assignment.ID,
+		UserID:        assignment.UserID,
+		DosenID:       assignment.DosenID,
+		UserName:      assignment.Dosen.Nama,
+		UserNIDN:      assignment.Dosen.NIDN,
+		OldRole:       assignment.PreviousRole,
+		NewRole:       assignment.Role,
+		Department:    assignment.Jurusan,
+		Faculty:       assignment.Faculty,
+		EffectiveDate: assignment.EffectiveDate,
+		EndDate:       assignment.EndDate,
+		AssignedBy:    assignment.Assigner.Username,
+		Reason:        assignment.Reason,
+		Status:        assignment.Status,
+		AppliedAt:     assignment.AppliedAt,
+		EndedAt:       assignment.EndedAt,
+		EndReason:     assignment.EndReason,
+		CreatedAt:     assignment.CreatedAt,
 	}
-	if end > len(policies) {
-		end = len(policies)
+}
+
+// UnlockAccount - Buka kunci akun yang terkunci karena login gagal berulang
+func (rc *RektorController) UnlockAccount(c *gin.Context) {
+	userID := c.Param("id")
+
+	var req models.UnlockAccountRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid JSON format")
+		return
 	}
 
-	paginatedPolicies := policies[start:end]
+	if err := utils.ValidateStruct(req); err != nil {
+		utils.HandleValidationError(c, err)
+		return
+	}
 
-	response := gin.H{
-		"policies": paginatedPolicies,
-		"pagination": gin.H{
-			"page":        page,
-			"limit":       limit,
-			"total":       len(policies),
-			"total_pages": (len(policies) + limit - 1) / limit,
-		},
+	var user models.Users
+	if err := config.DB.Where("id = ?", userID).First(&user).Error; err != nil {
+		utils.ErrorResponse(c, http.StatusNotFound, "User not found")
+		return
 	}
 
-	utils.SuccessResponse(c, response)
-}
+	actorID := middleware.GetPrincipal(c).UserID
+	err := config.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&models.Users{}).Where("id = ?", user.ID).Updates(map[string]interface{}{
+			"failed_login_count": 0,
+			"lockout_count":      0,
+			"locked_until":       nil,
+		}).Error; err != nil {
+			return err
+		}
 
-// GetRoleAssignments - View current role assignments
-func (rc *RektorController) GetRoleAssignments(c *gin.Context) {
-	userRole := c.GetString("user_role")
-	if userRole != "rektor" {
-		utils.ErrorResponse(c, http.StatusForbidden, "Access denied: Rektor role required")
+		return tx.Create(&models.AccountLockoutEvent{
+			UserID:    user.ID,
+			Event:     "unlocked",
+			Reason:    req.Reason,
+			IPAddress: c.ClientIP(),
+			ActorID:   &actorID,
+		}).Error
+	})
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to unlock account")
 		return
 	}
 
-	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
-	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "10"))
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Account unlocked successfully",
+		"data": gin.H{
+			"user_id":  user.ID,
+			"username": user.Username,
+		},
+	})
+}
+
+// GetLockoutHistory - Riwayat kunci/buka akun
+func (rc *RektorController) GetLockoutHistory(c *gin.Context) {
+	page := utils.GetPageParam(c)
+	limit := utils.GetLimitParam(c)
+	userID := c.Query("user_id")
+	event := c.Query("event")
 	offset := (page - 1) * limit
 
-	var dosens []models.Dosen
+	query := config.DB.Model(&models.AccountLockoutEvent{})
+	if userID != "" {
+		query = query.Where("user_id = ?", userID)
+	}
+	if event != "" {
+		query = query.Where("event = ?", event)
+	}
+
+	var events []models.AccountLockoutEvent
 	var total int64
 
-	query := config.DB.Model(&models.Dosen{})
 	query.Count(&total)
-	query.Offset(offset).Limit(limit).Find(&dosens)
-
-	var assignments []models.RoleAssignmentResponse
-	for _, dosen := range dosens {
-		assignment := models.RoleAssignmentResponse{
-			UserID:     dosen.ID,
-			UserName:   dosen.Nama,
-			UserNIDN:   dosen.NIDN,
-			NewRole:    "dosen",
-			Department: dosen.Jurusan,
-			Status:     dosen.Status,
-			CreatedAt:  dosen.CreatedAt,
-		}
-		assignments = append(assignments, assignment)
+	if err := query.Order("created_at DESC").Offset(offset).Limit(limit).Find(&events).Error; err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to fetch lockout history")
+		return
 	}
 
-	response := gin.H{
-		"assignments": assignments,
+	utils.SuccessResponse(c, gin.H{
+		"events": events,
 		"pagination": gin.H{
 			"page":        page,
 			"limit":       limit,
-			"total":       int(total),
-			"total_pages": (int(total) + limit - 1) / limit,
+			"total":       total,
+			"total_pages": (total + int64(limit) - 1) / int64(limit),
 		},
+	})
+}
+
+// RecalculateIPK - Hitung ulang Mahasiswa.IPK semua mahasiswa, misalnya setelah RETAKE_GRADE_POLICY diubah
+func (rc *RektorController) RecalculateIPK(c *gin.Context) {
+	var mahasiswaIDs []uint
+	if err := config.DB.Model(&models.Mahasiswa{}).Pluck("id", &mahasiswaIDs).Error; err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to fetch mahasiswa")
+		return
 	}
 
-	utils.SuccessResponse(c, response)
+	failed := 0
+	for _, id := range mahasiswaIDs {
+		if err := refreshMahasiswaIPK(config.DB, id); err != nil {
+			log.Printf("Failed to recalculate IPK of mahasiswa %d: %v", id, err)
+			failed++
+		}
+	}
+
+	if err := middleware.RecordAudit(c, nil, "recalculate_ipk", "mahasiswa", 0, nil, gin.H{
+		"retake_policy": retakePolicy(),
+		"total":         len(mahasiswaIDs),
+		"failed":        failed,
+	}); err != nil {
+		log.Printf("Failed to record audit: %v", err)
+	}
+
+	utils.SuccessResponse(c, gin.H{
+		"message":       "IPK recalculated",
+		"retake_policy": retakePolicy(),
+		"total":         len(mahasiswaIDs),
+		"failed":        failed,
+	})
 }
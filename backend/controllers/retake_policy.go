@@ -0,0 +1,104 @@
+package controllers
+
+import (
+	"SIAku/config"
+	"SIAku/models"
+	"math"
+
+	"gorm.io/gorm"
+)
+
+// Helper kebijakan mengulang: dari beberapa percobaan satu mata kuliah hanya satu yang dihitung di IPK
+
+// retakePolicy mengembalikan kebijakan yang dikonfigurasi (default nilai terbaik)
+func retakePolicy() string {
+	if config.AppConfig.RetakePolicy == models.RetakeLatest {
+		return models.RetakeLatest
+	}
+	return models.RetakeBest
+}
+
+// laterAttempt mengecek apakah a diambil setelah b
+func laterAttempt(a, b models.Nilai) bool {
+	if a.Semester != b.Semester {
+		return a.Semester > b.Semester
+	}
+	if a.Attempt != b.Attempt {
+		return a.Attempt > b.Attempt
+	}
+	return a.ID > b.ID
+}
+
+// countedAttempts memilih percobaan yang dihitung per mata kuliah (hanya yang sudah dinilai).
+// Kebijakan best memilih bobot tertinggi (seri: percobaan terakhir), latest memilih percobaan terakhir.
+func countedAttempts(nilaiList []models.Nilai, policy string) map[uint]bool {
+	chosen := map[uint]models.Nilai{}
+	for _, n := range nilaiList {
+		if n.Status != models.NilaiPublished {
+			continue
+		}
+		current, ok := chosen[n.CourseID]
+		switch {
+		case !ok:
+			chosen[n.CourseID] = n
+		case policy == models.RetakeLatest:
+			if laterAttempt(n, current) {
+				chosen[n.CourseID] = n
+			}
+		case n.GradePoint > current.GradePoint || (n.GradePoint == current.GradePoint && laterAttempt(n, current)):
+			chosen[n.CourseID] = n
+		}
+	}
+
+	counted := make(map[uint]bool, len(chosen))
+	for _, n := range chosen {
+		counted[n.ID] = true
+	}
+	return counted
+}
+
+// isSuperseded mengecek apakah percobaan sudah dinilai tetapi digantikan percobaan lain
+func isSuperseded(n models.Nilai, counted map[uint]bool) bool {
+	return n.Status == models.NilaiPublished && !counted[n.ID]
+}
+
+// gradeIndex menghitung indeks prestasi dari percobaan yang dihitung (Course harus ter-preload)
+func gradeIndex(nilaiList []models.Nilai, counted map[uint]bool) (float64, int) {
+	points, credits := 0.0, 0
+	for _, n := range nilaiList {
+		if counted[n.ID] {
+			points += n.GradePoint * float64(n.Course.Credits)
+			credits += n.Course.Credits
+		}
+	}
+	if credits == 0 {
+		return 0, 0
+	}
+	return math.Round(points/float64(credits)*100) / 100, credits
+}
+
+// cumulativeIPK menghitung IPK sampai semester tertentu menurut kebijakan mengulang; ok=false jika belum ada nilai
+func cumulativeIPK(db *gorm.DB, mahasiswaID uint, uptoSemester int) (float64, bool, error) {
+	var nilaiList []models.Nilai
+	if err := db.Preload("Course").
+		Where("mahasiswa_id = ? AND semester <= ? AND status = ?", mahasiswaID, uptoSemester, models.NilaiPublished).
+		Find(&nilaiList).Error; err != nil {
+		return 0, false, err
+	}
+
+	ipk, credits := gradeIndex(nilaiList, countedAttempts(nilaiList, retakePolicy()))
+	return ipk, credits > 0, nil
+}
+
+// refreshMahasiswaIPK menyimpan IPK terbaru ke Mahasiswa.IPK
+func refreshMahasiswaIPK(db *gorm.DB, mahasiswaID uint) error {
+	var nilaiList []models.Nilai
+	if err := db.Preload("Course").
+		Where("mahasiswa_id = ? AND status = ?", mahasiswaID, models.NilaiPublished).
+		Find(&nilaiList).Error; err != nil {
+		return err
+	}
+
+	ipk, _ := gradeIndex(nilaiList, countedAttempts(nilaiList, retakePolicy()))
+	return db.Model(&models.Mahasiswa{}).Where("id = ?", mahasiswaID).Update("ipk", ipk).Error
+}
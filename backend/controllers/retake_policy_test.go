@@ -0,0 +1,95 @@
+package controllers
+
+import (
+	"SIAku/models"
+	"reflect"
+	"testing"
+)
+
+func TestCountedAttempts(t *testing.T) {
+	nilai := func(id, courseID uint, semester, attempt int, gradePoint float64, status string) models.Nilai {
+		return models.Nilai{
+			ID: id, CourseID: courseID, Semester: semester, Attempt: attempt, GradePoint: gradePoint, Status: status,
+			Course: models.Course{Credits: 3},
+		}
+	}
+	published := models.NilaiPublished
+
+	tests := []struct {
+		name   string
+		list   []models.Nilai
+		policy string
+		want   map[uint]bool
+	}{
+		{
+			name:   "best memilih bobot tertinggi walau lebih lama",
+			list:   []models.Nilai{nilai(1, 10, 1, 1, 3.0, published), nilai(2, 10, 3, 2, 2.0, published)},
+			policy: models.RetakeBest,
+			want:   map[uint]bool{1: true},
+		},
+		{
+			name:   "latest memilih percobaan terakhir walau lebih rendah",
+			list:   []models.Nilai{nilai(1, 10, 1, 1, 3.0, published), nilai(2, 10, 3, 2, 2.0, published)},
+			policy: models.RetakeLatest,
+			want:   map[uint]bool{2: true},
+		},
+		{
+			name:   "best seri memilih semester terakhir",
+			list:   []models.Nilai{nilai(2, 10, 3, 2, 3.0, published), nilai(1, 10, 1, 1, 3.0, published)},
+			policy: models.RetakeBest,
+			want:   map[uint]bool{2: true},
+		},
+		{
+			name:   "semester sama memakai nomor percobaan",
+			list:   []models.Nilai{nilai(5, 10, 2, 2, 2.0, published), nilai(4, 10, 2, 1, 2.0, published)},
+			policy: models.RetakeLatest,
+			want:   map[uint]bool{5: true},
+		},
+		{
+			name:   "semester dan percobaan sama memakai ID",
+			list:   []models.Nilai{nilai(7, 10, 2, 1, 2.0, published), nilai(8, 10, 2, 1, 2.0, published)},
+			policy: models.RetakeBest,
+			want:   map[uint]bool{8: true},
+		},
+		{
+			name:   "draft tidak dihitung",
+			list:   []models.Nilai{nilai(1, 10, 1, 1, 1.0, published), nilai(2, 10, 3, 2, 4.0, models.NilaiDraft)},
+			policy: models.RetakeLatest,
+			want:   map[uint]bool{1: true},
+		},
+		{
+			name:   "satu per mata kuliah",
+			list:   []models.Nilai{nilai(1, 10, 1, 1, 2.0, published), nilai(2, 11, 1, 1, 1.0, published)},
+			policy: models.RetakeBest,
+			want:   map[uint]bool{1: true, 2: true},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := countedAttempts(tt.list, tt.policy)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("got %v, want %v", got, tt.want)
+			}
+			for _, n := range tt.list {
+				if want := n.Status == published && !tt.want[n.ID]; isSuperseded(n, got) != want {
+					t.Errorf("isSuperseded(%d) = %v, want %v", n.ID, !want, want)
+				}
+			}
+		})
+	}
+}
+
+func TestGradeIndexUsesCountedAttempts(t *testing.T) {
+	list := []models.Nilai{
+		{ID: 1, GradePoint: 4.0, Course: models.Course{Credits: 3}},
+		{ID: 2, GradePoint: 2.0, Course: models.Course{Credits: 3}},
+		{ID: 3, GradePoint: 3.0, Course: models.Course{Credits: 2}},
+	}
+	ipk, credits := gradeIndex(list, map[uint]bool{1: true, 3: true})
+	if ipk != 3.6 || credits != 5 {
+		t.Errorf("got (%v, %d), want (3.6, 5)", ipk, credits)
+	}
+	if ipk, credits := gradeIndex(list, map[uint]bool{}); ipk != 0 || credits != 0 {
+		t.Errorf("empty: got (%v, %d), want (0, 0)", ipk, credits)
+	}
+}
@@ -0,0 +1,240 @@
+package controllers
+
+import (
+	"SIAku/config"
+	"SIAku/middleware"
+	"SIAku/models"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Helper penerapan penugasan jabatan untuk RektorController.AssignRole dan scheduler
+
+var errAssignmentNotPending = errors.New("role assignment is no longer scheduled")
+
+// StartRoleAssignmentScheduler menerapkan penugasan yang sudah jatuh tempo dan mengakhiri masa jabatan yang habis secara berkala
+func StartRoleAssignmentScheduler(interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			ApplyDueRoleAssignments(time.Now())
+			<-ticker.C
+		}
+	}()
+}
+
+// ApplyDueRoleAssignments mengakhiri jabatan yang end_date-nya lewat lalu menerapkan penugasan yang effective_date-nya tiba
+func ApplyDueRoleAssignments(now time.Time) {
+	var expired []models.RoleAssignment
+	config.DB.Where("status = ? AND end_date IS NOT NULL AND end_date <= ?", "active", now).Find(&expired)
+	for _, assignment := range expired {
+		var affected []uint
+		err := config.DB.Transaction(func(tx *gorm.DB) error {
+			var err error
+			affected, err = endRoleAssignment(tx, assignment, "ended", "Masa jabatan berakhir", nil, now)
+			if err != nil || len(affected) == 0 {
+				return err
+			}
+			return auditScheduledRoleChange(tx, "end_role", assignment)
+		})
+		if err != nil {
+			log.Printf("Failed to end role assignment %d: %v", assignment.ID, err)
+			continue
+		}
+		revokeSessionsAfterRoleChange(affected)
+	}
+
+	var due []models.RoleAssignment
+	config.DB.Where("status = ? AND effective_date <= ?", "scheduled", now).Order("effective_date ASC, id ASC").Find(&due)
+	for _, assignment := range due {
+		var affected []uint
+		err := config.DB.Transaction(func(tx *gorm.DB) error {
+			var err error
+			affected, err = applyRoleAssignment(tx, assignment, now)
+			if err != nil {
+				return err
+			}
+			return auditScheduledRoleChange(tx, "apply_role", assignment)
+		})
+		if err != nil {
+			if !errors.Is(err, errAssignmentNotPending) {
+				log.Printf("Failed to apply role assignment %d: %v", assignment.ID, err)
+			}
+			continue
+		}
+		revokeSessionsAfterRoleChange(affected)
+	}
+}
+
+// auditScheduledRoleChange mencatat penugasan yang diubah scheduler (sebelum vs. sesudah) dengan actor system
+func auditScheduledRoleChange(tx *gorm.DB, action string, before models.RoleAssignment) error {
+	var after models.RoleAssignment
+	if err := tx.First(&after, before.ID).Error; err != nil {
+		return err
+	}
+	return middleware.RecordSystemAudit(tx, action, "role_assignment", before.ID, before, after)
+}
+
+// applyRoleAssignment mengaktifkan penugasan di dalam transaksi tx: mengakhiri jabatan lama user dan pemegang
+// jabatan yang sama, lalu memperbarui Users.Role dan profil kajur. Mengembalikan user yang sesinya perlu
+// dicabut setelah transaksi commit.
+func applyRoleAssignment(tx *gorm.DB, assignment models.RoleAssignment, now time.Time) ([]uint, error) {
+	var affected []uint
+
+	// Klaim penugasan secara bersyarat supaya scheduler dan request tidak menerapkannya dua kali
+	result := tx.Model(&models.RoleAssignment{}).
+		Where("id = ? AND status = ?", assignment.ID, "scheduled").
+		Updates(map[string]interface{}{"status": "active", "applied_at": now})
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	if result.RowsAffected == 0 {
+		return nil, errAssignmentNotPending
+	}
+
+	var user models.Users
+	if err := tx.Where("id = ?", assignment.UserID).First(&user).Error; err != nil {
+		return nil, err
+	}
+	var dosen models.Dosen
+	if err := tx.Where("id = ?", assignment.DosenID).First(&dosen).Error; err != nil {
+		return nil, err
+	}
+
+	// Jabatan aktif sebelumnya milik user ini berakhir karena digantikan
+	var previous []models.RoleAssignment
+	tx.Where("user_id = ? AND status = ? AND id <> ?", assignment.UserID, "active", assignment.ID).Find(&previous)
+	for _, p := range previous {
+		users, err := endRoleAssignment(tx, p, "ended", fmt.Sprintf("Digantikan penugasan #%d", assignment.ID), nil, now)
+		if err != nil {
+			return nil, err
+		}
+		affected = append(affected, users...)
+	}
+
+	// Pemegang jabatan yang sama di scope yang sama ikut berakhir
+	var holders []models.RoleAssignment
+	switch assignment.Role {
+	case "kajur":
+		tx.Where("role = ? AND jurusan = ? AND status = ? AND id <> ?", "kajur", assignment.Jurusan, "active", assignment.ID).Find(&holders)
+	case "dekan":
+		tx.Where("role = ? AND faculty = ? AND status = ? AND id <> ?", "dekan", assignment.Faculty, "active", assignment.ID).Find(&holders)
+	}
+	for _, h := range holders {
+		users, err := endRoleAssignment(tx, h, "ended", fmt.Sprintf("Digantikan penugasan #%d", assignment.ID), nil, now)
+		if err != nil {
+			return nil, err
+		}
+		affected = append(affected, users...)
+	}
+
+	if assignment.Role == "kajur" {
+		users, err := activateKajurProfile(tx, user, dosen, assignment.Jurusan)
+		if err != nil {
+			return nil, err
+		}
+		affected = append(affected, users...)
+	}
+
+	if err := tx.Model(&models.Users{}).Where("id = ?", user.ID).Update("role", assignment.Role).Error; err != nil {
+		return nil, err
+	}
+	affected = append(affected, user.ID)
+	return affected, nil
+}
+
+// endRoleAssignment mengakhiri penugasan aktif dan mengembalikan user ke role dosen
+func endRoleAssignment(tx *gorm.DB, assignment models.RoleAssignment, status, reason string, revokedBy *uint, now time.Time) ([]uint, error) {
+	result := tx.Model(&models.RoleAssignment{}).
+		Where("id = ? AND status = ?", assignment.ID, "active").
+		Updates(map[string]interface{}{
+			"status":     status,
+			"ended_at":   now,
+			"end_reason": reason,
+			"revoked_by": revokedBy,
+		})
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	if result.RowsAffected == 0 {
+		return nil, nil
+	}
+
+	if assignment.Role == "kajur" {
+		if err := tx.Model(&models.Kajur{}).Where("user_id = ?", assignment.UserID).Update("status", "nonaktif").Error; err != nil {
+			return nil, err
+		}
+	}
+
+	if assignment.Role != "dosen" {
+		if err := tx.Model(&models.Users{}).Where("id = ? AND role = ?", assignment.UserID, assignment.Role).
+			Update("role", "dosen").Error; err != nil {
+			return nil, err
+		}
+	}
+
+	return []uint{assignment.UserID}, nil
+}
+
+// activateKajurProfile memastikan user punya profil kajur aktif untuk jurusan tersebut
+// dan menonaktifkan profil kajur lain (termasuk data lama tanpa penugasan) di jurusan yang sama
+func activateKajurProfile(tx *gorm.DB, user models.Users, dosen models.Dosen, jurusan string) ([]uint, error) {
+	var others []models.Kajur
+	tx.Where("jurusan = ? AND status <> ? AND (user_id IS NULL OR user_id <> ?)", jurusan, "nonaktif", user.ID).Find(&others)
+
+	var affected []uint
+	for _, other := range others {
+		if err := tx.Model(&models.Kajur{}).Where("id = ?", other.ID).Update("status", "nonaktif").Error; err != nil {
+			return nil, err
+		}
+		if other.UserID != nil {
+			if err := tx.Model(&models.Users{}).Where("id = ? AND role = ?", *other.UserID, "kajur").Update("role", "dosen").Error; err != nil {
+				return nil, err
+			}
+			affected = append(affected, *other.UserID)
+		}
+	}
+
+	var kajur models.Kajur
+	err := tx.Where("user_id = ? OR nidn = ?", user.ID, dosen.NIDN).First(&kajur).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		kajur = models.Kajur{
+			UserID:  &user.ID,
+			NIDN:    dosen.NIDN,
+			Nama:    dosen.Nama,
+			Email:   dosen.Email,
+			Jurusan: jurusan,
+			Status:  "aktif",
+		}
+		return affected, tx.Create(&kajur).Error
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return affected, tx.Model(&kajur).Updates(map[string]interface{}{
+		"user_id": user.ID,
+		"jurusan": jurusan,
+		"status":  "aktif",
+	}).Error
+}
+
+// revokeSessionsAfterRoleChange memaksa login ulang supaya token membawa role yang baru
+func revokeSessionsAfterRoleChange(userIDs []uint) {
+	seen := map[uint]bool{}
+	for _, id := range userIDs {
+		if seen[id] {
+			continue
+		}
+		seen[id] = true
+		if err := middleware.RevokeUserSessions(id, "role_changed"); err != nil {
+			log.Printf("Failed to revoke sessions of user %d after role change: %v", id, err)
+		}
+	}
+}
@@ -0,0 +1,143 @@
+package controllers
+
+import (
+	"SIAku/models"
+	"SIAku/utils"
+	"errors"
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+// Helper deteksi bentrok jadwal untuk KRSController
+
+var errScheduleClash = errors.New("schedule clash")
+
+type jadwalSlot struct {
+	jadwal models.Jadwal
+	hari   string
+	start  int
+	end    int
+	ujian  bool
+}
+
+// toJadwalSlot mengubah baris jadwal menjadi rentang menit; ok=false untuk data lama yang jamnya tidak terbaca
+func toJadwalSlot(j models.Jadwal) (jadwalSlot, bool) {
+	hari, err := utils.NormalizeHari(j.Hari)
+	if err != nil {
+		return jadwalSlot{}, false
+	}
+	start, err := utils.ParseJam(j.JamMulai)
+	if err != nil {
+		return jadwalSlot{}, false
+	}
+	end, err := utils.ParseJam(j.JamSelesai)
+	if err != nil || end <= start {
+		return jadwalSlot{}, false
+	}
+	return jadwalSlot{jadwal: j, hari: hari, start: start, end: end, ujian: strings.EqualFold(j.TipeKelas, "ujian")}, true
+}
+
+// takenJadwal memuat jadwal KRS mahasiswa pada tahun ajaran: jadwal kelas jika KRS memilih kelas,
+// selain itu jadwal mata kuliah yang tidak terikat kelas
+func takenJadwal(db *gorm.DB, mahasiswaID uint, tahunAjaran string) ([]models.Jadwal, error) {
+	var krs []models.KRS
+	if err := db.Where("mahasiswa_id = ? AND tahun_ajaran = ? AND approval_status <> ?", mahasiswaID, tahunAjaran, models.KRSRejected).
+		Find(&krs).Error; err != nil {
+		return nil, err
+	}
+	if len(krs) == 0 {
+		return nil, nil
+	}
+
+	var kelasIDs, courseIDs []uint
+	for _, k := range krs {
+		if k.KelasID != nil {
+			kelasIDs = append(kelasIDs, *k.KelasID)
+		} else {
+			courseIDs = append(courseIDs, k.CourseID)
+		}
+	}
+
+	var jadwal []models.Jadwal
+	err := db.Preload("Course").
+		Where("tahun_ajaran = ? AND (kelas_id IN ? OR (kelas_id IS NULL AND course_id IN ?))", tahunAjaran, kelasIDs, courseIDs).
+		Find(&jadwal).Error
+	return jadwal, err
+}
+
+// findScheduleClashes membandingkan jadwal kandidat dengan jadwal KRS mahasiswa pada tahun ajaran yang sama.
+// Jadwal ujian hanya dibandingkan dengan jadwal ujian, kuliah/praktikum dengan kuliah/praktikum.
+func findScheduleClashes(db *gorm.DB, mahasiswaID uint, tahunAjaran string, candidates []models.Jadwal) ([]models.ScheduleClash, error) {
+	if len(candidates) == 0 {
+		return nil, nil
+	}
+
+	taken, err := takenJadwal(db, mahasiswaID, tahunAjaran)
+	if err != nil {
+		return nil, err
+	}
+	return clashesBetween(candidates, taken), nil
+}
+
+// clashesBetween membandingkan setiap jadwal kandidat dengan jadwal yang sudah diambil (mata kuliah yang sama dilewati)
+func clashesBetween(candidates, taken []models.Jadwal) []models.ScheduleClash {
+	var takenSlots []jadwalSlot
+	for _, j := range taken {
+		if slot, ok := toJadwalSlot(j); ok {
+			takenSlots = append(takenSlots, slot)
+		}
+	}
+
+	var clashes []models.ScheduleClash
+	for _, candidate := range candidates {
+		cs, ok := toJadwalSlot(candidate)
+		if !ok {
+			continue
+		}
+		for _, ts := range takenSlots {
+			if cs.jadwal.CourseID == ts.jadwal.CourseID || cs.hari != ts.hari || cs.ujian != ts.ujian {
+				continue
+			}
+			if !utils.JamOverlap(cs.start, cs.end, ts.start, ts.end) {
+				continue
+			}
+			clashes = append(clashes, models.ScheduleClash{
+				CourseID:        cs.jadwal.CourseID,
+				KelasID:         cs.jadwal.KelasID,
+				Hari:            cs.hari,
+				JamMulai:        cs.jadwal.JamMulai,
+				JamSelesai:      cs.jadwal.JamSelesai,
+				TipeKelas:       cs.jadwal.TipeKelas,
+				ClashCourseID:   ts.jadwal.CourseID,
+				ClashCourseCode: ts.jadwal.Course.Code,
+				ClashCourseName: ts.jadwal.Course.Name,
+				ClashJamMulai:   ts.jadwal.JamMulai,
+				ClashJamSelesai: ts.jadwal.JamSelesai,
+			})
+		}
+	}
+	return clashes
+}
+
+// candidateJadwal memuat jadwal yang akan diikuti jika mahasiswa mengambil mata kuliah (atau kelasnya)
+func candidateJadwal(db *gorm.DB, courseID uint, kelasID *uint, tahunAjaran string) ([]models.Jadwal, error) {
+	var jadwal []models.Jadwal
+	query := db.Where("tahun_ajaran = ?", tahunAjaran)
+	if kelasID != nil {
+		query = query.Where("kelas_id = ?", *kelasID)
+	} else {
+		query = query.Where("course_id = ? AND kelas_id IS NULL", courseID)
+	}
+	err := query.Find(&jadwal).Error
+	return jadwal, err
+}
+
+// describeScheduleClashes menyusun pesan singkat seperti "IF201 (senin 08:00-10:00)"
+func describeScheduleClashes(clashes []models.ScheduleClash) string {
+	parts := make([]string, 0, len(clashes))
+	for _, clash := range clashes {
+		parts = append(parts, clash.ClashCourseCode+" ("+clash.Hari+" "+clash.ClashJamMulai+"-"+clash.ClashJamSelesai+")")
+	}
+	return strings.Join(parts, ", ")
+}
@@ -0,0 +1,44 @@
+package controllers
+
+import (
+	"SIAku/models"
+	"testing"
+)
+
+func TestClashesBetween(t *testing.T) {
+	jadwal := func(courseID uint, hari, mulai, selesai, tipe string) models.Jadwal {
+		return models.Jadwal{CourseID: courseID, Hari: hari, JamMulai: mulai, JamSelesai: selesai, TipeKelas: tipe}
+	}
+	taken := []models.Jadwal{
+		jadwal(1, "senin", "08:00", "10:00", "kuliah"),
+		jadwal(2, "rabu", "13:00", "15:00", "ujian"),
+	}
+
+	tests := []struct {
+		name      string
+		candidate models.Jadwal
+		want      int
+	}{
+		{name: "bersentuhan sesudah", candidate: jadwal(3, "senin", "10:00", "12:00", "kuliah"), want: 0},
+		{name: "bersentuhan sebelum", candidate: jadwal(3, "senin", "06:30", "08:00", "kuliah"), want: 0},
+		{name: "beririsan", candidate: jadwal(3, "senin", "09:59", "11:00", "praktikum"), want: 1},
+		{name: "format jam lama", candidate: jadwal(3, "Senin", "9.00", "11.00", "kuliah"), want: 1},
+		{name: "hari berbeda", candidate: jadwal(3, "selasa", "08:00", "10:00", "kuliah"), want: 0},
+		{name: "mata kuliah yang sama", candidate: jadwal(1, "senin", "08:00", "10:00", "kuliah"), want: 0},
+		{name: "ujian vs kuliah", candidate: jadwal(3, "senin", "08:00", "10:00", "ujian"), want: 0},
+		{name: "ujian vs ujian", candidate: jadwal(3, "rabu", "14:00", "16:00", "Ujian"), want: 1},
+		{name: "jam tidak terbaca", candidate: jadwal(3, "senin", "pagi", "10:00", "kuliah"), want: 0},
+		{name: "jam selesai sebelum mulai", candidate: jadwal(3, "senin", "09:00", "08:30", "kuliah"), want: 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clashes := clashesBetween([]models.Jadwal{tt.candidate}, taken)
+			if len(clashes) != tt.want {
+				t.Fatalf("got %d clashes, want %d: %+v", len(clashes), tt.want, clashes)
+			}
+			if tt.want > 0 && clashes[0].ClashCourseID == tt.candidate.CourseID {
+				t.Errorf("clash reported against the candidate itself: %+v", clashes[0])
+			}
+		})
+	}
+}
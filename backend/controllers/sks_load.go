@@ -0,0 +1,113 @@
+package controllers
+
+import (
+	"SIAku/config"
+	"SIAku/models"
+	"errors"
+	"math"
+
+	"gorm.io/gorm"
+)
+
+// Helper batas beban SKS untuk KRSController
+
+var errSKSLimitExceeded = errors.New("sks load limit exceeded")
+
+// semesterIPS menghitung IPS mahasiswa pada semester tertentu; ok=false jika belum ada nilai
+func semesterIPS(db *gorm.DB, mahasiswaID uint, semester int) (float64, bool, error) {
+	var result struct {
+		Points  float64
+		Credits int
+	}
+
+	err := db.Table("nilais").
+		Select("COALESCE(SUM(nilais.grade_point * courses.credits), 0) AS points, COALESCE(SUM(courses.credits), 0) AS credits").
+		Joins("JOIN courses ON courses.id = nilais.course_id").
+		Where("nilais.mahasiswa_id = ? AND nilais.semester = ? AND nilais.status = ?", mahasiswaID, semester, models.NilaiPublished).
+		Scan(&result).Error
+	if err != nil || result.Credits == 0 {
+		return 0, false, err
+	}
+
+	return math.Round(result.Points/float64(result.Credits)*100) / 100, true, nil
+}
+
+// maxSKSForIPS memilih aturan jurusan (atau aturan default) dengan min_ips tertinggi yang masih terpenuhi
+func maxSKSForIPS(db *gorm.DB, jurusan string, ips float64) (int, error) {
+	var rules []models.SKSLoadRule
+	if err := db.Where("jurusan = ?", jurusan).Order("min_ips DESC").Find(&rules).Error; err != nil {
+		return 0, err
+	}
+	if len(rules) == 0 {
+		if err := db.Where("jurusan = ?", "").Order("min_ips DESC").Find(&rules).Error; err != nil {
+			return 0, err
+		}
+	}
+	if len(rules) == 0 {
+		rules = models.DefaultSKSLoadRules
+	}
+	return maxSKSFromRules(rules, ips), nil
+}
+
+// maxSKSFromRules mengambil max_sks terbesar dari aturan yang min_ips-nya terpenuhi (0 jika tidak ada)
+func maxSKSFromRules(rules []models.SKSLoadRule, ips float64) int {
+	maxSKS := 0
+	for _, rule := range rules {
+		if ips >= rule.MinIPS && rule.MaxSKS > maxSKS {
+			maxSKS = rule.MaxSKS
+		}
+	}
+	return maxSKS
+}
+
+// krsCredits menjumlahkan SKS KRS mahasiswa pada semester itu (KRS yang ditolak tidak dihitung)
+func krsCredits(db *gorm.DB, mahasiswaID uint, semester int, tahunAjaran string) (int, error) {
+	var total int
+	err := db.Table("krs").
+		Select("COALESCE(SUM(courses.credits), 0)").
+		Joins("JOIN courses ON courses.id = krs.course_id").
+		Where("krs.mahasiswa_id = ? AND krs.semester = ? AND krs.tahun_ajaran = ? AND krs.approval_status <> ?",
+			mahasiswaID, semester, tahunAjaran, models.KRSRejected).
+		Scan(&total).Error
+	return total, err
+}
+
+// sksQuota menghitung batas, total dan sisa SKS mahasiswa untuk satu semester
+func sksQuota(db *gorm.DB, mahasiswa models.Mahasiswa, semester int, tahunAjaran string) (models.SKSQuota, error) {
+	quota := models.SKSQuota{Semester: semester, TahunAjaran: tahunAjaran}
+
+	ips, ok, err := semesterIPS(db, mahasiswa.ID, semester-1)
+	if err != nil {
+		return quota, err
+	}
+
+	if ok {
+		quota.IPSSebelumnya = &ips
+		if quota.MaxSKS, err = maxSKSForIPS(db, mahasiswa.Jurusan, ips); err != nil {
+			return quota, err
+		}
+	} else {
+		// Semester pertama (atau belum ada nilai) memakai paket SKS awal
+		quota.MaxSKS = config.AppConfig.FirstSemesterSKS
+	}
+
+	if quota.TotalSKS, err = krsCredits(db, mahasiswa.ID, semester, tahunAjaran); err != nil {
+		return quota, err
+	}
+
+	quota.SisaSKS = remainingSKS(quota.MaxSKS, quota.TotalSKS)
+	return quota, nil
+}
+
+// remainingSKS menghitung sisa SKS; tidak pernah negatif walau batas turun setelah KRS diisi
+func remainingSKS(maxSKS, totalSKS int) int {
+	if totalSKS >= maxSKS {
+		return 0
+	}
+	return maxSKS - totalSKS
+}
+
+// exceedsSKSQuota mengecek apakah mata kuliah dengan credits SKS melampaui batas semester
+func exceedsSKSQuota(quota models.SKSQuota, credits int) bool {
+	return quota.TotalSKS+credits > quota.MaxSKS
+}
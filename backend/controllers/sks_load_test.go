@@ -0,0 +1,66 @@
+package controllers
+
+import (
+	"SIAku/models"
+	"testing"
+)
+
+func TestMaxSKSFromRules(t *testing.T) {
+	jurusanRules := []models.SKSLoadRule{
+		{MinIPS: 0, MaxSKS: 15},
+		{MinIPS: 3.5, MaxSKS: 24},
+		{MinIPS: 2.75, MaxSKS: 20},
+	}
+
+	tests := []struct {
+		name  string
+		rules []models.SKSLoadRule
+		ips   float64
+		want  int
+	}{
+		{name: "default IPS 0", rules: models.DefaultSKSLoadRules, ips: 0, want: 18},
+		{name: "default tepat di bawah 2.5", rules: models.DefaultSKSLoadRules, ips: 2.49, want: 18},
+		{name: "default tepat 2.5", rules: models.DefaultSKSLoadRules, ips: 2.5, want: 21},
+		{name: "default tepat di bawah 3.0", rules: models.DefaultSKSLoadRules, ips: 2.99, want: 21},
+		{name: "default tepat 3.0", rules: models.DefaultSKSLoadRules, ips: 3.0, want: 24},
+		{name: "default IPS 4", rules: models.DefaultSKSLoadRules, ips: 4, want: 24},
+		{name: "aturan jurusan tidak urut", rules: jurusanRules, ips: 2.75, want: 20},
+		{name: "aturan jurusan tertinggi", rules: jurusanRules, ips: 3.5, want: 24},
+		{name: "tidak ada aturan terpenuhi", rules: []models.SKSLoadRule{{MinIPS: 2, MaxSKS: 20}}, ips: 1.99, want: 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := maxSKSFromRules(tt.rules, tt.ips); got != tt.want {
+				t.Errorf("maxSKSFromRules(%v) = %d, want %d", tt.ips, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSKSQuotaBoundaries(t *testing.T) {
+	tests := []struct {
+		name       string
+		maxSKS     int
+		totalSKS   int
+		credits    int
+		wantSisa   int
+		wantExceed bool
+	}{
+		{name: "kosong", maxSKS: 20, totalSKS: 0, credits: 3, wantSisa: 20},
+		{name: "pas mencapai batas", maxSKS: 20, totalSKS: 17, credits: 3, wantSisa: 3},
+		{name: "lebih satu SKS", maxSKS: 20, totalSKS: 18, credits: 3, wantSisa: 2, wantExceed: true},
+		{name: "sudah penuh", maxSKS: 20, totalSKS: 20, credits: 1, wantSisa: 0, wantExceed: true},
+		{name: "batas turun di bawah total", maxSKS: 18, totalSKS: 21, credits: 2, wantSisa: 0, wantExceed: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			quota := models.SKSQuota{MaxSKS: tt.maxSKS, TotalSKS: tt.totalSKS}
+			if got := remainingSKS(tt.maxSKS, tt.totalSKS); got != tt.wantSisa {
+				t.Errorf("remainingSKS = %d, want %d", got, tt.wantSisa)
+			}
+			if got := exceedsSKSQuota(quota, tt.credits); got != tt.wantExceed {
+				t.Errorf("exceedsSKSQuota = %v, want %v", got, tt.wantExceed)
+			}
+		})
+	}
+}
@@ -0,0 +1,120 @@
+package controllers
+
+import (
+	"SIAku/config"
+	"SIAku/middleware"
+	"SIAku/models"
+	"SIAku/utils"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+type SKSRuleController struct{}
+
+func NewSKSRuleController() *SKSRuleController {
+	return &SKSRuleController{}
+}
+
+// GetRules - Aturan batas SKS jurusan beserta aturan default (kajur hanya jurusannya)
+func (sc *SKSRuleController) GetRules(c *gin.Context) {
+	principal := middleware.GetPrincipal(c)
+	jurusan := c.Query("jurusan")
+	if principal.Role == "kajur" {
+		jurusan = principal.Jurusan
+	}
+
+	var rules []models.SKSLoadRule
+	query := config.DB.Order("jurusan ASC, min_ips DESC")
+	if jurusan != "" {
+		query = query.Where("jurusan IN ?", []string{jurusan, ""})
+	}
+	if err := query.Find(&rules).Error; err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to fetch SKS rules")
+		return
+	}
+
+	utils.SuccessResponse(c, gin.H{
+		"rules":             rules,
+		"built_in_defaults": models.DefaultSKSLoadRules,
+		"first_semester":    config.AppConfig.FirstSemesterSKS,
+	})
+}
+
+// SetRules - Ganti seluruh tabel batas SKS sebuah jurusan (rektor juga bisa mengatur aturan default)
+func (sc *SKSRuleController) SetRules(c *gin.Context) {
+	var req models.SetSKSRulesRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid JSON format")
+		return
+	}
+
+	if err := utils.ValidateStruct(req); err != nil {
+		utils.HandleValidationError(c, err)
+		return
+	}
+
+	principal := middleware.GetPrincipal(c)
+	jurusan := req.Jurusan
+	if principal.Role == "kajur" {
+		if jurusan != "" && jurusan != principal.Jurusan {
+			utils.ErrorResponse(c, http.StatusForbidden, "Kajur hanya dapat mengatur batas SKS jurusannya")
+			return
+		}
+		jurusan = principal.Jurusan
+	}
+
+	// Harus ada aturan min_ips 0 supaya setiap IPS mendapat batas
+	seen := map[float64]bool{}
+	hasBase := false
+	for _, rule := range req.Rules {
+		if seen[rule.MinIPS] {
+			utils.ErrorResponse(c, http.StatusBadRequest, "Duplicate min_ips in rules")
+			return
+		}
+		seen[rule.MinIPS] = true
+		if rule.MinIPS == 0 {
+			hasBase = true
+		}
+	}
+	if !hasBase {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Rules must include min_ips 0")
+		return
+	}
+
+	err := config.DB.Transaction(func(tx *gorm.DB) error {
+		var before []models.SKSLoadRule
+		tx.Where("jurusan = ?", jurusan).Order("min_ips DESC").Find(&before)
+
+		if err := tx.Where("jurusan = ?", jurusan).Delete(&models.SKSLoadRule{}).Error; err != nil {
+			return err
+		}
+		for _, rule := range req.Rules {
+			if err := tx.Create(&models.SKSLoadRule{
+				Jurusan: jurusan,
+				MinIPS:  rule.MinIPS,
+				MaxSKS:  rule.MaxSKS,
+			}).Error; err != nil {
+				return err
+			}
+		}
+
+		previous := make([]models.SKSRuleItem, 0, len(before))
+		for _, rule := range before {
+			previous = append(previous, models.SKSRuleItem{MinIPS: rule.MinIPS, MaxSKS: rule.MaxSKS})
+		}
+		return middleware.RecordAudit(c, tx, "set_sks_rules", "sks_rule", 0,
+			gin.H{"jurusan": jurusan, "rules": previous}, gin.H{"jurusan": jurusan, "rules": req.Rules})
+	})
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to save SKS rules")
+		return
+	}
+
+	utils.SuccessResponse(c, gin.H{
+		"message": "SKS rules updated",
+		"jurusan": jurusan,
+		"rules":   req.Rules,
+	})
+}
@@ -0,0 +1,379 @@
+package controllers
+
+import (
+	"SIAku/config"
+	"SIAku/middleware"
+	"SIAku/models"
+	"SIAku/utils"
+	"crypto/rand"
+	"encoding/hex"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
+)
+
+const (
+	twoFactorIssuer        = "SIAku"
+	twoFactorRecoveryCount = 10
+)
+
+// Role staf yang boleh mendaftarkan 2FA
+var twoFactorStaffRoles = map[string]bool{"dosen": true, "kajur": true, "rektor": true}
+
+type TwoFactorController struct {
+	auth *AuthController
+}
+
+func NewTwoFactorController() *TwoFactorController {
+	return &TwoFactorController{auth: NewAuthController()}
+}
+
+// VerifyLogin - Langkah kedua login: tukar challenge token + kode TOTP/recovery dengan token akses
+func (tc *TwoFactorController) VerifyLogin(c *gin.Context) {
+	var req models.TwoFactorLoginRequest
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid JSON format")
+		return
+	}
+
+	if err := utils.ValidateStruct(req); err != nil {
+		utils.HandleValidationError(c, err)
+		return
+	}
+
+	claims, err := middleware.ParseTwoFactorChallenge(req.ChallengeToken)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusUnauthorized, "Challenge token tidak valid atau sudah kedaluwarsa, silakan login ulang")
+		return
+	}
+
+	var user models.Users
+	if err := config.DB.Where("id = ?", claims.UserID).First(&user).Error; err != nil || !user.TwoFactorEnabled {
+		utils.ErrorResponse(c, http.StatusUnauthorized, "Challenge token tidak valid atau sudah kedaluwarsa, silakan login ulang")
+		return
+	}
+
+	clientIP := c.ClientIP()
+
+	if user.LockedUntil != nil && user.LockedUntil.After(time.Now()) {
+		c.JSON(http.StatusLocked, gin.H{
+			"success":      false,
+			"error":        "Akun terkunci sementara karena terlalu banyak percobaan login gagal",
+			"locked_until": user.LockedUntil,
+		})
+		return
+	}
+
+	var verified bool
+	if req.RecoveryCode != "" {
+		verified = useRecoveryCode(user.ID, req.RecoveryCode)
+	} else {
+		verified = consumeTOTP(user, req.Code)
+	}
+
+	if !verified {
+		recordLoginAttempt(strings.ToLower(user.Username), clientIP, &user.ID, false)
+		if lockedUntil := registerFailedLogin(&user, clientIP); lockedUntil != nil {
+			c.JSON(http.StatusLocked, gin.H{
+				"success":      false,
+				"error":        "Akun terkunci sementara karena terlalu banyak percobaan login gagal",
+				"locked_until": lockedUntil,
+			})
+			return
+		}
+		utils.ErrorResponse(c, http.StatusUnauthorized, "Kode verifikasi salah")
+		return
+	}
+
+	if user.Status != "aktif" {
+		utils.ErrorResponse(c, http.StatusForbidden, "Akun tidak aktif, silakan hubungi admin")
+		return
+	}
+
+	tokens, err := tc.auth.issueTokens(c, user, claims.DeviceName)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to generate token")
+		return
+	}
+
+	// Counter gagal hanya direset setelah login lengkap (password + kode) berhasil
+	recordLoginAttempt(strings.ToLower(user.Username), clientIP, &user.ID, true)
+	resetFailedLogins(user)
+
+	c.JSON(http.StatusOK, gin.H{
+		"success":       true,
+		"message":       "Login successful",
+		"data":          tc.auth.buildUserResponse(user),
+		"token":         tokens.AccessToken,
+		"refresh_token": tokens.RefreshToken,
+		"expires_in":    tokens.ExpiresIn,
+	})
+}
+
+// Setup - Buat secret TOTP baru (belum aktif sampai dikonfirmasi lewat Enable)
+func (tc *TwoFactorController) Setup(c *gin.Context) {
+	principal := middleware.GetPrincipal(c)
+	if !twoFactorStaffRoles[principal.Role] {
+		utils.ErrorResponse(c, http.StatusForbidden, "2FA hanya tersedia untuk akun dosen, kajur dan rektor")
+		return
+	}
+
+	var user models.Users
+	if err := config.DB.Where("id = ?", principal.UserID).First(&user).Error; err != nil {
+		utils.ErrorResponse(c, http.StatusNotFound, "User not found")
+		return
+	}
+
+	if user.TwoFactorEnabled {
+		utils.ErrorResponse(c, http.StatusConflict, "2FA sudah aktif, nonaktifkan dulu untuk mendaftarkan perangkat baru")
+		return
+	}
+
+	secret, err := utils.GenerateTOTPSecret()
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to generate secret")
+		return
+	}
+
+	if err := config.DB.Model(&user).Updates(map[string]interface{}{
+		"two_factor_secret":    secret,
+		"two_factor_last_step": 0,
+	}).Error; err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to save secret")
+		return
+	}
+
+	uri := utils.TOTPProvisioningURI(twoFactorIssuer, user.Username, secret)
+	qrCode, err := utils.QRCodeDataURL(uri)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to generate QR code")
+		return
+	}
+
+	utils.SuccessResponse(c, models.TwoFactorSetupResponse{
+		Secret:          secret,
+		ProvisioningURI: uri,
+		QRCode:          qrCode,
+	})
+}
+
+// Enable - Konfirmasi kode pertama dari authenticator lalu aktifkan 2FA
+func (tc *TwoFactorController) Enable(c *gin.Context) {
+	var req models.TwoFactorCodeRequest
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid JSON format")
+		return
+	}
+
+	if err := utils.ValidateStruct(req); err != nil {
+		utils.HandleValidationError(c, err)
+		return
+	}
+
+	var user models.Users
+	if err := config.DB.Where("id = ?", middleware.GetPrincipal(c).UserID).First(&user).Error; err != nil {
+		utils.ErrorResponse(c, http.StatusNotFound, "User not found")
+		return
+	}
+
+	if user.TwoFactorEnabled {
+		utils.ErrorResponse(c, http.StatusConflict, "2FA sudah aktif")
+		return
+	}
+	if user.TwoFactorSecret == "" {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Jalankan setup 2FA terlebih dahulu")
+		return
+	}
+
+	if !consumeTOTP(user, req.Code) {
+		utils.ErrorResponse(c, http.StatusUnauthorized, "Kode verifikasi salah")
+		return
+	}
+
+	var codes []string
+	err := config.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&models.Users{}).Where("id = ?", user.ID).Update("two_factor_enabled", true).Error; err != nil {
+			return err
+		}
+		var err error
+		codes, err = replaceRecoveryCodes(tx, user.ID)
+		return err
+	})
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to enable 2FA")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "2FA berhasil diaktifkan. Simpan recovery code berikut, kode hanya ditampilkan sekali",
+		"data": gin.H{
+			"recovery_codes": codes,
+		},
+	})
+}
+
+// Disable - Nonaktifkan 2FA (butuh password dan kode TOTP, ditolak jika role wajib 2FA)
+func (tc *TwoFactorController) Disable(c *gin.Context) {
+	var req models.TwoFactorDisableRequest
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid JSON format")
+		return
+	}
+
+	if err := utils.ValidateStruct(req); err != nil {
+		utils.HandleValidationError(c, err)
+		return
+	}
+
+	var user models.Users
+	if err := config.DB.Where("id = ?", middleware.GetPrincipal(c).UserID).First(&user).Error; err != nil {
+		utils.ErrorResponse(c, http.StatusNotFound, "User not found")
+		return
+	}
+
+	if !user.TwoFactorEnabled {
+		utils.ErrorResponse(c, http.StatusBadRequest, "2FA belum aktif")
+		return
+	}
+
+	if middleware.TwoFactorRequired(user.Role) {
+		utils.ErrorResponse(c, http.StatusForbidden, "2FA wajib untuk role "+user.Role+" dan tidak dapat dinonaktifkan")
+		return
+	}
+
+	if err := bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(req.Password)); err != nil {
+		utils.ErrorResponse(c, http.StatusUnauthorized, "Password salah")
+		return
+	}
+
+	if !consumeTOTP(user, req.Code) {
+		utils.ErrorResponse(c, http.StatusUnauthorized, "Kode verifikasi salah")
+		return
+	}
+
+	err := config.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&models.Users{}).Where("id = ?", user.ID).Updates(map[string]interface{}{
+			"two_factor_enabled":   false,
+			"two_factor_secret":    "",
+			"two_factor_last_step": 0,
+		}).Error; err != nil {
+			return err
+		}
+		return tx.Where("user_id = ?", user.ID).Delete(&models.TwoFactorRecoveryCode{}).Error
+	})
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to disable 2FA")
+		return
+	}
+
+	utils.SuccessResponse(c, gin.H{"message": "2FA berhasil dinonaktifkan"})
+}
+
+// RegenerateRecoveryCodes - Ganti semua recovery code lama dengan set baru
+func (tc *TwoFactorController) RegenerateRecoveryCodes(c *gin.Context) {
+	var req models.TwoFactorCodeRequest
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid JSON format")
+		return
+	}
+
+	if err := utils.ValidateStruct(req); err != nil {
+		utils.HandleValidationError(c, err)
+		return
+	}
+
+	var user models.Users
+	if err := config.DB.Where("id = ?", middleware.GetPrincipal(c).UserID).First(&user).Error; err != nil {
+		utils.ErrorResponse(c, http.StatusNotFound, "User not found")
+		return
+	}
+
+	if !user.TwoFactorEnabled {
+		utils.ErrorResponse(c, http.StatusBadRequest, "2FA belum aktif")
+		return
+	}
+
+	if !consumeTOTP(user, req.Code) {
+		utils.ErrorResponse(c, http.StatusUnauthorized, "Kode verifikasi salah")
+		return
+	}
+
+	var codes []string
+	err := config.DB.Transaction(func(tx *gorm.DB) error {
+		var err error
+		codes, err = replaceRecoveryCodes(tx, user.ID)
+		return err
+	})
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to generate recovery codes")
+		return
+	}
+
+	utils.SuccessResponse(c, gin.H{"recovery_codes": codes})
+}
+
+// freshTOTPStep memvalidasi kode dan menolak step yang tidak lebih baru dari step terakhir yang dipakai
+func freshTOTPStep(user models.Users, code string, now time.Time) (int64, bool) {
+	step, ok := utils.ValidateTOTP(user.TwoFactorSecret, code, now)
+	if !ok || step <= user.TwoFactorLastStep {
+		return 0, false
+	}
+	return step, true
+}
+
+// consumeTOTP memvalidasi kode dan mencatat step-nya supaya kode yang sama tidak bisa dipakai ulang
+func consumeTOTP(user models.Users, code string) bool {
+	step, ok := freshTOTPStep(user, code, time.Now())
+	if !ok {
+		return false
+	}
+
+	result := config.DB.Model(&models.Users{}).
+		Where("id = ? AND two_factor_last_step < ?", user.ID, step).
+		Update("two_factor_last_step", step)
+	return result.Error == nil && result.RowsAffected > 0
+}
+
+// useRecoveryCode menandai recovery code sebagai terpakai, hanya berhasil sekali
+func useRecoveryCode(userID uint, code string) bool {
+	normalized := strings.ToLower(strings.ReplaceAll(strings.TrimSpace(code), "-", ""))
+	result := config.DB.Model(&models.TwoFactorRecoveryCode{}).
+		Where("user_id = ? AND code_hash = ? AND used_at IS NULL", userID, middleware.HashToken(normalized)).
+		Update("used_at", time.Now())
+	return result.Error == nil && result.RowsAffected > 0
+}
+
+// replaceRecoveryCodes menghapus recovery code lama dan membuat set baru; kode mentah hanya dikembalikan sekali
+func replaceRecoveryCodes(tx *gorm.DB, userID uint) ([]string, error) {
+	if err := tx.Where("user_id = ?", userID).Delete(&models.TwoFactorRecoveryCode{}).Error; err != nil {
+		return nil, err
+	}
+
+	codes := make([]string, 0, twoFactorRecoveryCount)
+	for i := 0; i < twoFactorRecoveryCount; i++ {
+		b := make([]byte, 5)
+		if _, err := rand.Read(b); err != nil {
+			return nil, err
+		}
+		raw := hex.EncodeToString(b)
+
+		if err := tx.Create(&models.TwoFactorRecoveryCode{
+			UserID:   userID,
+			CodeHash: middleware.HashToken(raw),
+		}).Error; err != nil {
+			return nil, err
+		}
+		codes = append(codes, raw[:5]+"-"+raw[5:])
+	}
+
+	return codes, nil
+}
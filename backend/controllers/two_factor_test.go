@@ -0,0 +1,44 @@
+package controllers
+
+import (
+	"SIAku/models"
+	"SIAku/utils"
+	"testing"
+	"time"
+)
+
+func TestFreshTOTPStepRejectsReplay(t *testing.T) {
+	secret, err := utils.GenerateTOTPSecret()
+	if err != nil {
+		t.Fatal(err)
+	}
+	now := time.Unix(1700000000, 0)
+	current := now.Unix() / 30
+	code, err := utils.TOTPCode(secret, current)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name     string
+		lastStep int64
+		wantOK   bool
+	}{
+		{name: "belum pernah dipakai", lastStep: 0, wantOK: true},
+		{name: "step sebelumnya sudah dipakai", lastStep: current - 1, wantOK: true},
+		{name: "step yang sama dipakai ulang", lastStep: current},
+		{name: "step lebih baru sudah dipakai", lastStep: current + 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			user := models.Users{TwoFactorSecret: secret, TwoFactorLastStep: tt.lastStep}
+			step, ok := freshTOTPStep(user, code, now)
+			if ok != tt.wantOK {
+				t.Fatalf("ok = %v, want %v", ok, tt.wantOK)
+			}
+			if ok && step != current {
+				t.Errorf("step = %d, want %d", step, current)
+			}
+		})
+	}
+}
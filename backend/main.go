@@ -2,6 +2,7 @@ package main
 
 import (
 	"SIAku/config"
+	"SIAku/controllers"
 	"SIAku/middleware"
 	"SIAku/models"
 	"SIAku/routes"
@@ -28,16 +29,39 @@ func main() {
 	}
 
 	// Users table migration
-	if err := db.AutoMigrate(&models.Users{}); err != nil {
+	if err := db.AutoMigrate(&models.Users{}, &models.UserSession{}, &models.LoginAttempt{}, &models.AccountLockoutEvent{}, &models.PasswordReset{}, &models.TwoFactorRecoveryCode{}, &models.StaffInvitation{}, &models.NIMWhitelist{}, &models.AuditLog{}, &models.RoleAssignment{}, &models.Policy{}, &models.PolicyRevision{}, &models.PolicyAttachment{}, &models.PolicyReview{}, &models.CoursePrerequisite{}, &models.SKSLoadRule{}, &models.Kelas{}, &models.KelasWaitlist{}, &models.AcademicCalendar{}, &models.KRSWindowOverride{}, &models.KRSApprovalHistory{}, &models.GradingComponent{}, &models.NilaiKomponen{}, &models.GradeScale{}, &models.GradeScaleItem{}, &models.GradeSheet{}, &models.GradeCorrection{}, &models.GradeAppeal{}, &models.GradeAppealAttachment{}); err != nil {
 		log.Fatalf("Users table migration failed: %v", err)
 	}
 
+	if err := config.LinkProfilesToUsers(db); err != nil {
+		log.Fatalf("Linking profiles to users failed: %v", err)
+	}
+
+	if err := config.MigrateKRSApprovalStatus(db); err != nil {
+		log.Fatalf("KRS approval status migration failed: %v", err)
+	}
+
+	if err := config.MigrateNilaiAttempts(db); err != nil {
+		log.Fatalf("Nilai attempt migration failed: %v", err)
+	}
+
+	if err := config.SeedDefaultGradeScale(db); err != nil {
+		log.Fatalf("Grade scale seeding failed: %v", err)
+	}
+
+	if err := config.MigrateGradeSheets(db); err != nil {
+		log.Fatalf("Grade sheet migration failed: %v", err)
+	}
+
+	controllers.StartRoleAssignmentScheduler(config.AppConfig.RoleSchedulerEvery)
+
 	if os.Getenv("GIN_MODE") == "" {
 		gin.SetMode(gin.ReleaseMode)
 	}
 
 	r := gin.New()
 
+	r.Use(middleware.RequestID())
 	r.Use(middleware.Logger())
 	r.Use(middleware.Recovery())
 	r.Use(middleware.CORS())
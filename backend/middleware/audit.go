@@ -0,0 +1,105 @@
+package middleware
+
+import (
+	"SIAku/config"
+	"SIAku/models"
+	"encoding/json"
+	"reflect"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// Field yang tidak ikut dicatat di diff audit
+var auditIgnoredFields = map[string]bool{"created_at": true, "updated_at": true}
+
+// RecordAudit mencatat perubahan entity ke audit_logs. before/after berupa struct model (nil untuk create/delete);
+// yang disimpan hanya field yang berubah. Jika tx diisi, log ikut transaksi pemanggil.
+func RecordAudit(c *gin.Context, tx *gorm.DB, action, entity string, entityID uint, before, after interface{}) error {
+	beforeMap, afterMap := auditDiff(toAuditMap(before), toAuditMap(after))
+	if before != nil && after != nil && len(afterMap) == 0 {
+		return nil
+	}
+
+	principal := GetPrincipal(c)
+	entry := models.AuditLog{
+		ActorRole: principal.Role,
+		Jurusan:   principal.Jurusan,
+		Action:    action,
+		Entity:    entity,
+		EntityID:  entityID,
+		Before:    beforeMap,
+		After:     afterMap,
+		IPAddress: c.ClientIP(),
+		RequestID: c.GetString("request_id"),
+	}
+	if principal.UserID != 0 {
+		entry.ActorID = &principal.UserID
+	}
+
+	if tx == nil {
+		tx = config.DB
+	}
+	return tx.Create(&entry).Error
+}
+
+// RecordSystemAudit mencatat perubahan yang dilakukan proses latar (scheduler) tanpa request: actor_role "system"
+// tanpa actor_id. Log ikut transaksi tx.
+func RecordSystemAudit(tx *gorm.DB, action, entity string, entityID uint, before, after interface{}) error {
+	beforeMap, afterMap := auditDiff(toAuditMap(before), toAuditMap(after))
+	if before != nil && after != nil && len(afterMap) == 0 {
+		return nil
+	}
+
+	return tx.Create(&models.AuditLog{
+		ActorRole: "system",
+		Action:    action,
+		Entity:    entity,
+		EntityID:  entityID,
+		Before:    beforeMap,
+		After:     afterMap,
+	}).Error
+}
+
+// toAuditMap mengubah struct model menjadi map lewat tag json, relasi (objek bersarang) dilewati
+func toAuditMap(value interface{}) models.JSONMap {
+	if value == nil || (reflect.ValueOf(value).Kind() == reflect.Ptr && reflect.ValueOf(value).IsNil()) {
+		return nil
+	}
+
+	b, err := json.Marshal(value)
+	if err != nil {
+		return nil
+	}
+
+	var raw map[string]interface{}
+	if err := json.Unmarshal(b, &raw); err != nil {
+		return nil
+	}
+
+	result := models.JSONMap{}
+	for key, v := range raw {
+		if _, nested := v.(map[string]interface{}); nested || auditIgnoredFields[key] {
+			continue
+		}
+		result[key] = v
+	}
+	return result
+}
+
+// auditDiff menyisakan field yang berbeda antara before dan after
+func auditDiff(before, after models.JSONMap) (models.JSONMap, models.JSONMap) {
+	if before == nil || after == nil {
+		return before, after
+	}
+
+	changedBefore := models.JSONMap{}
+	changedAfter := models.JSONMap{}
+	for key, newValue := range after {
+		if oldValue, ok := before[key]; !ok || !reflect.DeepEqual(oldValue, newValue) {
+			changedBefore[key] = before[key]
+			changedAfter[key] = newValue
+		}
+	}
+	return changedBefore, changedAfter
+}
@@ -12,18 +12,22 @@ import (
 )
 
 type Claims struct {
-	UserID uint   `json:"user_id"`
-	NIM    string `json:"nim"`
+	UserID    uint   `json:"user_id"`
+	NIM       string `json:"nim"`
+	Role      string `json:"role"`
+	SessionID uint   `json:"sid"`
 	jwt.RegisteredClaims
 }
 
-// GenerateJWT membuat JWT token
-func GenerateJWT(userID uint, nim string) (string, error) {
+// GenerateJWT membuat access token berumur pendek yang terikat ke satu sesi
+func GenerateJWT(userID uint, nim string, role string, sessionID uint) (string, error) {
 	claims := Claims{
-		UserID: userID,
-		NIM:    nim,
+		UserID:    userID,
+		NIM:       nim,
+		Role:      role,
+		SessionID: sessionID,
 		RegisteredClaims: jwt.RegisteredClaims{
-			ExpiresAt: jwt.NewNumericDate(time.Now().Add(24 * time.Hour)),
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(config.AppConfig.AccessTokenTTL)),
 			IssuedAt:  jwt.NewNumericDate(time.Now()),
 		},
 	}
@@ -72,9 +76,59 @@ func ValidateJWT() gin.HandlerFunc {
 			return
 		}
 
+		if !SessionActive(claims.SessionID) {
+			c.JSON(http.StatusUnauthorized, gin.H{
+				"error": "Session has been revoked",
+			})
+			c.Abort()
+			return
+		}
+
 		// Set user info ke context
 		c.Set("user_id", claims.UserID)
+		c.Set("session_id", claims.SessionID)
 		c.Set("nim", claims.NIM)
+		c.Set("user_role", claims.Role)
+		c.Next()
+	}
+}
+
+// RequireRole membatasi endpoint hanya untuk role tertentu.
+// Role dari token dicek ulang ke principal (tabel users) supaya perubahan role langsung berlaku.
+func RequireRole(roles ...string) gin.HandlerFunc {
+	allowed := make(map[string]bool, len(roles))
+	for _, role := range roles {
+		allowed[role] = true
+	}
+
+	return func(c *gin.Context) {
+		principal := GetPrincipal(c)
+		if !allowed[c.GetString("user_role")] || !allowed[principal.Role] {
+			c.JSON(http.StatusForbidden, gin.H{
+				"error": "Access denied: insufficient role",
+			})
+			c.Abort()
+			return
+		}
+
+		if TwoFactorRequired(principal.Role) && !principal.TwoFactorEnabled {
+			c.JSON(http.StatusForbidden, gin.H{
+				"error":                     "Two-factor authentication is required for role " + principal.Role,
+				"two_factor_setup_required": true,
+			})
+			c.Abort()
+			return
+		}
+
+		if !principal.HasProfile() {
+			c.JSON(http.StatusForbidden, gin.H{
+				"error": "Profile for role " + principal.Role + " not found",
+			})
+			c.Abort()
+			return
+		}
+
+		c.Set("user_role", principal.Role)
 		c.Next()
 	}
 }
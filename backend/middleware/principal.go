@@ -0,0 +1,122 @@
+package middleware
+
+import (
+	"SIAku/config"
+	"SIAku/models"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Principal - akun yang sedang login beserta ID profil role-nya
+type Principal struct {
+	UserID      uint   `json:"user_id"`
+	Role        string `json:"role"`
+	Status      string `json:"status"`
+	MahasiswaID uint   `json:"mahasiswa_id,omitempty"`
+	DosenID     uint   `json:"dosen_id,omitempty"`
+	KajurID     uint   `json:"kajur_id,omitempty"`
+	RektorID    uint   `json:"rektor_id,omitempty"`
+	Jurusan     string `json:"jurusan,omitempty"`
+
+	TwoFactorEnabled bool `json:"two_factor_enabled"`
+}
+
+// HasProfile mengecek apakah profil untuk role principal sudah terhubung
+func (p *Principal) HasProfile() bool {
+	switch p.Role {
+	case "mahasiswa":
+		return p.MahasiswaID != 0
+	case "dosen", "dekan":
+		return p.DosenID != 0
+	case "kajur":
+		return p.KajurID != 0
+	case "rektor":
+		return p.RektorID != 0
+	}
+	return false
+}
+
+// LoadPrincipal memuat akun dan profil role yang terhubung lewat user_id, bisa diganti saat testing
+var LoadPrincipal = func(userID uint) (*Principal, error) {
+	var user models.Users
+	if err := config.DB.Where("id = ?", userID).First(&user).Error; err != nil {
+		return nil, err
+	}
+
+	principal := &Principal{
+		UserID:           user.ID,
+		Role:             user.Role,
+		Status:           user.Status,
+		TwoFactorEnabled: user.TwoFactorEnabled,
+	}
+
+	switch user.Role {
+	case "mahasiswa":
+		var mahasiswa models.Mahasiswa
+		if err := config.DB.Select("id", "jurusan").Where("user_id = ?", user.ID).First(&mahasiswa).Error; err == nil {
+			principal.MahasiswaID = mahasiswa.ID
+			principal.Jurusan = mahasiswa.Jurusan
+		}
+
+	case "dosen", "kajur", "dekan":
+		// Kajur dan dekan juga dosen, jadi profil dosennya ikut dimuat kalau ada
+		var dosen models.Dosen
+		if err := config.DB.Select("id", "jurusan").Where("user_id = ?", user.ID).First(&dosen).Error; err == nil {
+			principal.DosenID = dosen.ID
+			principal.Jurusan = dosen.Jurusan
+		}
+
+		if user.Role == "kajur" {
+			var kajur models.Kajur
+			// Profil kajur yang masa jabatannya sudah berakhir berstatus nonaktif
+			if err := config.DB.Select("id", "jurusan").Where("user_id = ? AND status <> ?", user.ID, "nonaktif").First(&kajur).Error; err == nil {
+				principal.KajurID = kajur.ID
+				principal.Jurusan = kajur.Jurusan
+			}
+		}
+
+	case "rektor":
+		var rektor models.Rektor
+		if err := config.DB.Select("id").Where("user_id = ?", user.ID).First(&rektor).Error; err == nil {
+			principal.RektorID = rektor.ID
+		}
+	}
+
+	return principal, nil
+}
+
+// ResolvePrincipal memuat principal sekali per request dan menyimpannya di context
+func ResolvePrincipal() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		principal, err := LoadPrincipal(c.GetUint("user_id"))
+		if err != nil {
+			c.JSON(http.StatusUnauthorized, gin.H{
+				"error": "User not found",
+			})
+			c.Abort()
+			return
+		}
+
+		if principal.Status != "aktif" {
+			c.JSON(http.StatusForbidden, gin.H{
+				"error": "Account is not active",
+			})
+			c.Abort()
+			return
+		}
+
+		c.Set("principal", principal)
+		c.Next()
+	}
+}
+
+// GetPrincipal mengambil principal dari context, kosong jika belum di-resolve
+func GetPrincipal(c *gin.Context) *Principal {
+	if value, exists := c.Get("principal"); exists {
+		if principal, ok := value.(*Principal); ok {
+			return principal
+		}
+	}
+	return &Principal{}
+}
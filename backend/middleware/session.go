@@ -0,0 +1,128 @@
+package middleware
+
+import (
+	"SIAku/config"
+	"SIAku/models"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+var (
+	ErrRefreshTokenInvalid = errors.New("refresh token invalid or expired")
+	ErrRefreshTokenReused  = errors.New("refresh token reuse detected")
+)
+
+// HashToken membuat hash SHA-256 dari token; hanya hash yang disimpan di database
+func HashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+func generateRefreshToken() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// SessionActive mengecek sesi belum di-revoke dan belum expired, bisa diganti saat testing
+var SessionActive = func(sessionID uint) bool {
+	var count int64
+	config.DB.Model(&models.UserSession{}).
+		Where("id = ? AND revoked_at IS NULL AND expires_at > ?", sessionID, time.Now()).
+		Count(&count)
+	return count > 0
+}
+
+// CreateSession membuat sesi baru untuk user dan mengembalikan refresh token mentah
+func CreateSession(userID uint, deviceName, userAgent, ipAddress string) (models.UserSession, string, error) {
+	refreshToken, err := generateRefreshToken()
+	if err != nil {
+		return models.UserSession{}, "", err
+	}
+
+	now := time.Now()
+	session := models.UserSession{
+		UserID:           userID,
+		RefreshTokenHash: HashToken(refreshToken),
+		DeviceName:       deviceName,
+		UserAgent:        userAgent,
+		IPAddress:        ipAddress,
+		ExpiresAt:        now.Add(config.AppConfig.RefreshTokenTTL),
+		LastUsedAt:       now,
+	}
+
+	if err := config.DB.Create(&session).Error; err != nil {
+		return models.UserSession{}, "", err
+	}
+
+	return session, refreshToken, nil
+}
+
+// RotateRefreshToken menukar refresh token lama dengan yang baru pada sesi yang sama.
+// Jika token yang sudah dirotasi dipakai lagi, sesi tersebut langsung di-revoke.
+func RotateRefreshToken(refreshToken, userAgent, ipAddress string) (models.UserSession, string, error) {
+	hash := HashToken(refreshToken)
+	now := time.Now()
+
+	var session models.UserSession
+	if err := config.DB.Where("refresh_token_hash = ?", hash).First(&session).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			var reused models.UserSession
+			if config.DB.Where("previous_token_hash = ?", hash).First(&reused).Error == nil {
+				RevokeSession(reused.ID, "token_reuse")
+				return models.UserSession{}, "", ErrRefreshTokenReused
+			}
+		}
+		return models.UserSession{}, "", ErrRefreshTokenInvalid
+	}
+
+	if session.RevokedAt != nil || session.ExpiresAt.Before(now) {
+		return models.UserSession{}, "", ErrRefreshTokenInvalid
+	}
+
+	newToken, err := generateRefreshToken()
+	if err != nil {
+		return models.UserSession{}, "", err
+	}
+
+	// Update bersyarat supaya dua refresh bersamaan dengan token yang sama tidak sama-sama berhasil
+	result := config.DB.Model(&models.UserSession{}).
+		Where("id = ? AND refresh_token_hash = ? AND revoked_at IS NULL", session.ID, hash).
+		Updates(map[string]interface{}{
+			"refresh_token_hash":  HashToken(newToken),
+			"previous_token_hash": hash,
+			"user_agent":          userAgent,
+			"ip_address":          ipAddress,
+			"last_used_at":        now,
+		})
+	if result.Error != nil {
+		return models.UserSession{}, "", result.Error
+	}
+	if result.RowsAffected == 0 {
+		return models.UserSession{}, "", ErrRefreshTokenInvalid
+	}
+
+	return session, newToken, nil
+}
+
+// RevokeSession mencabut satu sesi
+func RevokeSession(sessionID uint, reason string) error {
+	return config.DB.Model(&models.UserSession{}).
+		Where("id = ? AND revoked_at IS NULL", sessionID).
+		Updates(map[string]interface{}{"revoked_at": time.Now(), "revoked_reason": reason}).Error
+}
+
+// RevokeUserSessions mencabut semua sesi aktif milik user
+func RevokeUserSessions(userID uint, reason string) error {
+	return config.DB.Model(&models.UserSession{}).
+		Where("user_id = ? AND revoked_at IS NULL", userID).
+		Updates(map[string]interface{}{"revoked_at": time.Now(), "revoked_reason": reason}).Error
+}
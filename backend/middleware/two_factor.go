@@ -0,0 +1,60 @@
+package middleware
+
+import (
+	"SIAku/config"
+	"fmt"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const twoFactorChallengeTTL = 5 * time.Minute
+
+// TwoFactorClaims - token sementara antara langkah password dan langkah kode TOTP
+type TwoFactorClaims struct {
+	UserID     uint   `json:"user_id"`
+	Purpose    string `json:"purpose"`
+	DeviceName string `json:"device_name,omitempty"`
+	jwt.RegisteredClaims
+}
+
+// TwoFactorRequired mengecek apakah role wajib memakai 2FA menurut kebijakan
+func TwoFactorRequired(role string) bool {
+	for _, r := range config.AppConfig.TwoFactorRoles {
+		if r == role {
+			return true
+		}
+	}
+	return false
+}
+
+// GenerateTwoFactorChallenge membuat challenge token setelah password terverifikasi
+func GenerateTwoFactorChallenge(userID uint, deviceName string) (string, error) {
+	claims := TwoFactorClaims{
+		UserID:     userID,
+		Purpose:    "2fa_login",
+		DeviceName: deviceName,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(twoFactorChallengeTTL)),
+			IssuedAt:  jwt.NewNumericDate(time.Now()),
+		},
+	}
+
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	return token.SignedString([]byte(config.AppConfig.JWTSecret))
+}
+
+// ParseTwoFactorChallenge memvalidasi challenge token dan mengembalikan claims-nya
+func ParseTwoFactorChallenge(tokenString string) (*TwoFactorClaims, error) {
+	claims := &TwoFactorClaims{}
+	token, err := jwt.ParseWithClaims(tokenString, claims, func(token *jwt.Token) (interface{}, error) {
+		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
+		}
+		return []byte(config.AppConfig.JWTSecret), nil
+	})
+	if err != nil || !token.Valid || claims.Purpose != "2fa_login" {
+		return nil, fmt.Errorf("invalid challenge token")
+	}
+	return claims, nil
+}
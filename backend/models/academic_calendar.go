@@ -0,0 +1,89 @@
+package models
+
+import "time"
+
+// Jenis jendela kalender KRS
+const (
+	WindowKRS      = "krs"      // pengisian KRS
+	WindowApproval = "approval" // persetujuan dosen wali / validasi kajur
+	WindowAddDrop  = "add_drop" // perubahan rencana studi (PRS)
+)
+
+// AcademicCalendar - Jadwal pengisian, persetujuan dan perubahan KRS per tahun ajaran dan periode (ganjil/genap).
+// Tanggal akhir bersifat inklusif sampai akhir hari.
+type AcademicCalendar struct {
+	ID            uint      `gorm:"primaryKey" json:"id"`
+	TahunAjaran   string    `gorm:"type:varchar(20);not null;uniqueIndex:idx_calendar_period" json:"tahun_ajaran"`
+	Periode       string    `gorm:"type:varchar(10);not null;uniqueIndex:idx_calendar_period" json:"periode"` // ganjil, genap
+	KRSStart      time.Time `gorm:"type:date;not null" json:"krs_start"`
+	KRSEnd        time.Time `gorm:"type:date;not null" json:"krs_end"`
+	ApprovalStart time.Time `gorm:"type:date;not null" json:"approval_start"`
+	ApprovalEnd   time.Time `gorm:"type:date;not null" json:"approval_end"`
+	AddDropStart  time.Time `gorm:"type:date;not null" json:"add_drop_start"`
+	AddDropEnd    time.Time `gorm:"type:date;not null" json:"add_drop_end"`
+	UpdatedBy     *uint     `gorm:"default:null" json:"updated_by,omitempty"`
+	CreatedAt     time.Time `json:"created_at"`
+	UpdatedAt     time.Time `json:"updated_at"`
+}
+
+// Window mengembalikan rentang [start, end) sebuah jendela; end sudah digeser ke awal hari berikutnya
+func (ac AcademicCalendar) Window(window string) (time.Time, time.Time) {
+	switch window {
+	case WindowKRS:
+		return ac.KRSStart, ac.KRSEnd.AddDate(0, 0, 1)
+	case WindowApproval:
+		return ac.ApprovalStart, ac.ApprovalEnd.AddDate(0, 0, 1)
+	case WindowAddDrop:
+		return ac.AddDropStart, ac.AddDropEnd.AddDate(0, 0, 1)
+	}
+	return time.Time{}, time.Time{}
+}
+
+// IsOpen mengecek apakah jendela sedang berlangsung pada waktu now
+func (ac AcademicCalendar) IsOpen(window string, now time.Time) bool {
+	start, end := ac.Window(window)
+	return !now.Before(start) && now.Before(end)
+}
+
+// PeriodeForSemester - semester ganjil (1, 3, ...) masuk periode ganjil, semester genap masuk periode genap
+func PeriodeForSemester(semester int) string {
+	if semester%2 == 0 {
+		return "genap"
+	}
+	return "ganjil"
+}
+
+// KRSWindowOverride - Izin kajur untuk satu mahasiswa di luar jendela kalender
+type KRSWindowOverride struct {
+	ID          uint       `gorm:"primaryKey" json:"id"`
+	MahasiswaID uint       `gorm:"not null;index" json:"mahasiswa_id"`
+	TahunAjaran string     `gorm:"type:varchar(20);not null" json:"tahun_ajaran"`
+	Periode     string     `gorm:"type:varchar(10);not null" json:"periode"`
+	Window      string     `gorm:"column:window_name;type:varchar(20);not null" json:"window"` // "window" adalah kata kunci SQL
+	ValidUntil  time.Time  `gorm:"not null" json:"valid_until"`
+	Reason      string     `gorm:"type:text;not null" json:"reason"`
+	GrantedBy   uint       `gorm:"not null" json:"granted_by"` // kajur ID
+	RevokedAt   *time.Time `gorm:"default:null" json:"revoked_at,omitempty"`
+	Mahasiswa   Mahasiswa  `gorm:"foreignKey:MahasiswaID" json:"mahasiswa,omitempty"`
+	CreatedAt   time.Time  `json:"created_at"`
+}
+
+type AcademicCalendarRequest struct {
+	TahunAjaran   string `json:"tahun_ajaran" validate:"required"`
+	Periode       string `json:"periode" validate:"required,oneof=ganjil genap"`
+	KRSStart      string `json:"krs_start" validate:"required,datetime=2006-01-02"`
+	KRSEnd        string `json:"krs_end" validate:"required,datetime=2006-01-02"`
+	ApprovalStart string `json:"approval_start" validate:"required,datetime=2006-01-02"`
+	ApprovalEnd   string `json:"approval_end" validate:"required,datetime=2006-01-02"`
+	AddDropStart  string `json:"add_drop_start" validate:"required,datetime=2006-01-02"`
+	AddDropEnd    string `json:"add_drop_end" validate:"required,datetime=2006-01-02"`
+}
+
+type KRSWindowOverrideRequest struct {
+	MahasiswaID uint   `json:"mahasiswa_id" validate:"required"`
+	TahunAjaran string `json:"tahun_ajaran" validate:"required"`
+	Periode     string `json:"periode" validate:"required,oneof=ganjil genap"`
+	Window      string `json:"window" validate:"required,oneof=krs approval add_drop"`
+	ValidUntil  string `json:"valid_until" validate:"required,datetime=2006-01-02"`
+	Reason      string `json:"reason" validate:"required,min=5"`
+}
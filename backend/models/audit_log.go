@@ -0,0 +1,53 @@
+package models
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// JSONMap - Kolom jsonb generik untuk snapshot data
+type JSONMap map[string]interface{}
+
+func (m JSONMap) Value() (driver.Value, error) {
+	if m == nil {
+		return nil, nil
+	}
+	b, err := json.Marshal(m)
+	return string(b), err
+}
+
+func (m *JSONMap) Scan(value interface{}) error {
+	if value == nil {
+		*m = nil
+		return nil
+	}
+
+	var b []byte
+	switch v := value.(type) {
+	case []byte:
+		b = v
+	case string:
+		b = []byte(v)
+	default:
+		return fmt.Errorf("unsupported JSONMap value type %T", value)
+	}
+	return json.Unmarshal(b, m)
+}
+
+// AuditLog - Jejak perubahan data akademik: siapa mengubah apa, sebelum dan sesudahnya
+type AuditLog struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	ActorID   *uint     `gorm:"index" json:"actor_id,omitempty"`
+	ActorRole string    `gorm:"type:varchar(20);index" json:"actor_role"`
+	Jurusan   string    `gorm:"type:varchar(100);index" json:"jurusan,omitempty"` // jurusan actor, untuk scope kajur
+	Action    string    `gorm:"type:varchar(30);not null" json:"action"`          // create, update, delete, approve, ...
+	Entity    string    `gorm:"type:varchar(50);not null;index:idx_audit_entity" json:"entity"`
+	EntityID  uint      `gorm:"index:idx_audit_entity" json:"entity_id"`
+	Before    JSONMap   `gorm:"type:jsonb" json:"before"`
+	After     JSONMap   `gorm:"type:jsonb" json:"after"`
+	IPAddress string    `gorm:"type:varchar(45)" json:"ip_address"`
+	RequestID string    `gorm:"type:varchar(64);index" json:"request_id"`
+	CreatedAt time.Time `gorm:"index" json:"created_at"`
+}
@@ -2,12 +2,19 @@ package models
 
 import "time"
 
+// Sifat mata kuliah dalam kurikulum
+const (
+	CourseWajib   = "wajib"
+	CoursePilihan = "pilihan"
+)
+
 type Course struct {
 	ID         uint        `gorm:"primaryKey" json:"id"`
 	Code       string      `gorm:"unique;not null" json:"code" validate:"required,min=3,max=10"`
 	Name       string      `gorm:"type:varchar(100);not null" json:"name" validate:"required,min=3,max=100"`
 	Credits    int         `gorm:"not null" json:"credits" validate:"required,min=1,max=6"`
 	Semester   int         `gorm:"not null;default:1" json:"semester" validate:"required,min=1,max=14"`
+	Sifat      string      `gorm:"type:varchar(10);not null;default:'wajib'" json:"sifat"`
 	Prasyarat  string      `gorm:"type:text" json:"prasyarat"`
 	Deskripsi  string      `gorm:"type:text" json:"deskripsi"`
 	DosenID    *uint       `gorm:"default:null" json:"dosen_id,omitempty"`
@@ -28,6 +35,7 @@ type CourseRequest struct {
 	Name      string `json:"name" validate:"required,min=3,max=100"`
 	Credits   int    `json:"credits" validate:"required,min=1,max=6"`
 	Semester  int    `json:"semester" validate:"required,min=1,max=14"`
+	Sifat     string `json:"sifat" validate:"omitempty,oneof=wajib pilihan"` // default wajib
 	Prasyarat string `json:"prasyarat"`
 	Deskripsi string `json:"deskripsi"`
 }
@@ -4,6 +4,7 @@ import "time"
 
 type Dosen struct {
 	ID          uint        `gorm:"primaryKey" json:"id"`
+	UserID      *uint       `gorm:"index" json:"user_id,omitempty"`
 	NIDN        string      `gorm:"unique;not null" json:"nidn" validate:"required,min=8,max=20"`
 	Nama        string      `gorm:"type:varchar(100);not null" json:"nama" validate:"required,min=2,max=100"`
 	Email       string      `gorm:"type:varchar(100);unique;not null" json:"email" validate:"required,email"`
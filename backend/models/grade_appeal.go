@@ -0,0 +1,83 @@
+package models
+
+import (
+	"fmt"
+	"time"
+)
+
+// Status sanggah nilai
+const (
+	AppealOpen      = "open"      // diajukan mahasiswa, menunggu tanggapan dosen
+	AppealEscalated = "escalated" // diambil alih kajur
+	AppealAccepted  = "accepted"
+	AppealRejected  = "rejected"
+)
+
+// gradeAppealTransitions - state machine: status sekarang -> role -> aksi -> status berikutnya.
+// Kajur dapat mengambil alih sanggah yang belum ditanggapi atau yang ditolak dosen, lalu memutuskannya sendiri.
+var gradeAppealTransitions = map[string]map[string]map[string]string{
+	AppealOpen: {
+		"dosen": {"accept": AppealAccepted, "reject": AppealRejected},
+		"kajur": {"escalate": AppealEscalated},
+	},
+	AppealRejected:  {"kajur": {"escalate": AppealEscalated}},
+	AppealEscalated: {"kajur": {"accept": AppealAccepted, "reject": AppealRejected}},
+}
+
+// NextGradeAppealStatus mengembalikan status hasil aksi oleh role tersebut, error jika transisi tidak diizinkan
+func NextGradeAppealStatus(current, role, action string) (string, error) {
+	if next, ok := gradeAppealTransitions[current][role][action]; ok {
+		return next, nil
+	}
+	return "", fmt.Errorf("%s cannot %s a grade appeal with status %s", role, action, current)
+}
+
+// GradeAppeal - Sanggah nilai mahasiswa atas nilai yang sudah dipublikasikan
+type GradeAppeal struct {
+	ID             uint                    `gorm:"primaryKey" json:"id"`
+	NilaiID        uint                    `gorm:"not null;index" json:"nilai_id"`
+	MahasiswaID    uint                    `gorm:"not null;index" json:"mahasiswa_id"`
+	DosenID        *uint                   `gorm:"index" json:"dosen_id,omitempty"` // dosen kelas atau pengampu utama yang menanggapi
+	Jurusan        string                  `gorm:"type:varchar(100);index" json:"jurusan"`
+	Pesan          string                  `gorm:"type:text;not null" json:"pesan"`
+	Status         string                  `gorm:"type:varchar(20);not null;default:'open';index" json:"status"`
+	Komponen       JSONMap                 `gorm:"type:jsonb" json:"komponen,omitempty"` // skor komponen hasil revisi jika diterima
+	Response       string                  `gorm:"type:text" json:"response,omitempty"`
+	RespondedBy    *uint                   `gorm:"default:null" json:"responded_by,omitempty"` // user ID dosen/kajur
+	RespondedAt    *time.Time              `gorm:"default:null" json:"responded_at,omitempty"`
+	EscalatedBy    *uint                   `gorm:"default:null" json:"escalated_by,omitempty"` // kajur ID
+	EscalatedAt    *time.Time              `gorm:"default:null" json:"escalated_at,omitempty"`
+	EscalationNote string                  `gorm:"type:text" json:"escalation_note,omitempty"`
+	NilaiAkhirLama float64                 `gorm:"type:decimal(5,2)" json:"nilai_akhir_lama"`
+	GradeHurufLama string                  `gorm:"type:varchar(3)" json:"grade_huruf_lama"`
+	NilaiAkhirBaru *float64                `gorm:"type:decimal(5,2)" json:"nilai_akhir_baru,omitempty"`
+	GradeHurufBaru string                  `gorm:"type:varchar(3)" json:"grade_huruf_baru,omitempty"`
+	Attachments    []GradeAppealAttachment `gorm:"foreignKey:AppealID" json:"attachments,omitempty"`
+	Nilai          Nilai                   `gorm:"foreignKey:NilaiID" json:"nilai,omitempty"`
+	Mahasiswa      Mahasiswa               `gorm:"foreignKey:MahasiswaID" json:"mahasiswa,omitempty"`
+	CreatedAt      time.Time               `json:"created_at"`
+	UpdatedAt      time.Time               `json:"updated_at"`
+}
+
+// GradeAppealAttachment - Bukti pendukung sanggah yang diunggah mahasiswa
+type GradeAppealAttachment struct {
+	ID         uint      `gorm:"primaryKey" json:"id"`
+	AppealID   uint      `gorm:"not null;index" json:"appeal_id"`
+	FileName   string    `gorm:"type:varchar(255);not null" json:"file_name"`
+	FilePath   string    `gorm:"type:varchar(500);not null" json:"-"`
+	FileSize   int64     `gorm:"default:0" json:"file_size"`
+	MimeType   string    `gorm:"type:varchar(100)" json:"mime_type"`
+	UploadedBy uint      `gorm:"not null" json:"uploaded_by"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+type GradeAppealRequest struct {
+	Pesan string `json:"pesan" validate:"required,min=10,max=2000"`
+}
+
+// GradeAppealResponseRequest - Tanggapan dosen/kajur; accept wajib menyertakan skor komponen hasil revisi
+type GradeAppealResponseRequest struct {
+	Action   string             `json:"action" validate:"required,oneof=accept reject escalate"`
+	Response string             `json:"response" validate:"max=2000"`
+	Komponen map[string]float64 `json:"komponen" validate:"omitempty,dive,min=0,max=100"`
+}
@@ -0,0 +1,49 @@
+package models
+
+// Status baris impor nilai
+const (
+	ImportRowNew       = "new"       // mahasiswa belum punya nilai
+	ImportRowUpdate    = "update"    // nilai draft berubah
+	ImportRowUnchanged = "unchanged" // skor sama dengan yang tersimpan
+	ImportRowSkipped   = "skipped"   // semua sel skor kosong
+	ImportRowError     = "error"
+)
+
+// GradeImportChange - perubahan skor satu komponen; SkorLama nil jika sebelumnya belum diisi
+type GradeImportChange struct {
+	Kode     string   `json:"kode"`
+	SkorLama *float64 `json:"skor_lama"`
+	SkorBaru float64  `json:"skor_baru"`
+}
+
+// GradeImportRow - hasil validasi satu baris file impor nilai
+type GradeImportRow struct {
+	Row            int                 `json:"row"` // nomor baris di file (header = baris 1)
+	NIM            string              `json:"nim"`
+	Nama           string              `json:"nama,omitempty"`
+	MahasiswaID    uint                `json:"mahasiswa_id,omitempty"`
+	Status         string              `json:"status"`
+	Errors         []string            `json:"errors,omitempty"`
+	Changes        []GradeImportChange `json:"changes,omitempty"`
+	NilaiAkhirLama *float64            `json:"nilai_akhir_lama,omitempty"`
+	GradeHurufLama string              `json:"grade_huruf_lama,omitempty"`
+	NilaiAkhirBaru *float64            `json:"nilai_akhir_baru,omitempty"`
+	GradeHurufBaru string              `json:"grade_huruf_baru,omitempty"`
+}
+
+// GradeImportResult - pratinjau (atau hasil commit) impor nilai satu lembar nilai
+type GradeImportResult struct {
+	GradeSheetID uint             `json:"grade_sheet_id"`
+	CourseID     uint             `json:"course_id"`
+	TahunAjaran  string           `json:"tahun_ajaran"`
+	KelasID      uint             `json:"kelas_id"`
+	Komponen     []string         `json:"komponen"` // kode komponen yang ada di file
+	Committed    bool             `json:"committed"`
+	TotalRows    int              `json:"total_rows"`
+	New          int              `json:"new"`
+	Updated      int              `json:"updated"`
+	Unchanged    int              `json:"unchanged"`
+	Skipped      int              `json:"skipped"`
+	Errors       int              `json:"errors"`
+	Rows         []GradeImportRow `json:"rows"`
+}
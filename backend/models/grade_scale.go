@@ -0,0 +1,64 @@
+package models
+
+import (
+	"sort"
+	"time"
+)
+
+// GradeScale - Skala konversi nilai angka ke huruf untuk kurikulum/angkatan tertentu. Skala tidak pernah diubah;
+// perubahan dibuat sebagai versi baru sehingga nilai lama tetap merujuk skala yang menghasilkannya.
+type GradeScale struct {
+	ID            uint             `gorm:"primaryKey" json:"id"`
+	Nama          string           `gorm:"type:varchar(100);not null" json:"nama"`
+	Jurusan       string           `gorm:"type:varchar(100);not null;default:'';uniqueIndex:idx_grade_scale_version" json:"jurusan"` // kosong = semua jurusan
+	AngkatanMulai int              `gorm:"not null;default:0;uniqueIndex:idx_grade_scale_version" json:"angkatan_mulai"`             // berlaku untuk angkatan >= ini
+	Versi         int              `gorm:"not null;uniqueIndex:idx_grade_scale_version" json:"versi"`
+	CreatedBy     *uint            `json:"created_by,omitempty"`
+	Items         []GradeScaleItem `gorm:"foreignKey:GradeScaleID" json:"items"`
+	CreatedAt     time.Time        `json:"created_at"`
+}
+
+type GradeScaleItem struct {
+	ID           uint    `gorm:"primaryKey" json:"id"`
+	GradeScaleID uint    `gorm:"not null;index" json:"grade_scale_id"`
+	Huruf        string  `gorm:"type:varchar(3);not null" json:"huruf"`
+	MinNilai     float64 `gorm:"type:decimal(5,2);not null" json:"min_nilai"`
+	Bobot        float64 `gorm:"type:decimal(3,2);not null" json:"bobot"`
+}
+
+// DefaultGradeScaleItems - konversi standar A/AB/B/BC/C/D/E
+var DefaultGradeScaleItems = []GradeScaleItem{
+	{Huruf: "A", MinNilai: 85, Bobot: 4.0},
+	{Huruf: "AB", MinNilai: 80, Bobot: 3.5},
+	{Huruf: "B", MinNilai: 75, Bobot: 3.0},
+	{Huruf: "BC", MinNilai: 70, Bobot: 2.5},
+	{Huruf: "C", MinNilai: 65, Bobot: 2.0},
+	{Huruf: "D", MinNilai: 50, Bobot: 1.0},
+	{Huruf: "E", MinNilai: 0, Bobot: 0.0},
+}
+
+// Convert mengubah nilai akhir menjadi huruf dan bobot menurut skala
+func (s GradeScale) Convert(nilai float64) (string, float64) {
+	items := append([]GradeScaleItem(nil), s.Items...)
+	sort.Slice(items, func(i, j int) bool { return items[i].MinNilai > items[j].MinNilai })
+	for _, item := range items {
+		if nilai >= item.MinNilai {
+			return item.Huruf, item.Bobot
+		}
+	}
+	return "E", 0
+}
+
+type GradeScaleItemRequest struct {
+	Huruf    string  `json:"huruf" validate:"required,max=3"`
+	MinNilai float64 `json:"min_nilai" validate:"min=0,max=100"`
+	Bobot    float64 `json:"bobot" validate:"min=0,max=4"`
+}
+
+// CreateGradeScaleRequest - Versi baru skala untuk jurusan (kosong = semua) dan angkatan mulai
+type CreateGradeScaleRequest struct {
+	Nama          string                  `json:"nama" validate:"required,max=100"`
+	Jurusan       string                  `json:"jurusan"`
+	AngkatanMulai int                     `json:"angkatan_mulai" validate:"min=0,max=2100"`
+	Items         []GradeScaleItemRequest `json:"items" validate:"required,min=2,max=20,dive"`
+}
@@ -0,0 +1,52 @@
+package models
+
+import "testing"
+
+func TestGradeScaleConvert(t *testing.T) {
+	// Skala plus/minus, sengaja tidak urut
+	plusMinus := GradeScale{Items: []GradeScaleItem{
+		{Huruf: "B+", MinNilai: 75, Bobot: 3.3},
+		{Huruf: "A", MinNilai: 85, Bobot: 4.0},
+		{Huruf: "C", MinNilai: 55, Bobot: 2.0},
+		{Huruf: "A-", MinNilai: 80, Bobot: 3.7},
+		{Huruf: "B", MinNilai: 70, Bobot: 3.0},
+		{Huruf: "B-", MinNilai: 65, Bobot: 2.7},
+		{Huruf: "E", MinNilai: 0, Bobot: 0},
+	}}
+	standard := GradeScale{Items: DefaultGradeScaleItems}
+
+	tests := []struct {
+		name      string
+		scale     GradeScale
+		nilai     float64
+		wantHuruf string
+		wantBobot float64
+	}{
+		{"plus/minus A", plusMinus, 100, "A", 4.0},
+		{"plus/minus tepat batas A", plusMinus, 85, "A", 4.0},
+		{"plus/minus tepat di bawah A", plusMinus, 84.99, "A-", 3.7},
+		{"plus/minus tepat batas A-", plusMinus, 80, "A-", 3.7},
+		{"plus/minus B+", plusMinus, 79.5, "B+", 3.3},
+		{"plus/minus B", plusMinus, 74.99, "B", 3.0},
+		{"plus/minus B-", plusMinus, 65, "B-", 2.7},
+		{"plus/minus C", plusMinus, 64.99, "C", 2.0},
+		{"plus/minus E", plusMinus, 0, "E", 0},
+		{"standar AB", standard, 80, "AB", 3.5},
+		{"standar BC", standard, 74.99, "BC", 2.5},
+		{"standar E", standard, 49.99, "E", 0},
+		{"tanpa batas 0", GradeScale{Items: []GradeScaleItem{{Huruf: "A", MinNilai: 85, Bobot: 4}}}, 50, "E", 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			huruf, bobot := tt.scale.Convert(tt.nilai)
+			if huruf != tt.wantHuruf || bobot != tt.wantBobot {
+				t.Errorf("Convert(%v) = (%s, %v), want (%s, %v)", tt.nilai, huruf, bobot, tt.wantHuruf, tt.wantBobot)
+			}
+		})
+	}
+
+	// Convert tidak boleh mengubah urutan item skala
+	if plusMinus.Items[0].Huruf != "B+" {
+		t.Errorf("Convert reordered scale items: %+v", plusMinus.Items)
+	}
+}
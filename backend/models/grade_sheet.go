@@ -0,0 +1,113 @@
+package models
+
+import (
+	"fmt"
+	"time"
+)
+
+// Status lembar nilai per kelas
+const (
+	GradeSheetDraft     = "draft"
+	GradeSheetSubmitted = "submitted"
+	GradeSheetVerified  = "verified"
+	GradeSheetPublished = "published"
+)
+
+// gradeSheetTransitions - state machine: status sekarang -> role -> aksi -> status berikutnya.
+// Dosen mengajukan, kajur memverifikasi lalu mempublikasikan atau mengembalikan ke dosen.
+var gradeSheetTransitions = map[string]map[string]map[string]string{
+	GradeSheetDraft: {"dosen": {"submit": GradeSheetSubmitted}},
+	GradeSheetSubmitted: {"kajur": {
+		"verify": GradeSheetVerified,
+		"return": GradeSheetDraft,
+	}},
+	GradeSheetVerified: {"kajur": {
+		"publish": GradeSheetPublished,
+		"return":  GradeSheetDraft,
+	}},
+}
+
+// NextGradeSheetStatus mengembalikan status hasil aksi oleh role tersebut, error jika transisi tidak diizinkan
+func NextGradeSheetStatus(current, role, action string) (string, error) {
+	if next, ok := gradeSheetTransitions[current][role][action]; ok {
+		return next, nil
+	}
+	return "", fmt.Errorf("%s cannot %s a grade sheet with status %s", role, action, current)
+}
+
+// GradeSheet - Lembar nilai satu kelas (mata kuliah, tahun ajaran, kelas paralel).
+// Nilai baru terlihat mahasiswa dan dihitung di IPK setelah lembar dipublikasikan.
+type GradeSheet struct {
+	ID          uint       `gorm:"primaryKey" json:"id"`
+	CourseID    uint       `gorm:"not null;uniqueIndex:idx_grade_sheet_class" json:"course_id"`
+	TahunAjaran string     `gorm:"type:varchar(20);not null;uniqueIndex:idx_grade_sheet_class" json:"tahun_ajaran"`
+	KelasID     uint       `gorm:"not null;default:0;uniqueIndex:idx_grade_sheet_class" json:"kelas_id"` // 0 = tanpa kelas paralel
+	Jurusan     string     `gorm:"type:varchar(100);index" json:"jurusan"`                               // jurusan dosen pengampu, untuk scope kajur
+	Status      string     `gorm:"type:varchar(20);not null;default:'draft';index" json:"status"`
+	SubmittedBy *uint      `gorm:"default:null" json:"submitted_by,omitempty"` // dosen ID
+	SubmittedAt *time.Time `gorm:"default:null" json:"submitted_at,omitempty"`
+	VerifiedBy  *uint      `gorm:"default:null" json:"verified_by,omitempty"` // kajur ID
+	VerifiedAt  *time.Time `gorm:"default:null" json:"verified_at,omitempty"`
+	PublishedAt *time.Time `gorm:"default:null" json:"published_at,omitempty"`
+	Note        string     `gorm:"type:text" json:"note,omitempty"`
+	Course      Course     `gorm:"foreignKey:CourseID" json:"course,omitempty"`
+	CreatedAt   time.Time  `json:"created_at"`
+	UpdatedAt   time.Time  `json:"updated_at"`
+}
+
+// Status pengajuan koreksi nilai yang sudah dipublikasikan
+const (
+	CorrectionPending  = "pending"
+	CorrectionApproved = "approved"
+	CorrectionRejected = "rejected"
+)
+
+// GradeCorrection - Permintaan dosen mengubah nilai yang sudah dipublikasikan; berlaku setelah disetujui kajur
+type GradeCorrection struct {
+	ID             uint       `gorm:"primaryKey" json:"id"`
+	NilaiID        uint       `gorm:"not null;index" json:"nilai_id"`
+	RequestedBy    uint       `gorm:"not null;index" json:"requested_by"` // dosen ID
+	Jurusan        string     `gorm:"type:varchar(100);index" json:"jurusan"`
+	Alasan         string     `gorm:"type:text;not null" json:"alasan"`
+	Komponen       JSONMap    `gorm:"type:jsonb" json:"komponen"` // skor komponen baru per kode
+	NilaiAkhirLama float64    `gorm:"type:decimal(5,2)" json:"nilai_akhir_lama"`
+	GradeHurufLama string     `gorm:"type:varchar(3)" json:"grade_huruf_lama"`
+	NilaiAkhirBaru float64    `gorm:"type:decimal(5,2)" json:"nilai_akhir_baru"`
+	GradeHurufBaru string     `gorm:"type:varchar(3)" json:"grade_huruf_baru"`
+	Status         string     `gorm:"type:varchar(20);not null;default:'pending';index" json:"status"`
+	ReviewedBy     *uint      `gorm:"default:null" json:"reviewed_by,omitempty"` // kajur ID
+	ReviewedAt     *time.Time `gorm:"default:null" json:"reviewed_at,omitempty"`
+	ReviewNote     string     `gorm:"type:text" json:"review_note,omitempty"`
+	Nilai          Nilai      `gorm:"foreignKey:NilaiID" json:"nilai,omitempty"`
+	CreatedAt      time.Time  `json:"created_at"`
+	UpdatedAt      time.Time  `json:"updated_at"`
+}
+
+type SubmitGradeSheetRequest struct {
+	TahunAjaran string `json:"tahun_ajaran" validate:"required"`
+	KelasID     *uint  `json:"kelas_id,omitempty"`
+	Note        string `json:"note" validate:"max=500"`
+}
+
+type GradeSheetActionRequest struct {
+	Action string `json:"action" validate:"required,oneof=verify return publish"`
+	Note   string `json:"note" validate:"max=500"`
+}
+
+type GradeCorrectionRequest struct {
+	Komponen map[string]float64 `json:"komponen" validate:"required,min=1,dive,min=0,max=100"`
+	Alasan   string             `json:"alasan" validate:"required,min=10,max=1000"`
+}
+
+type GradeCorrectionReviewRequest struct {
+	Action string `json:"action" validate:"required,oneof=approve reject"`
+	Note   string `json:"note" validate:"max=500"`
+}
+
+// GradeSheetResponse - Status lembar nilai beserta jumlah mahasiswa yang sudah/belum dinilai
+type GradeSheetResponse struct {
+	GradeSheet
+	TotalMahasiswa int `json:"total_mahasiswa"`
+	SudahDinilai   int `json:"sudah_dinilai"`
+	BelumDinilai   int `json:"belum_dinilai"`
+}
@@ -0,0 +1,73 @@
+package models
+
+import "time"
+
+// GradingComponent - Komponen penilaian mata kuliah per tahun ajaran. KelasID diisi untuk skema khusus kelas paralel,
+// selain itu skema berlaku untuk semua kelas mata kuliah tersebut.
+type GradingComponent struct {
+	ID          uint      `gorm:"primaryKey" json:"id"`
+	CourseID    uint      `gorm:"not null;index:idx_grading_scheme" json:"course_id"`
+	TahunAjaran string    `gorm:"type:varchar(20);not null;index:idx_grading_scheme" json:"tahun_ajaran"`
+	KelasID     *uint     `gorm:"index" json:"kelas_id,omitempty"`
+	Kode        string    `gorm:"type:varchar(20);not null" json:"kode"`
+	Nama        string    `gorm:"type:varchar(50);not null" json:"nama"`
+	Bobot       float64   `gorm:"type:decimal(5,2);not null" json:"bobot"`
+	Urutan      int       `gorm:"not null;default:0" json:"urutan"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+// DefaultGradingComponents - skema bawaan jika mata kuliah belum menetapkan komponen (30% tugas, 35% UTS, 35% UAS)
+var DefaultGradingComponents = []GradingComponent{
+	{Kode: "tugas", Nama: "Tugas", Bobot: 30, Urutan: 1},
+	{Kode: "uts", Nama: "UTS", Bobot: 35, Urutan: 2},
+	{Kode: "uas", Nama: "UAS", Bobot: 35, Urutan: 3},
+}
+
+// NilaiKomponen - Skor satu komponen pada Nilai; Nama dan Bobot disalin dari skema saat nilai dihitung.
+// Komponen yang dihapus dari skema tetap disimpan dengan bobot 0.
+type NilaiKomponen struct {
+	ID      uint    `gorm:"primaryKey" json:"id"`
+	NilaiID uint    `gorm:"not null;uniqueIndex:idx_nilai_komponen" json:"nilai_id"`
+	Kode    string  `gorm:"type:varchar(20);not null;uniqueIndex:idx_nilai_komponen" json:"kode"`
+	Nama    string  `gorm:"type:varchar(50);not null" json:"nama"`
+	Bobot   float64 `gorm:"type:decimal(5,2);not null;default:0" json:"bobot"`
+	Skor    float64 `gorm:"type:decimal(5,2);not null;default:0" json:"skor"`
+}
+
+type GradingComponentItem struct {
+	Kode  string  `json:"kode" validate:"required,alphanum,max=20"`
+	Nama  string  `json:"nama" validate:"required,max=50"`
+	Bobot float64 `json:"bobot" validate:"gt=0,lte=100"`
+}
+
+// SetGradingSchemeRequest - Ganti komponen penilaian; total bobot harus 100
+type SetGradingSchemeRequest struct {
+	TahunAjaran string                 `json:"tahun_ajaran" validate:"required"`
+	KelasID     *uint                  `json:"kelas_id,omitempty"`
+	Components  []GradingComponentItem `json:"components" validate:"required,min=1,max=10,dive"`
+}
+
+// InputNilaiRequest - Skor per komponen (kode -> 0..100). Field tugas/UTS/UAS lama tetap diterima
+// untuk skema yang memakai kode tersebut.
+type InputNilaiRequest struct {
+	Komponen   map[string]float64 `json:"komponen" validate:"omitempty,dive,min=0,max=100"`
+	NilaiTugas float64            `json:"nilai_tugas" validate:"min=0,max=100"`
+	NilaiUTS   float64            `json:"nilai_uts" validate:"min=0,max=100"`
+	NilaiUAS   float64            `json:"nilai_uas" validate:"min=0,max=100"`
+}
+
+type NilaiKomponenResponse struct {
+	Kode  string  `json:"kode"`
+	Nama  string  `json:"nama"`
+	Bobot float64 `json:"bobot"`
+	Skor  float64 `json:"skor"`
+}
+
+type GradingSchemeResponse struct {
+	CourseID    uint               `json:"course_id"`
+	TahunAjaran string             `json:"tahun_ajaran"`
+	KelasID     *uint              `json:"kelas_id,omitempty"`
+	Source      string             `json:"source"` // kelas, course atau default
+	Components  []GradingComponent `json:"components"`
+}
@@ -0,0 +1,82 @@
+package models
+
+import "time"
+
+// StaffInvitation - Undangan sekali pakai untuk membuat akun staf (dosen, kajur, rektor)
+type StaffInvitation struct {
+	ID             uint       `gorm:"primaryKey" json:"id"`
+	TokenHash      string     `gorm:"type:varchar(64);uniqueIndex;not null" json:"-"`
+	Email          string     `gorm:"type:varchar(100);not null;index" json:"email"`
+	Role           string     `gorm:"type:varchar(20);not null" json:"role"` // dosen, kajur, rektor
+	Jurusan        string     `gorm:"type:varchar(100)" json:"jurusan"`
+	InvitedBy      uint       `gorm:"not null;index" json:"invited_by"` // user_id pembuat undangan
+	ExpiresAt      time.Time  `gorm:"not null" json:"expires_at"`
+	AcceptedAt     *time.Time `gorm:"default:null" json:"accepted_at,omitempty"`
+	AcceptedUserID *uint      `gorm:"default:null" json:"accepted_user_id,omitempty"`
+	RevokedAt      *time.Time `gorm:"default:null" json:"revoked_at,omitempty"`
+	Inviter        Users      `gorm:"foreignKey:InvitedBy" json:"-"`
+	CreatedAt      time.Time  `json:"created_at"`
+	UpdatedAt      time.Time  `json:"updated_at"`
+}
+
+// Status menghitung status undangan: pending, accepted, revoked, expired
+func (i StaffInvitation) Status() string {
+	switch {
+	case i.AcceptedAt != nil:
+		return "accepted"
+	case i.RevokedAt != nil:
+		return "revoked"
+	case i.ExpiresAt.Before(time.Now()):
+		return "expired"
+	}
+	return "pending"
+}
+
+// NIMWhitelist - Daftar NIM yang sudah diimpor dan boleh registrasi mandiri
+type NIMWhitelist struct {
+	ID           uint       `gorm:"primaryKey" json:"id"`
+	NIM          string     `gorm:"type:varchar(20);uniqueIndex;not null" json:"nim"`
+	Nama         string     `gorm:"type:varchar(100)" json:"nama"`
+	Jurusan      string     `gorm:"type:varchar(100);not null" json:"jurusan"`
+	ImportedBy   uint       `gorm:"not null" json:"imported_by"`
+	RegisteredAt *time.Time `gorm:"default:null" json:"registered_at,omitempty"`
+	CreatedAt    time.Time  `json:"created_at"`
+	UpdatedAt    time.Time  `json:"updated_at"`
+}
+
+type CreateInvitationRequest struct {
+	Email   string `json:"email" validate:"required,email"`
+	Role    string `json:"role" validate:"required,oneof=dosen kajur rektor"`
+	Jurusan string `json:"jurusan,omitempty" validate:"omitempty,min=2,max=100"`
+}
+
+type AcceptInvitationRequest struct {
+	Token       string `json:"token" validate:"required"`
+	Username    string `json:"username" validate:"required,min=3,max=50"`
+	Nama        string `json:"nama" validate:"required,min=2,max=100"`
+	Password    string `json:"password" validate:"required,min=6"`
+	NIDN        string `json:"nidn" validate:"required,min=8,max=20"`
+	PhoneNumber string `json:"phone_number,omitempty" validate:"omitempty,min=10,max=15"`
+}
+
+type InvitationResponse struct {
+	ID        uint       `json:"id"`
+	Email     string     `json:"email"`
+	Role      string     `json:"role"`
+	Jurusan   string     `json:"jurusan"`
+	Status    string     `json:"status"`
+	InvitedBy uint       `json:"invited_by"`
+	ExpiresAt time.Time  `json:"expires_at"`
+	RevokedAt *time.Time `json:"revoked_at,omitempty"`
+	CreatedAt time.Time  `json:"created_at"`
+}
+
+type NIMWhitelistEntry struct {
+	NIM     string `json:"nim" validate:"required,min=8,max=20"`
+	Nama    string `json:"nama,omitempty" validate:"omitempty,max=100"`
+	Jurusan string `json:"jurusan" validate:"required,min=2,max=100"`
+}
+
+type ImportNIMWhitelistRequest struct {
+	Entries []NIMWhitelistEntry `json:"entries" validate:"required,min=1,dive"`
+}
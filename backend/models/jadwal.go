@@ -1,10 +1,17 @@
 package models
 
-import "time"
+import (
+	"SIAku/utils"
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+)
 
 type Jadwal struct {
 	ID          uint      `gorm:"primaryKey" json:"id"`
 	CourseID    uint      `gorm:"not null" json:"course_id"`
+	KelasID     *uint     `gorm:"default:null;index" json:"kelas_id,omitempty"` // null = jadwal mata kuliah tanpa kelas paralel
 	Hari        string    `gorm:"type:varchar(20);not null" json:"hari"`
 	JamMulai    string    `gorm:"type:varchar(10);not null" json:"jam_mulai"`
 	JamSelesai  string    `gorm:"type:varchar(10);not null" json:"jam_selesai"`
@@ -18,11 +25,33 @@ type Jadwal struct {
 	UpdatedAt   time.Time `json:"updated_at"`
 }
 
+// BeforeSave menormalkan hari dan jam (HH:MM) supaya bentrok jadwal bisa dihitung
+func (j *Jadwal) BeforeSave(tx *gorm.DB) error {
+	hari, err := utils.NormalizeHari(j.Hari)
+	if err != nil {
+		return err
+	}
+	jamMulai, err := utils.NormalizeJam(j.JamMulai)
+	if err != nil {
+		return err
+	}
+	jamSelesai, err := utils.NormalizeJam(j.JamSelesai)
+	if err != nil {
+		return err
+	}
+	if jamSelesai <= jamMulai {
+		return fmt.Errorf("jam_selesai %s must be after jam_mulai %s", jamSelesai, jamMulai)
+	}
+
+	j.Hari, j.JamMulai, j.JamSelesai = hari, jamMulai, jamSelesai
+	return nil
+}
+
 type JadwalRequest struct {
 	CourseID    uint   `json:"course_id" validate:"required"`
 	Hari        string `json:"hari" validate:"required,oneof=senin selasa rabu kamis jumat sabtu"`
-	JamMulai    string `json:"jam_mulai" validate:"required"`
-	JamSelesai  string `json:"jam_selesai" validate:"required"`
+	JamMulai    string `json:"jam_mulai" validate:"required,jam"`
+	JamSelesai  string `json:"jam_selesai" validate:"required,jam"`
 	Ruangan     string `json:"ruangan" validate:"required"`
 	Dosen       string `json:"dosen" validate:"required"`
 	TipeKelas   string `json:"tipe_kelas" validate:"required,oneof=kuliah ujian praktikum"`
@@ -45,3 +74,18 @@ type JadwalResponse struct {
 	TahunAjaran string    `json:"tahun_ajaran"`
 	CreatedAt   time.Time `json:"created_at"`
 }
+
+// ScheduleClash - Jadwal mata kuliah yang bentrok dengan jadwal KRS mahasiswa
+type ScheduleClash struct {
+	CourseID        uint   `json:"course_id"`
+	KelasID         *uint  `json:"kelas_id,omitempty"`
+	Hari            string `json:"hari"`
+	JamMulai        string `json:"jam_mulai"`
+	JamSelesai      string `json:"jam_selesai"`
+	TipeKelas       string `json:"tipe_kelas"`
+	ClashCourseID   uint   `json:"clash_course_id"`
+	ClashCourseCode string `json:"clash_course_code"`
+	ClashCourseName string `json:"clash_course_name"`
+	ClashJamMulai   string `json:"clash_jam_mulai"`
+	ClashJamSelesai string `json:"clash_jam_selesai"`
+}
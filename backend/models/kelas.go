@@ -0,0 +1,87 @@
+package models
+
+import "time"
+
+// Kelas - Kelas paralel (A/B/C) sebuah mata kuliah pada satu tahun ajaran
+type Kelas struct {
+	ID          uint      `gorm:"primaryKey" json:"id"`
+	CourseID    uint      `gorm:"not null;uniqueIndex:idx_kelas_course_period" json:"course_id"`
+	TahunAjaran string    `gorm:"type:varchar(20);not null;uniqueIndex:idx_kelas_course_period" json:"tahun_ajaran"`
+	Nama        string    `gorm:"type:varchar(10);not null;uniqueIndex:idx_kelas_course_period" json:"nama"`
+	DosenID     *uint     `gorm:"default:null" json:"dosen_id,omitempty"`
+	Kapasitas   int       `gorm:"not null" json:"kapasitas"`
+	Terisi      int       `gorm:"not null;default:0" json:"terisi"` // kursi terpakai, diubah hanya lewat update bersyarat
+	Course      Course    `gorm:"foreignKey:CourseID" json:"course,omitempty"`
+	Dosen       *Dosen    `gorm:"foreignKey:DosenID" json:"dosen,omitempty"`
+	Jadwal      []Jadwal  `gorm:"foreignKey:KelasID" json:"jadwal,omitempty"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+// KelasWaitlist - Antrian FIFO mahasiswa untuk kelas yang penuh (urutan berdasarkan ID)
+type KelasWaitlist struct {
+	ID          uint       `gorm:"primaryKey" json:"id"`
+	KelasID     uint       `gorm:"not null;index" json:"kelas_id"`
+	MahasiswaID uint       `gorm:"not null;index" json:"mahasiswa_id"`
+	Semester    int        `gorm:"not null" json:"semester"`
+	Status      string     `gorm:"type:varchar(20);not null;default:'menunggu'" json:"status"` // menunggu, dipromosikan, dilewati, dibatalkan
+	Note        string     `gorm:"type:text" json:"note,omitempty"`
+	KRSID       *uint      `gorm:"default:null" json:"krs_id,omitempty"`
+	PromotedAt  *time.Time `gorm:"default:null" json:"promoted_at,omitempty"`
+	Kelas       Kelas      `gorm:"foreignKey:KelasID" json:"kelas,omitempty"`
+	Mahasiswa   Mahasiswa  `gorm:"foreignKey:MahasiswaID" json:"mahasiswa,omitempty"`
+	CreatedAt   time.Time  `json:"created_at"`
+	UpdatedAt   time.Time  `json:"updated_at"`
+}
+
+type KelasJadwalItem struct {
+	Hari       string `json:"hari" validate:"required,oneof=senin selasa rabu kamis jumat sabtu"`
+	JamMulai   string `json:"jam_mulai" validate:"required,jam"`
+	JamSelesai string `json:"jam_selesai" validate:"required,jam"`
+	Ruangan    string `json:"ruangan" validate:"required"`
+	TipeKelas  string `json:"tipe_kelas" validate:"required,oneof=kuliah ujian praktikum"`
+}
+
+type KelasRequest struct {
+	CourseID    uint              `json:"course_id" validate:"required"`
+	TahunAjaran string            `json:"tahun_ajaran" validate:"required"`
+	Nama        string            `json:"nama" validate:"required,max=10"`
+	DosenID     uint              `json:"dosen_id" validate:"required"`
+	Kapasitas   int               `json:"kapasitas" validate:"required,min=1,max=500"`
+	Jadwal      []KelasJadwalItem `json:"jadwal" validate:"dive"`
+}
+
+type UpdateKelasRequest struct {
+	DosenID   uint `json:"dosen_id,omitempty"`
+	Kapasitas int  `json:"kapasitas,omitempty" validate:"omitempty,min=1,max=500"`
+}
+
+type KelasResponse struct {
+	ID              uint            `json:"id"`
+	CourseID        uint            `json:"course_id"`
+	TahunAjaran     string          `json:"tahun_ajaran"`
+	Nama            string          `json:"nama"`
+	DosenID         *uint           `json:"dosen_id,omitempty"`
+	DosenNama       string          `json:"dosen_nama,omitempty"`
+	Kapasitas       int             `json:"kapasitas"`
+	Terisi          int             `json:"terisi"`
+	SisaKursi       int             `json:"sisa_kursi"`
+	Waitlist        int64           `json:"waitlist"`
+	Jadwal          []Jadwal        `json:"jadwal,omitempty"`
+	ScheduleClashes []ScheduleClash `json:"schedule_clashes,omitempty"`
+}
+
+type WaitlistResponse struct {
+	ID          uint       `json:"id"`
+	KelasID     uint       `json:"kelas_id"`
+	KelasNama   string     `json:"kelas_nama"`
+	CourseID    uint       `json:"course_id"`
+	CourseCode  string     `json:"course_code"`
+	CourseName  string     `json:"course_name"`
+	TahunAjaran string     `json:"tahun_ajaran"`
+	Position    int64      `json:"position,omitempty"` // posisi antrian, hanya untuk status menunggu
+	Status      string     `json:"status"`
+	Note        string     `json:"note,omitempty"`
+	PromotedAt  *time.Time `json:"promoted_at,omitempty"`
+	CreatedAt   time.Time  `json:"created_at"`
+}
@@ -1,27 +1,73 @@
 package models
 
-import "time"
+import (
+	"fmt"
+	"time"
+)
+
+// Status persetujuan KRS
+const (
+	KRSDraft             = "draft"
+	KRSSubmitted         = "submitted"
+	KRSApprovedWali      = "approved_wali"
+	KRSValidatedKajur    = "validated_kajur"
+	KRSRejected          = "rejected"
+	KRSRevisionRequested = "revision_requested"
+)
+
+// krsTransitions - state machine: status sekarang -> role -> aksi -> status berikutnya.
+// Dosen wali hanya memproses KRS yang diajukan, kajur hanya memvalidasi yang sudah disetujui dosen wali.
+var krsTransitions = map[string]map[string]map[string]string{
+	KRSDraft:             {"mahasiswa": {"submit": KRSSubmitted}},
+	KRSRevisionRequested: {"mahasiswa": {"submit": KRSSubmitted}},
+	KRSSubmitted: {"dosen_wali": {
+		"approve":          KRSApprovedWali,
+		"reject":           KRSRejected,
+		"request_revision": KRSRevisionRequested,
+	}},
+	KRSApprovedWali: {"kajur": {
+		"approve":          KRSValidatedKajur,
+		"reject":           KRSRejected,
+		"request_revision": KRSRevisionRequested,
+	}},
+}
+
+// NextKRSStatus mengembalikan status hasil aksi oleh role tersebut, error jika transisi tidak diizinkan
+func NextKRSStatus(current, role, action string) (string, error) {
+	if next, ok := krsTransitions[current][role][action]; ok {
+		return next, nil
+	}
+	return "", fmt.Errorf("%s cannot %s a KRS with status %s", role, action, current)
+}
 
 type KRS struct {
 	ID              uint       `gorm:"primaryKey" json:"id"`
 	MahasiswaID     uint       `gorm:"not null" json:"mahasiswa_id"`
 	CourseID        uint       `gorm:"not null" json:"course_id"`
+	KelasID         *uint      `gorm:"default:null;index" json:"kelas_id,omitempty"`
 	Semester        int        `gorm:"not null" json:"semester"`
 	TahunAjaran     string     `gorm:"type:varchar(20);not null" json:"tahun_ajaran"`
 	Status          string     `gorm:"type:varchar(20);default:'pending'" json:"status"`
-	ApprovalStatus  string     `gorm:"type:varchar(20);default:'pending'" json:"approval_status"`
-	ApprovedBy      *uint      `gorm:"default:null" json:"approved_by,omitempty"`
-	ApprovedAt      *time.Time `gorm:"default:null" json:"approved_at,omitempty"`
+	ApprovalStatus  string     `gorm:"type:varchar(20);default:'draft';index" json:"approval_status"`
+	SubmittedAt     *time.Time `gorm:"default:null" json:"submitted_at,omitempty"`
+	WaliApprovedBy  *uint      `gorm:"default:null" json:"wali_approved_by,omitempty"` // dosen ID
+	WaliApprovedAt  *time.Time `gorm:"default:null" json:"wali_approved_at,omitempty"`
+	ValidatedBy     *uint      `gorm:"default:null" json:"validated_by,omitempty"` // kajur ID
+	ValidatedAt     *time.Time `gorm:"default:null" json:"validated_at,omitempty"`
 	RejectionReason string     `gorm:"type:text" json:"rejection_reason,omitempty"`
+	RevisionNote    string     `gorm:"type:text" json:"revision_note,omitempty"`
 	Mahasiswa       Mahasiswa  `gorm:"foreignKey:MahasiswaID" json:"mahasiswa,omitempty"`
 	Course          Course     `gorm:"foreignKey:CourseID" json:"course,omitempty"`
-	Approver        *Dosen     `gorm:"foreignKey:ApprovedBy" json:"approver,omitempty"`
+	Kelas           *Kelas     `gorm:"foreignKey:KelasID" json:"kelas,omitempty"`
+	WaliApprover    *Dosen     `gorm:"foreignKey:WaliApprovedBy" json:"wali_approver,omitempty"`
+	Validator       *Kajur     `gorm:"foreignKey:ValidatedBy" json:"validator,omitempty"`
 	CreatedAt       time.Time  `json:"created_at"`
 	UpdatedAt       time.Time  `json:"updated_at"`
 }
 
 type KRSRequest struct {
 	CourseID    uint   `json:"course_id" validate:"required"`
+	KelasID     uint   `json:"kelas_id,omitempty"` // wajib jika mata kuliah punya kelas paralel di tahun ajaran itu
 	Semester    int    `json:"semester" validate:"required,min=1,max=14"`
 	TahunAjaran string `json:"tahun_ajaran" validate:"required"`
 }
@@ -31,18 +77,83 @@ type KRSResponse struct {
 	CourseID        uint       `json:"course_id"`
 	CourseName      string     `json:"course_name"`
 	CourseCode      string     `json:"course_code"`
+	KelasID         *uint      `json:"kelas_id,omitempty"`
+	KelasNama       string     `json:"kelas_nama,omitempty"`
 	Credits         int        `json:"credits"`
 	Semester        int        `json:"semester"`
 	TahunAjaran     string     `json:"tahun_ajaran"`
 	Status          string     `json:"status"`
 	ApprovalStatus  string     `json:"approval_status"`
-	ApprovedBy      *uint      `json:"approved_by,omitempty"`
-	ApprovedAt      *time.Time `json:"approved_at,omitempty"`
+	SubmittedAt     *time.Time `json:"submitted_at,omitempty"`
+	WaliApprovedBy  *uint      `json:"wali_approved_by,omitempty"`
+	WaliApprovedAt  *time.Time `json:"wali_approved_at,omitempty"`
+	ValidatedBy     *uint      `json:"validated_by,omitempty"`
+	ValidatedAt     *time.Time `json:"validated_at,omitempty"`
 	RejectionReason string     `json:"rejection_reason,omitempty"`
+	RevisionNote    string     `json:"revision_note,omitempty"`
 	CreatedAt       time.Time  `json:"created_at"`
 }
 
+// KRSApprovalRequest - rejection_reason berisi alasan penolakan atau catatan revisi
 type KRSApprovalRequest struct {
-	Action          string `json:"action" validate:"required,oneof=approve reject"`
-	RejectionReason string `json:"rejection_reason"`
+	Action          string `json:"action" validate:"required,oneof=approve reject request_revision"`
+	RejectionReason string `json:"rejection_reason" validate:"required_unless=Action approve"`
+}
+
+// KRSApprovalHistory - Riwayat transisi status persetujuan KRS
+type KRSApprovalHistory struct {
+	ID         uint      `gorm:"primaryKey" json:"id"`
+	KRSID      uint      `gorm:"not null;index" json:"krs_id"`
+	FromStatus string    `gorm:"type:varchar(20);not null" json:"from_status"`
+	ToStatus   string    `gorm:"type:varchar(20);not null" json:"to_status"`
+	Action     string    `gorm:"type:varchar(20);not null" json:"action"`
+	ActorID    uint      `gorm:"not null" json:"actor_id"` // user ID
+	ActorRole  string    `gorm:"type:varchar(20);not null" json:"actor_role"`
+	Note       string    `gorm:"type:text" json:"note,omitempty"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+// KRSPackageRequest - Seluruh KRS mahasiswa pada satu semester dan tahun ajaran
+type KRSPackageRequest struct {
+	Semester    int    `json:"semester" validate:"required,min=1,max=14"`
+	TahunAjaran string `json:"tahun_ajaran" validate:"required"`
+}
+
+type KRSItemNote struct {
+	KRSID uint   `json:"krs_id" validate:"required"`
+	Note  string `json:"note" validate:"required"`
+}
+
+// KRSPackageApprovalRequest - Keputusan dosen wali untuk satu paket KRS, catatan per mata kuliah opsional
+type KRSPackageApprovalRequest struct {
+	Semester    int           `json:"semester" validate:"required,min=1,max=14"`
+	TahunAjaran string        `json:"tahun_ajaran" validate:"required"`
+	Action      string        `json:"action" validate:"required,oneof=approve reject request_revision"`
+	Note        string        `json:"note"`
+	Items       []KRSItemNote `json:"items" validate:"dive"`
+}
+
+type KRSPackageRef struct {
+	MahasiswaID uint   `json:"mahasiswa_id" validate:"required"`
+	Semester    int    `json:"semester" validate:"required,min=1,max=14"`
+	TahunAjaran string `json:"tahun_ajaran" validate:"required"`
+}
+
+// BulkKRSValidationRequest - Validasi kajur untuk banyak paket KRS sekaligus (semua atau tidak sama sekali)
+type BulkKRSValidationRequest struct {
+	Packages []KRSPackageRef `json:"packages" validate:"required,min=1,max=200,dive"`
+	Action   string          `json:"action" validate:"required,oneof=approve reject request_revision"`
+	Note     string          `json:"note" validate:"required_unless=Action approve"`
+}
+
+// KRSPackageReview - Ringkasan paket KRS untuk ditinjau dosen wali
+type KRSPackageReview struct {
+	Mahasiswa       MahasiswaResponse `json:"mahasiswa"`
+	IPK             float64           `json:"ipk"`
+	Semester        int               `json:"semester"`
+	TahunAjaran     string            `json:"tahun_ajaran"`
+	SKS             SKSQuota          `json:"sks"`
+	Status          map[string]int    `json:"status"` // jumlah mata kuliah per approval_status
+	Items           []KRSResponse     `json:"items"`
+	ScheduleClashes []ScheduleClash   `json:"schedule_clashes"`
 }
@@ -0,0 +1,49 @@
+package models
+
+import "testing"
+
+func TestNextKRSStatus(t *testing.T) {
+	tests := []struct {
+		current, role, action string
+		want                  string // kosong berarti transisi ditolak
+	}{
+		// Transisi yang diizinkan
+		{KRSDraft, "mahasiswa", "submit", KRSSubmitted},
+		{KRSRevisionRequested, "mahasiswa", "submit", KRSSubmitted},
+		{KRSSubmitted, "dosen_wali", "approve", KRSApprovedWali},
+		{KRSSubmitted, "dosen_wali", "reject", KRSRejected},
+		{KRSSubmitted, "dosen_wali", "request_revision", KRSRevisionRequested},
+		{KRSApprovedWali, "kajur", "approve", KRSValidatedKajur},
+		{KRSApprovedWali, "kajur", "reject", KRSRejected},
+		{KRSApprovedWali, "kajur", "request_revision", KRSRevisionRequested},
+
+		// Transisi yang ditolak
+		{KRSSubmitted, "mahasiswa", "submit", ""},
+		{KRSDraft, "dosen_wali", "approve", ""},
+		{KRSSubmitted, "kajur", "approve", ""},
+		{KRSApprovedWali, "dosen_wali", "approve", ""},
+		{KRSValidatedKajur, "kajur", "reject", ""},
+		{KRSValidatedKajur, "mahasiswa", "submit", ""},
+		{KRSRejected, "mahasiswa", "submit", ""},
+		{KRSRejected, "dosen_wali", "approve", ""},
+		{KRSSubmitted, "dosen_wali", "validate", ""},
+		{"unknown", "mahasiswa", "submit", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.current+"/"+tt.role+"/"+tt.action, func(t *testing.T) {
+			got, err := NextKRSStatus(tt.current, tt.role, tt.action)
+			if tt.want == "" {
+				if err == nil {
+					t.Fatalf("expected error, got %q", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
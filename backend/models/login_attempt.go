@@ -0,0 +1,31 @@
+package models
+
+import "time"
+
+// LoginAttempt - Catatan setiap percobaan login, dipakai untuk membatasi brute-force per identifier dan per IP
+type LoginAttempt struct {
+	ID         uint      `gorm:"primaryKey" json:"id"`
+	Identifier string    `gorm:"type:varchar(100);not null;index" json:"identifier"`
+	IPAddress  string    `gorm:"type:varchar(45);not null;index" json:"ip_address"`
+	UserID     *uint     `gorm:"index" json:"user_id,omitempty"`
+	Success    bool      `gorm:"not null;default:false" json:"success"`
+	CreatedAt  time.Time `gorm:"index" json:"created_at"`
+}
+
+// AccountLockoutEvent - Riwayat kunci/buka akun yang bisa diaudit
+type AccountLockoutEvent struct {
+	ID             uint       `gorm:"primaryKey" json:"id"`
+	UserID         uint       `gorm:"not null;index" json:"user_id"`
+	Event          string     `gorm:"type:varchar(20);not null" json:"event"` // locked, unlocked
+	Reason         string     `gorm:"type:text" json:"reason"`
+	IPAddress      string     `gorm:"type:varchar(45)" json:"ip_address"`
+	FailedAttempts int        `json:"failed_attempts"`
+	LockedUntil    *time.Time `gorm:"default:null" json:"locked_until,omitempty"`
+	ActorID        *uint      `gorm:"default:null" json:"actor_id,omitempty"`
+	User           Users      `gorm:"foreignKey:UserID" json:"-"`
+	CreatedAt      time.Time  `json:"created_at"`
+}
+
+type UnlockAccountRequest struct {
+	Reason string `json:"reason" validate:"required,min=5"`
+}
@@ -11,6 +11,7 @@ type Mahasiswa struct {
 	PhoneNumber    string    `gorm:"type:varchar(20)" json:"phone_number,omitempty"`
 	StatusAkademik string    `gorm:"type:varchar(20);default:'aktif'" json:"status_akademik"`
 	Semester       int       `gorm:"default:1" json:"semester"`
+	Angkatan       int       `gorm:"default:0" json:"angkatan"` // 0 = pakai tahun pendaftaran
 	IPK            float64   `gorm:"type:decimal(3,2);default:0.00" json:"ipk"`
 	DosenWaliID    *uint     `gorm:"default:null" json:"dosen_wali_id,omitempty"`
 	Courses        []Course  `gorm:"many2many:mahasiswa_courses;" json:"courses,omitempty"`
@@ -2,44 +2,80 @@ package models
 
 import "time"
 
+// GradePoints - bobot nilai huruf standar, dipakai sebagai batas minimum prasyarat
+var GradePoints = map[string]float64{
+	"A": 4.0, "AB": 3.5, "B": 3.0, "BC": 2.5, "C": 2.0, "D": 1.0, "E": 0.0,
+}
+
+// MeetsMinimumPoint mengecek apakah bobot nilai yang dicapai sama atau lebih baik dari bobot minGrade.
+// Dibandingkan lewat bobot supaya nilai dari skala lain (A-, B+) tetap bisa dinilai.
+func MeetsMinimumPoint(point float64, minGrade string) bool {
+	minPoint, ok := GradePoints[minGrade]
+	return ok && point >= minPoint
+}
+
+// Status nilai: draft sudah diisi dosen tetapi belum dipublikasikan (tidak terlihat mahasiswa, tidak dihitung di IPK)
+const (
+	NilaiBelumDinilai = "belum_dinilai"
+	NilaiDraft        = "draft"
+	NilaiPublished    = "sudah_dinilai"
+)
+
+// Kebijakan nilai mata kuliah yang diulang: nilai terbaik atau nilai percobaan terakhir yang dihitung di IPK
+const (
+	RetakeBest   = "best"
+	RetakeLatest = "latest"
+)
+
+// Nilai - satu baris per percobaan; mengulang mata kuliah menambah baris dengan Attempt berikutnya
 type Nilai struct {
-	ID          uint      `gorm:"primaryKey" json:"id"`
-	MahasiswaID uint      `gorm:"not null" json:"mahasiswa_id"`
-	CourseID    uint      `gorm:"not null" json:"course_id"`
-	Semester    int       `gorm:"not null" json:"semester"`
-	TahunAjaran string    `gorm:"type:varchar(20);not null" json:"tahun_ajaran"`
-	NilaiTugas  float64   `gorm:"type:decimal(5,2);default:0" json:"nilai_tugas"`
-	NilaiUTS    float64   `gorm:"type:decimal(5,2);default:0" json:"nilai_uts"`
-	NilaiUAS    float64   `gorm:"type:decimal(5,2);default:0" json:"nilai_uas"`
-	NilaiAkhir  float64   `gorm:"type:decimal(5,2);default:0" json:"nilai_akhir"`
-	GradeHuruf  string    `gorm:"type:varchar(2)" json:"grade_huruf"`
-	GradePoint  float64   `gorm:"type:decimal(3,2);default:0" json:"grade_point"`
-	Status      string    `gorm:"type:varchar(20);default:'belum_dinilai'" json:"status"`
-	Mahasiswa   Mahasiswa `gorm:"foreignKey:MahasiswaID" json:"mahasiswa,omitempty"`
-	Course      Course    `gorm:"foreignKey:CourseID" json:"course,omitempty"`
-	CreatedAt   time.Time `json:"created_at"`
-	UpdatedAt   time.Time `json:"updated_at"`
+	ID           uint            `gorm:"primaryKey" json:"id"`
+	MahasiswaID  uint            `gorm:"not null;uniqueIndex:idx_nilai_attempt" json:"mahasiswa_id"`
+	CourseID     uint            `gorm:"not null;uniqueIndex:idx_nilai_attempt" json:"course_id"`
+	Semester     int             `gorm:"not null;uniqueIndex:idx_nilai_attempt" json:"semester"`
+	TahunAjaran  string          `gorm:"type:varchar(20);not null;uniqueIndex:idx_nilai_attempt" json:"tahun_ajaran"`
+	Attempt      int             `gorm:"not null;default:1" json:"attempt"`
+	KRSID        *uint           `gorm:"index" json:"krs_id,omitempty"`
+	NilaiTugas   float64         `gorm:"type:decimal(5,2);default:0" json:"nilai_tugas"`
+	NilaiUTS     float64         `gorm:"type:decimal(5,2);default:0" json:"nilai_uts"`
+	NilaiUAS     float64         `gorm:"type:decimal(5,2);default:0" json:"nilai_uas"`
+	NilaiAkhir   float64         `gorm:"type:decimal(5,2);default:0" json:"nilai_akhir"`
+	GradeHuruf   string          `gorm:"type:varchar(3)" json:"grade_huruf"`
+	GradePoint   float64         `gorm:"type:decimal(3,2);default:0" json:"grade_point"`
+	GradeScaleID *uint           `gorm:"index" json:"grade_scale_id,omitempty"` // skala konversi yang menghasilkan grade
+	Status       string          `gorm:"type:varchar(20);default:'belum_dinilai'" json:"status"`
+	Komponen     []NilaiKomponen `gorm:"foreignKey:NilaiID" json:"komponen,omitempty"`
+	KRS          *KRS            `gorm:"foreignKey:KRSID" json:"-"`
+	Mahasiswa    Mahasiswa       `gorm:"foreignKey:MahasiswaID" json:"mahasiswa,omitempty"`
+	Course       Course          `gorm:"foreignKey:CourseID" json:"course,omitempty"`
+	CreatedAt    time.Time       `json:"created_at"`
+	UpdatedAt    time.Time       `json:"updated_at"`
 }
 
 type NilaiResponse struct {
-	ID          uint      `json:"id"`
-	CourseCode  string    `json:"course_code"`
-	CourseName  string    `json:"course_name"`
-	Credits     int       `json:"credits"`
-	Semester    int       `json:"semester"`
-	TahunAjaran string    `json:"tahun_ajaran"`
-	NilaiTugas  float64   `json:"nilai_tugas"`
-	NilaiUTS    float64   `json:"nilai_uts"`
-	NilaiUAS    float64   `json:"nilai_uas"`
-	NilaiAkhir  float64   `json:"nilai_akhir"`
-	GradeHuruf  string    `json:"grade_huruf"`
-	GradePoint  float64   `json:"grade_point"`
-	Status      string    `json:"status"`
-	CreatedAt   time.Time `json:"created_at"`
+	ID           uint                    `json:"id"`
+	CourseCode   string                  `json:"course_code"`
+	CourseName   string                  `json:"course_name"`
+	Credits      int                     `json:"credits"`
+	Semester     int                     `json:"semester"`
+	TahunAjaran  string                  `json:"tahun_ajaran"`
+	NilaiTugas   float64                 `json:"nilai_tugas"`
+	NilaiUTS     float64                 `json:"nilai_uts"`
+	NilaiUAS     float64                 `json:"nilai_uas"`
+	NilaiAkhir   float64                 `json:"nilai_akhir"`
+	GradeHuruf   string                  `json:"grade_huruf"`
+	GradePoint   float64                 `json:"grade_point"`
+	Status       string                  `json:"status"`
+	Attempt      int                     `json:"attempt"`
+	Superseded   bool                    `json:"superseded"`               // percobaan yang tidak dihitung menurut kebijakan mengulang
+	GradeScaleID *uint                   `json:"grade_scale_id,omitempty"` // skala yang menghasilkan grade
+	Komponen     []NilaiKomponenResponse `json:"komponen,omitempty"`
+	CreatedAt    time.Time               `json:"created_at"`
 }
 
 type TranskripResponse struct {
 	Mahasiswa       MahasiswaResponse `json:"mahasiswa"`
+	RetakePolicy    string            `json:"retake_policy"`
 	TotalSKS        int               `json:"total_sks"`
 	TotalSKSLulus   int               `json:"total_sks_lulus"`
 	IPKKumulatif    float64           `json:"ipk_kumulatif"`
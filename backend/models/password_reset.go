@@ -0,0 +1,31 @@
+package models
+
+import "time"
+
+// PasswordReset - Kode OTP sekali pakai untuk reset password (yang disimpan hanya hash-nya)
+type PasswordReset struct {
+	ID          uint       `gorm:"primaryKey" json:"id"`
+	UserID      uint       `gorm:"not null;index" json:"user_id"`
+	CodeHash    string     `gorm:"not null" json:"-"`
+	Channel     string     `gorm:"type:varchar(20);not null" json:"channel"` // whatsapp, email
+	Destination string     `gorm:"type:varchar(100)" json:"destination"`
+	ExpiresAt   time.Time  `gorm:"not null" json:"expires_at"`
+	Attempts    int        `gorm:"default:0" json:"attempts"`
+	MaxAttempts int        `gorm:"not null" json:"max_attempts"`
+	UsedAt      *time.Time `gorm:"default:null" json:"used_at,omitempty"`
+	IPAddress   string     `gorm:"type:varchar(45)" json:"ip_address"`
+	User        Users      `gorm:"foreignKey:UserID" json:"-"`
+	CreatedAt   time.Time  `json:"created_at"`
+	UpdatedAt   time.Time  `json:"updated_at"`
+}
+
+type ForgotPasswordRequest struct {
+	Identifier string `json:"identifier" validate:"required"` // username, email, atau NIM
+	Channel    string `json:"channel" validate:"required,oneof=whatsapp email"`
+}
+
+type ResetPasswordRequest struct {
+	Identifier  string `json:"identifier" validate:"required"`
+	Code        string `json:"code" validate:"required,len=6,numeric"`
+	NewPassword string `json:"new_password" validate:"required,min=6"`
+}
@@ -0,0 +1,109 @@
+package models
+
+import (
+	"fmt"
+	"time"
+)
+
+// Status kebijakan akademik
+const (
+	PolicyDraft             = "draft"
+	PolicySubmitted         = "submitted"
+	PolicyRevisionRequested = "revision_requested"
+	PolicyApproved          = "approved"
+	PolicyRejected          = "rejected"
+)
+
+// policyTransitions - state machine: status sekarang -> aksi -> status berikutnya
+var policyTransitions = map[string]map[string]string{
+	PolicyDraft:             {"submit": PolicySubmitted},
+	PolicyRevisionRequested: {"submit": PolicySubmitted},
+	PolicySubmitted: {
+		"approve":          PolicyApproved,
+		"reject":           PolicyRejected,
+		"request_revision": PolicyRevisionRequested,
+	},
+}
+
+// NextPolicyStatus mengembalikan status hasil aksi, error jika transisi tidak diizinkan
+func NextPolicyStatus(current, action string) (string, error) {
+	if next, ok := policyTransitions[current][action]; ok {
+		return next, nil
+	}
+	return "", fmt.Errorf("cannot %s a policy with status %s", action, current)
+}
+
+// Policy - Kebijakan akademik yang diajukan kajur dan diputuskan rektor
+type Policy struct {
+	ID             uint               `gorm:"primaryKey" json:"id"`
+	Title          string             `gorm:"type:varchar(200);not null" json:"title"`
+	Description    string             `gorm:"type:text" json:"description"`
+	Content        string             `gorm:"type:text" json:"content"`
+	Department     string             `gorm:"type:varchar(100);not null;index" json:"department"`
+	Faculty        string             `gorm:"type:varchar(100);index" json:"faculty"`
+	Status         string             `gorm:"type:varchar(30);not null;default:'draft';index" json:"status"`
+	CurrentVersion int                `gorm:"default:0" json:"current_version"` // versi revisi terakhir yang diajukan
+	CreatedBy      uint               `gorm:"not null" json:"created_by"`
+	SubmittedAt    *time.Time         `gorm:"default:null" json:"submitted_at,omitempty"`
+	DecidedBy      *uint              `gorm:"default:null" json:"decided_by,omitempty"`
+	DecidedAt      *time.Time         `gorm:"default:null" json:"decided_at,omitempty"`
+	Creator        Users              `gorm:"foreignKey:CreatedBy" json:"-"`
+	Revisions      []PolicyRevision   `gorm:"foreignKey:PolicyID" json:"revisions,omitempty"`
+	Attachments    []PolicyAttachment `gorm:"foreignKey:PolicyID" json:"attachments,omitempty"`
+	Reviews        []PolicyReview     `gorm:"foreignKey:PolicyID" json:"reviews,omitempty"`
+	CreatedAt      time.Time          `json:"created_at"`
+	UpdatedAt      time.Time          `json:"updated_at"`
+}
+
+// PolicyRevision - Snapshot isi kebijakan setiap kali diajukan
+type PolicyRevision struct {
+	ID          uint      `gorm:"primaryKey" json:"id"`
+	PolicyID    uint      `gorm:"not null;uniqueIndex:idx_policy_version" json:"policy_id"`
+	Version     int       `gorm:"not null;uniqueIndex:idx_policy_version" json:"version"`
+	Title       string    `gorm:"type:varchar(200);not null" json:"title"`
+	Description string    `gorm:"type:text" json:"description"`
+	Content     string    `gorm:"type:text" json:"content"`
+	ChangeNote  string    `gorm:"type:text" json:"change_note"`
+	SubmittedBy uint      `gorm:"not null" json:"submitted_by"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// PolicyAttachment - Lampiran kebijakan, Version = revisi berikutnya saat diunggah
+type PolicyAttachment struct {
+	ID         uint      `gorm:"primaryKey" json:"id"`
+	PolicyID   uint      `gorm:"not null;index" json:"policy_id"`
+	Version    int       `gorm:"not null" json:"version"`
+	FileName   string    `gorm:"type:varchar(255);not null" json:"file_name"`
+	FilePath   string    `gorm:"type:varchar(500);not null" json:"-"`
+	FileSize   int64     `gorm:"default:0" json:"file_size"`
+	MimeType   string    `gorm:"type:varchar(100)" json:"mime_type"`
+	UploadedBy uint      `gorm:"not null" json:"uploaded_by"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+// PolicyReview - Keputusan/komentar rektor per versi
+type PolicyReview struct {
+	ID         uint      `gorm:"primaryKey" json:"id"`
+	PolicyID   uint      `gorm:"not null;index" json:"policy_id"`
+	Version    int       `gorm:"not null" json:"version"`
+	Action     string    `gorm:"type:varchar(30);not null" json:"action"` // approve, reject, request_revision
+	Comments   string    `gorm:"type:text" json:"comments"`
+	ReviewerID uint      `gorm:"not null" json:"reviewer_id"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+type PolicyRequest struct {
+	Title       string `json:"title" validate:"required,min=5,max=200"`
+	Description string `json:"description" validate:"required,min=10"`
+	Content     string `json:"content,omitempty"`
+	Faculty     string `json:"faculty,omitempty" validate:"omitempty,max=100"`
+}
+
+type PolicySubmitRequest struct {
+	ChangeNote string `json:"change_note,omitempty" validate:"omitempty,max=1000"`
+}
+
+type PolicyApprovalRequest struct {
+	Action   string `json:"action" validate:"required,oneof=approve reject request_revision"`
+	Comments string `json:"comments" validate:"required_unless=Action approve"`
+}
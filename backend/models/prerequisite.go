@@ -0,0 +1,52 @@
+package models
+
+import "time"
+
+// CoursePrerequisite - Relasi prasyarat: CourseID hanya boleh diambil jika PrerequisiteID lulus minimal MinGrade
+type CoursePrerequisite struct {
+	ID             uint      `gorm:"primaryKey" json:"id"`
+	CourseID       uint      `gorm:"not null;uniqueIndex:idx_course_prerequisite" json:"course_id"`
+	PrerequisiteID uint      `gorm:"not null;uniqueIndex:idx_course_prerequisite;index" json:"prerequisite_id"`
+	MinGrade       string    `gorm:"type:varchar(2);not null;default:'D'" json:"min_grade"`
+	Prerequisite   Course    `gorm:"foreignKey:PrerequisiteID" json:"-"`
+	CreatedAt      time.Time `json:"created_at"`
+}
+
+// UnmetPrerequisite - Detail prasyarat yang belum terpenuhi untuk ditampilkan ke mahasiswa
+type UnmetPrerequisite struct {
+	CourseID      uint   `json:"course_id"`
+	CourseCode    string `json:"course_code"`
+	CourseName    string `json:"course_name"`
+	MinGrade      string `json:"min_grade"`
+	AchievedGrade string `json:"achieved_grade,omitempty"` // kosong jika belum pernah dinilai
+}
+
+type PrerequisiteItem struct {
+	PrerequisiteID uint   `json:"prerequisite_id" validate:"required"`
+	MinGrade       string `json:"min_grade" validate:"required,oneof=A AB B BC C D E"`
+}
+
+type SetPrerequisitesRequest struct {
+	Prerequisites []PrerequisiteItem `json:"prerequisites" validate:"dive"`
+}
+
+type PrerequisiteResponse struct {
+	PrerequisiteID uint   `json:"prerequisite_id"`
+	CourseCode     string `json:"course_code"`
+	CourseName     string `json:"course_name"`
+	MinGrade       string `json:"min_grade"`
+}
+
+type MigratePrasyaratRequest struct {
+	DefaultMinGrade string `json:"default_min_grade,omitempty" validate:"omitempty,oneof=A AB B BC C D E"`
+	DryRun          bool   `json:"dry_run"`
+}
+
+// AvailableCourseResponse - Mata kuliah yang ditawarkan beserta status prasyarat dan bentrok jadwalnya
+type AvailableCourseResponse struct {
+	Course
+	Eligible           bool                `json:"eligible"`
+	UnmetPrerequisites []UnmetPrerequisite `json:"unmet_prerequisites,omitempty"`
+	ScheduleClashes    []ScheduleClash     `json:"schedule_clashes,omitempty"`
+	Kelas              []KelasResponse     `json:"kelas,omitempty"`
+}
@@ -0,0 +1,23 @@
+package models
+
+// CourseRecommendation - Saran mata kuliah untuk KRS beserta skor dan alasannya
+type CourseRecommendation struct {
+	Course
+	Score              int                 `json:"score"`
+	Recommended        bool                `json:"recommended"` // prasyarat terpenuhi dan jadwal muat
+	FitsQuota          bool                `json:"fits_quota"`  // masuk dalam sisa SKS menurut urutan saran
+	Retake             bool                `json:"retake"`
+	PreviousGrade      string              `json:"previous_grade,omitempty"`
+	Reasons            []string            `json:"reasons"`
+	UnmetPrerequisites []UnmetPrerequisite `json:"unmet_prerequisites,omitempty"`
+	ScheduleClashes    []ScheduleClash     `json:"schedule_clashes,omitempty"`
+	Kelas              []KelasResponse     `json:"kelas,omitempty"`
+}
+
+type KRSRecommendationResponse struct {
+	Semester        int                    `json:"semester"`
+	TahunAjaran     string                 `json:"tahun_ajaran"`
+	SKS             SKSQuota               `json:"sks"`
+	SuggestedSKS    int                    `json:"suggested_sks"`
+	Recommendations []CourseRecommendation `json:"recommendations"`
+}
@@ -4,6 +4,7 @@ import "time"
 
 type Rektor struct {
 	ID        uint      `gorm:"primaryKey" json:"id"`
+	UserID    *uint     `gorm:"index" json:"user_id,omitempty"`
 	NIDN      string    `gorm:"unique;not null" json:"nidn" validate:"required,min=8,max=20"`
 	Nama      string    `gorm:"type:varchar(100);not null" json:"nama" validate:"required,min=2,max=100"`
 	Email     string    `gorm:"type:varchar(100);unique;not null" json:"email" validate:"required,email"`
@@ -103,47 +104,33 @@ type UniversityAlert struct {
 
 // Role Management Structures
 type RoleAssignmentRequest struct {
-	UserID        uint   `json:"user_id" validate:"required"`
+	DosenID       uint   `json:"dosen_id" validate:"required"` // Dosen.ID (bukan Users.ID) yang ditugaskan
 	UserType      string `json:"user_type" validate:"required,oneof=dosen"`
 	NewRole       string `json:"new_role" validate:"required,oneof=dosen kajur dekan"`
-	Department    string `json:"department,omitempty"`
-	Faculty       string `json:"faculty,omitempty"`
-	EffectiveDate string `json:"effective_date" validate:"required"`
+	Department    string `json:"department,omitempty" validate:"required_if=NewRole kajur"`
+	Faculty       string `json:"faculty,omitempty" validate:"required_if=NewRole dekan"`
+	EffectiveDate string `json:"effective_date" validate:"required,datetime=2006-01-02"`
+	EndDate       string `json:"end_date,omitempty" validate:"omitempty,datetime=2006-01-02"`
 	Reason        string `json:"reason" validate:"required,min=10"`
 }
 
 type RoleAssignmentResponse struct {
-	AssignmentID  uint      `json:"assignment_id"`
-	UserID        uint      `json:"user_id"`
-	UserName      string    `json:"user_name"`
-	UserNIDN      string    `json:"user_nidn"`
-	OldRole       string    `json:"old_role"`
-	NewRole       string    `json:"new_role"`
-	Department    string    `json:"department,omitempty"`
-	Faculty       string    `json:"faculty,omitempty"`
-	EffectiveDate time.Time `json:"effective_date"`
-	AssignedBy    string    `json:"assigned_by"`
-	Reason        string    `json:"reason"`
-	Status        string    `json:"status"`
-	CreatedAt     time.Time `json:"created_at"`
-}
-
-type PolicyApprovalRequest struct {
-	PolicyID     uint   `json:"policy_id" validate:"required"`
-	Action       string `json:"action" validate:"required,oneof=approve reject"`
-	Comments     string `json:"comments,omitempty"`
-	ApprovalDate string `json:"approval_date" validate:"required"`
-}
-
-type PolicyApprovalResponse struct {
-	PolicyID     uint      `json:"policy_id"`
-	PolicyTitle  string    `json:"policy_title"`
-	SubmittedBy  string    `json:"submitted_by"`
-	Department   string    `json:"department"`
-	Faculty      string    `json:"faculty"`
-	Action       string    `json:"action"`
-	ApprovedBy   string    `json:"approved_by"`
-	Comments     string    `json:"comments"`
-	ApprovalDate time.Time `json:"approval_date"`
-	Status       string    `json:"status"`
+	AssignmentID  uint       `json:"assignment_id"`
+	UserID        uint       `json:"user_id"`
+	DosenID       uint       `json:"dosen_id"`
+	UserName      string     `json:"user_name"`
+	UserNIDN      string     `json:"user_nidn"`
+	OldRole       string     `json:"old_role"`
+	NewRole       string     `json:"new_role"`
+	Department    string     `json:"department,omitempty"`
+	Faculty       string     `json:"faculty,omitempty"`
+	EffectiveDate time.Time  `json:"effective_date"`
+	EndDate       *time.Time `json:"end_date,omitempty"`
+	AssignedBy    string     `json:"assigned_by"`
+	Reason        string     `json:"reason"`
+	Status        string     `json:"status"`
+	AppliedAt     *time.Time `json:"applied_at,omitempty"`
+	EndedAt       *time.Time `json:"ended_at,omitempty"`
+	EndReason     string     `json:"end_reason,omitempty"`
+	CreatedAt     time.Time  `json:"created_at"`
 }
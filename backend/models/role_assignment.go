@@ -0,0 +1,32 @@
+package models
+
+import "time"
+
+// RoleAssignment - Penugasan jabatan (kajur/dekan) dengan tanggal berlaku dan masa jabatan
+type RoleAssignment struct {
+	ID            uint       `gorm:"primaryKey" json:"id"`
+	UserID        uint       `gorm:"not null;index" json:"user_id"`
+	DosenID       uint       `gorm:"not null;index" json:"dosen_id"`
+	PreviousRole  string     `gorm:"type:varchar(20)" json:"previous_role"`
+	Role          string     `gorm:"type:varchar(20);not null" json:"role"` // dosen, kajur, dekan
+	Jurusan       string     `gorm:"type:varchar(100);index" json:"jurusan,omitempty"`
+	Faculty       string     `gorm:"type:varchar(100);index" json:"faculty,omitempty"`
+	EffectiveDate time.Time  `gorm:"not null;index" json:"effective_date"`
+	EndDate       *time.Time `gorm:"default:null" json:"end_date,omitempty"`                            // akhir masa jabatan, null = sampai diganti
+	Status        string     `gorm:"type:varchar(20);not null;default:'scheduled';index" json:"status"` // scheduled, active, ended, revoked
+	Reason        string     `gorm:"type:text" json:"reason"`
+	AssignedBy    uint       `gorm:"not null" json:"assigned_by"`
+	AppliedAt     *time.Time `gorm:"default:null" json:"applied_at,omitempty"`
+	EndedAt       *time.Time `gorm:"default:null" json:"ended_at,omitempty"`
+	EndReason     string     `gorm:"type:text" json:"end_reason,omitempty"`
+	RevokedBy     *uint      `gorm:"default:null" json:"revoked_by,omitempty"`
+	User          Users      `gorm:"foreignKey:UserID" json:"-"`
+	Dosen         Dosen      `gorm:"foreignKey:DosenID" json:"-"`
+	Assigner      Users      `gorm:"foreignKey:AssignedBy" json:"-"`
+	CreatedAt     time.Time  `json:"created_at"`
+	UpdatedAt     time.Time  `json:"updated_at"`
+}
+
+type RevokeRoleAssignmentRequest struct {
+	Reason string `json:"reason" validate:"required,min=5"`
+}
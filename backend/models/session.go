@@ -0,0 +1,36 @@
+package models
+
+import "time"
+
+// UserSession - Sesi login per device, menyimpan hash refresh token yang dirotasi setiap refresh
+type UserSession struct {
+	ID                uint       `gorm:"primaryKey" json:"id"`
+	UserID            uint       `gorm:"not null;index" json:"user_id"`
+	RefreshTokenHash  string     `gorm:"type:varchar(64);uniqueIndex;not null" json:"-"`
+	PreviousTokenHash string     `gorm:"type:varchar(64);index" json:"-"`
+	DeviceName        string     `gorm:"type:varchar(100)" json:"device_name"`
+	UserAgent         string     `gorm:"type:varchar(255)" json:"user_agent"`
+	IPAddress         string     `gorm:"type:varchar(45)" json:"ip_address"`
+	ExpiresAt         time.Time  `gorm:"not null" json:"expires_at"`
+	LastUsedAt        time.Time  `json:"last_used_at"`
+	RevokedAt         *time.Time `gorm:"default:null" json:"revoked_at,omitempty"`
+	RevokedReason     string     `gorm:"type:varchar(50)" json:"revoked_reason,omitempty"`
+	User              Users      `gorm:"foreignKey:UserID" json:"-"`
+	CreatedAt         time.Time  `json:"created_at"`
+	UpdatedAt         time.Time  `json:"updated_at"`
+}
+
+type RefreshTokenRequest struct {
+	RefreshToken string `json:"refresh_token" validate:"required"`
+}
+
+type SessionResponse struct {
+	ID         uint      `json:"id"`
+	DeviceName string    `json:"device_name"`
+	UserAgent  string    `json:"user_agent"`
+	IPAddress  string    `json:"ip_address"`
+	LastUsedAt time.Time `json:"last_used_at"`
+	ExpiresAt  time.Time `json:"expires_at"`
+	Current    bool      `json:"current"`
+	CreatedAt  time.Time `json:"created_at"`
+}
@@ -0,0 +1,41 @@
+package models
+
+import "time"
+
+// SKSLoadRule - Batas SKS per semester berdasarkan IPS semester sebelumnya.
+// Jurusan kosong = aturan default yang dipakai jika jurusan belum punya aturan sendiri.
+type SKSLoadRule struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	Jurusan   string    `gorm:"type:varchar(100);not null;default:'';uniqueIndex:idx_sks_rule" json:"jurusan"`
+	MinIPS    float64   `gorm:"type:decimal(3,2);not null;uniqueIndex:idx_sks_rule" json:"min_ips"`
+	MaxSKS    int       `gorm:"not null" json:"max_sks"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// DefaultSKSLoadRules - dipakai jika tabel aturan masih kosong
+var DefaultSKSLoadRules = []SKSLoadRule{
+	{MinIPS: 3.0, MaxSKS: 24},
+	{MinIPS: 2.5, MaxSKS: 21},
+	{MinIPS: 0, MaxSKS: 18},
+}
+
+type SKSRuleItem struct {
+	MinIPS float64 `json:"min_ips" validate:"min=0,max=4"`
+	MaxSKS int     `json:"max_sks" validate:"required,min=1,max=30"`
+}
+
+type SetSKSRulesRequest struct {
+	Jurusan string        `json:"jurusan,omitempty"` // hanya untuk rektor; kosong = aturan default
+	Rules   []SKSRuleItem `json:"rules" validate:"required,min=1,dive"`
+}
+
+// SKSQuota - Beban SKS mahasiswa pada satu semester
+type SKSQuota struct {
+	Semester      int      `json:"semester"`
+	TahunAjaran   string   `json:"tahun_ajaran"`
+	IPSSebelumnya *float64 `json:"ips_sebelumnya"` // null untuk semester pertama
+	MaxSKS        int      `json:"max_sks"`
+	TotalSKS      int      `json:"total_sks"`
+	SisaSKS       int      `json:"sisa_sks"`
+}
@@ -4,36 +4,47 @@ import "time"
 
 // Users - Tabel utama untuk semua akun yang bisa login
 type Users struct {
-	ID        uint      `gorm:"primaryKey" json:"id"`
-	Username  string    `gorm:"unique;not null" json:"username" validate:"required,min=3,max=50"`
-	Email     string    `gorm:"unique;not null" json:"email" validate:"required,email"`
-	Password  string    `gorm:"not null" json:"-" validate:"required,min=6"`
-	Role      string    `gorm:"type:varchar(20);not null" json:"role" validate:"required,oneof=mahasiswa dosen kajur rektor"`
-	Status    string    `gorm:"type:varchar(20);default:'aktif'" json:"status"`
+	ID       uint   `gorm:"primaryKey" json:"id"`
+	Username string `gorm:"unique;not null" json:"username" validate:"required,min=3,max=50"`
+	Email    string `gorm:"unique;not null" json:"email" validate:"required,email"`
+	Password string `gorm:"not null" json:"-" validate:"required,min=6"`
+	Role     string `gorm:"type:varchar(20);not null" json:"role" validate:"required,oneof=mahasiswa dosen kajur dekan rektor"`
+	Status   string `gorm:"type:varchar(20);default:'aktif'" json:"status"`
+
+	// Proteksi brute-force login
+	FailedLoginCount int        `gorm:"default:0" json:"-"`
+	LockoutCount     int        `gorm:"default:0" json:"-"`
+	LockedUntil      *time.Time `gorm:"default:null" json:"locked_until,omitempty"`
+
+	// Two-factor authentication (TOTP)
+	TwoFactorEnabled  bool   `gorm:"default:false" json:"two_factor_enabled"`
+	TwoFactorSecret   string `gorm:"type:varchar(64)" json:"-"`
+	TwoFactorLastStep int64  `gorm:"default:0" json:"-"`
+
 	CreatedAt time.Time `json:"created_at"`
 	UpdatedAt time.Time `json:"updated_at"`
 }
 
 // Request & Response structures for new architecture
+// UserRegistrationRequest - Registrasi mandiri, hanya untuk mahasiswa (akun staf lewat StaffInvitation)
 type UserRegistrationRequest struct {
 	Username string `json:"username" validate:"required,min=3,max=50"`
 	Nama     string `json:"nama" validate:"required,min=2,max=100"`
 	Email    string `json:"email" validate:"required,email"`
 	Password string `json:"password" validate:"required,min=6"`
-	Role     string `json:"role" validate:"required,oneof=mahasiswa dosen kajur rektor"`
-
-	// Detail fields based on role
-	NIM            string `json:"nim,omitempty" validate:"omitempty,min=8,max=20"`      // For mahasiswa
-	NIDN           string `json:"nidn,omitempty" validate:"omitempty,min=8,max=20"`     // For dosen, kajur, rektor
-	PhoneNumber    string `json:"phone_number,omitempty" validate:"omitempty,min=10,max=15"` // For all roles
-	Jurusan        string `json:"jurusan,omitempty" validate:"omitempty,min=2,max=100"` // For mahasiswa, dosen, kajur
-	Semester       int    `json:"semester,omitempty"`                                   // For mahasiswa
-	StatusAkademik string `json:"status_akademik,omitempty"`                            // For mahasiswa
+	Role     string `json:"role,omitempty" validate:"omitempty,oneof=mahasiswa"`
+
+	NIM            string `json:"nim" validate:"required,min=8,max=20"`
+	PhoneNumber    string `json:"phone_number,omitempty" validate:"omitempty,min=10,max=15"`
+	Jurusan        string `json:"jurusan,omitempty" validate:"omitempty,min=2,max=100"` // Diambil dari whitelist jika diaktifkan
+	Semester       int    `json:"semester,omitempty"`
+	StatusAkademik string `json:"status_akademik,omitempty"`
 }
 
 type UserLoginRequest struct {
 	Identifier string `json:"identifier" validate:"required"` // Could be username, email, nim, or nidn
 	Password   string `json:"password" validate:"required"`
+	DeviceName string `json:"device_name,omitempty" validate:"omitempty,max=100"`
 }
 
 type UserResponse struct {
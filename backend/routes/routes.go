@@ -7,6 +7,9 @@ import (
 	"github.com/gin-gonic/gin"
 )
 
+// teachingRoles - role yang mengajar: kajur dan dekan adalah dosen dengan jabatan tambahan
+var teachingRoles = []string{"dosen", "kajur", "dekan"}
+
 func SetupRoutes(r *gin.Engine) {
 	authController := controllers.NewAuthController()
 	mahasiswaController := controllers.NewMahasiswaController()
@@ -19,6 +22,21 @@ func SetupRoutes(r *gin.Engine) {
 	materiController := controllers.NewMateriController()
 	kajurController := controllers.NewKajurController()
 	rektorController := controllers.NewRektorController()
+	dekanController := controllers.NewDekanController()
+	twoFactorController := controllers.NewTwoFactorController()
+	invitationController := controllers.NewInvitationController()
+	auditController := controllers.NewAuditController()
+	policyController := controllers.NewPolicyController()
+	prerequisiteController := controllers.NewPrerequisiteController()
+	sksRuleController := controllers.NewSKSRuleController()
+	kelasController := controllers.NewKelasController()
+	calendarController := controllers.NewAcademicCalendarController()
+	gradingController := controllers.NewGradingController()
+	gradeScaleController := controllers.NewGradeScaleController()
+	gradeSheetController := controllers.NewGradeSheetController()
+	gradeCorrectionController := controllers.NewGradeCorrectionController()
+	gradeAppealController := controllers.NewGradeAppealController()
+	gradeImportController := controllers.NewGradeImportController()
 
 	r.GET("/", func(c *gin.Context) {
 		c.JSON(200, gin.H{
@@ -30,9 +48,19 @@ func SetupRoutes(r *gin.Engine) {
 	{
 		auth := api.Group("/auth")
 		{
-			// Universal registration and login for all roles
+			// Registrasi mandiri hanya mahasiswa, akun staf lewat kode undangan
 			auth.POST("/register", authController.Register)
+			auth.POST("/register/invitation", invitationController.AcceptInvitation)
 			auth.POST("/login", authController.Login)
+			auth.POST("/login/2fa", twoFactorController.VerifyLogin)
+			auth.POST("/refresh", authController.RefreshToken)
+			auth.POST("/forgot-password", authController.ForgotPassword)
+			auth.POST("/reset-password", authController.ResetPassword)
+
+			// Session management (butuh access token)
+			auth.POST("/logout", middleware.ValidateJWT(), authController.Logout)
+			auth.POST("/logout-all", middleware.ValidateJWT(), authController.LogoutAll)
+			auth.GET("/sessions", middleware.ValidateJWT(), authController.GetSessions)
 		}
 
 		// Public endpoint for WhatsApp bot
@@ -41,11 +69,64 @@ func SetupRoutes(r *gin.Engine) {
 		api.POST("/mahasiswa/unbind-phone", mahasiswaController.UnbindPhoneNumber)
 
 		protected := api.Group("/")
-		protected.Use(middleware.ValidateJWT())
+		protected.Use(middleware.ValidateJWT(), middleware.ResolvePrincipal())
 		{
 			protected.GET("/profile", authController.GetProfile)
 
+			// Pendaftaran 2FA tidak memakai RequireRole supaya role yang wajib 2FA tetap bisa enroll
+			twoFactor := protected.Group("/auth/2fa")
+			{
+				twoFactor.POST("/setup", twoFactorController.Setup)
+				twoFactor.POST("/enable", twoFactorController.Enable)
+				twoFactor.POST("/disable", twoFactorController.Disable)
+				twoFactor.POST("/recovery-codes", twoFactorController.RegenerateRecoveryCodes)
+			}
+
+			invitations := protected.Group("/invitations")
+			invitations.Use(middleware.RequireRole("kajur", "rektor"))
+			{
+				invitations.POST("", invitationController.CreateInvitation)
+				invitations.GET("", invitationController.GetInvitations)
+				invitations.DELETE("/:id", invitationController.RevokeInvitation)
+			}
+
+			nimWhitelist := protected.Group("/nim-whitelist")
+			nimWhitelist.Use(middleware.RequireRole("kajur", "rektor"))
+			{
+				nimWhitelist.POST("", invitationController.ImportNIMWhitelist)
+				nimWhitelist.GET("", invitationController.GetNIMWhitelist)
+			}
+
+			protected.GET("/audit", middleware.RequireRole("kajur", "rektor"), auditController.GetAuditLogs)
+			protected.GET("/academic-calendar", calendarController.GetCalendars)
+
+			// Kebijakan akademik (kajur hanya melihat jurusannya)
+			policies := protected.Group("/policies")
+			policies.Use(middleware.RequireRole("kajur", "rektor"))
+			{
+				policies.GET("", policyController.GetPolicies)
+				policies.GET("/:id", policyController.GetPolicyDetail)
+				policies.GET("/:id/attachments/:attachmentId", policyController.DownloadAttachment)
+			}
+
+			// Aturan batas SKS berdasarkan IPS (kajur hanya jurusannya)
+			sksRules := protected.Group("/sks-rules")
+			sksRules.Use(middleware.RequireRole("kajur", "rektor"))
+			{
+				sksRules.GET("", sksRuleController.GetRules)
+				sksRules.PUT("", sksRuleController.SetRules)
+			}
+
+			// Skala konversi nilai huruf per jurusan/angkatan (versi baru, tidak mengubah nilai lama)
+			gradeScales := protected.Group("/grade-scales")
+			gradeScales.Use(middleware.RequireRole("kajur", "rektor"))
+			{
+				gradeScales.GET("", gradeScaleController.GetScales)
+				gradeScales.POST("", gradeScaleController.CreateScale)
+			}
+
 			mahasiswa := protected.Group("/mahasiswa")
+			mahasiswa.Use(middleware.RequireRole("mahasiswa"))
 			{
 				mahasiswa.GET("", mahasiswaController.GetAllMahasiswa)
 				mahasiswa.GET("/:id", mahasiswaController.GetMahasiswaByID)
@@ -57,47 +138,91 @@ func SetupRoutes(r *gin.Engine) {
 			{
 				courses.GET("", courseController.GetAllCourses)
 				courses.GET("/:id", courseController.GetCourseByID)
-				courses.POST("", courseController.CreateCourse)
-				courses.PUT("/:id", courseController.UpdateCourse)
-				courses.DELETE("/:id", courseController.DeleteCourse)
-				courses.POST("/:id/enroll", courseController.EnrollCourse)
-				courses.DELETE("/:id/enroll", courseController.UnenrollCourse)
+				courses.GET("/:id/prerequisites", prerequisiteController.GetCoursePrerequisites)
+
+				// Perubahan mata kuliah hanya untuk kajur/rektor, enroll hanya mahasiswa
+				courses.POST("", middleware.RequireRole("kajur", "rektor"), courseController.CreateCourse)
+				courses.PUT("/:id", middleware.RequireRole("kajur", "rektor"), courseController.UpdateCourse)
+				courses.DELETE("/:id", middleware.RequireRole("kajur", "rektor"), courseController.DeleteCourse)
+				courses.POST("/:id/enroll", middleware.RequireRole("mahasiswa"), courseController.EnrollCourse)
+				courses.DELETE("/:id/enroll", middleware.RequireRole("mahasiswa"), courseController.UnenrollCourse)
 			}
 
 			krs := protected.Group("/krs")
+			krs.Use(middleware.RequireRole("mahasiswa"))
 			{
 				krs.GET("", krsController.GetMyKRS)
 				krs.POST("", krsController.AddCourseToKRS)
 				krs.DELETE("/:id", krsController.RemoveCourseFromKRS)
+				krs.POST("/submit", krsController.SubmitKRSPackage)
+				krs.POST("/:id/submit", krsController.SubmitKRS)
+				krs.GET("/:id/history", krsController.GetKRSHistory)
 				krs.GET("/available-courses", krsController.GetAvailableCourses)
+				krs.GET("/recommendations", krsController.GetRecommendations)
+				krs.GET("/print", krsController.PrintKRS)
+				krs.GET("/waitlist", kelasController.GetMyWaitlist)
+				krs.DELETE("/waitlist/:id", kelasController.CancelWaitlist)
+			}
+
+			// Detail dan bukti sanggah nilai (mahasiswa pengaju, dosen penanggung jawab, kajur jurusan)
+			gradeAppeals := protected.Group("/grade-appeals")
+			gradeAppeals.Use(middleware.RequireRole("mahasiswa", "dosen", "kajur"))
+			{
+				gradeAppeals.GET("/:id", gradeAppealController.GetAppealDetail)
+				gradeAppeals.GET("/:id/attachments/:attachmentId", gradeAppealController.DownloadEvidence)
 			}
 
 			nilai := protected.Group("/nilai")
+			nilai.Use(middleware.RequireRole("mahasiswa"))
 			{
 				nilai.GET("", nilaiController.GetMyNilai)
 				nilai.GET("/transkrip", nilaiController.GetTranskrip)
 				nilai.GET("/statistik", nilaiController.GetStatistikNilai)
+				nilai.GET("/khs/print", nilaiController.PrintKHS)
+
+				// Sanggah nilai
+				nilai.POST("/:id/appeals", gradeAppealController.OpenAppeal)
+				nilai.GET("/appeals", gradeAppealController.GetMyAppeals)
+				nilai.POST("/appeals/:id/attachments", gradeAppealController.UploadEvidence)
 			}
 
 			jadwal := protected.Group("/jadwal")
+			jadwal.Use(middleware.RequireRole("mahasiswa"))
 			{
 				jadwal.GET("", jadwalController.GetMyJadwal)
 				jadwal.GET("/hari/:hari", jadwalController.GetJadwalByHari)
 				jadwal.GET("/minggu-ini", jadwalController.GetJadwalMingguIni)
 			}
 
-			// Dosen endpoints
+			// Dosen endpoints (kajur dan dekan tetap dosen pengajar)
 			dosen := protected.Group("/dosen")
+			dosen.Use(middleware.RequireRole(teachingRoles...))
 			{
 				// Input nilai mahasiswa
 				dosen.POST("/courses/:courseId/students/:mahasiswaId/nilai", dosenController.InputNilai)
 
+				// Komponen penilaian per mata kuliah/kelas
+				dosen.GET("/courses/:courseId/grading-scheme", gradingController.GetScheme)
+				dosen.PUT("/courses/:courseId/grading-scheme", gradingController.SetScheme)
+
+				// Lembar nilai kelas dan koreksi nilai yang sudah dipublikasikan
+				dosen.GET("/courses/:courseId/grade-sheet", gradeSheetController.GetSheet)
+				dosen.POST("/courses/:courseId/grade-sheet/submit", gradeSheetController.SubmitSheet)
+				dosen.GET("/courses/:courseId/grade-sheet/template", gradeImportController.DownloadTemplate)
+				dosen.POST("/courses/:courseId/grade-sheet/import", gradeImportController.ImportGrades)
+				dosen.POST("/nilai/:nilaiId/corrections", gradeCorrectionController.RequestCorrection)
+				dosen.GET("/grade-corrections", gradeCorrectionController.GetMyCorrections)
+				dosen.GET("/grade-appeals", gradeAppealController.GetAppeals)
+				dosen.PUT("/grade-appeals/:id", gradeAppealController.RespondAppeal)
+
 				// Lihat daftar mahasiswa di kelas
 				dosen.GET("/courses/:courseId/students", dosenController.GetMahasiswaInClass)
 
 				// Approve/Reject KRS
 				dosen.GET("/krs/pending", dosenController.GetPendingKRS)
 				dosen.PUT("/krs/:krsId/approval", dosenController.ProcessKRSApproval)
+				dosen.GET("/krs/students/:mahasiswaId", dosenController.GetKRSPackageReview)
+				dosen.PUT("/krs/students/:mahasiswaId/approval", dosenController.ProcessKRSPackage)
 
 				// Get my courses
 				dosen.GET("/courses", materiController.GetMyCourses)
@@ -105,6 +230,7 @@ func SetupRoutes(r *gin.Engine) {
 
 			// Absensi endpoints
 			absensi := protected.Group("/absensi")
+			absensi.Use(middleware.RequireRole(teachingRoles...))
 			{
 				absensi.POST("/input", absensiController.InputAbsensiPertemuan)
 				absensi.GET("/courses/:courseId", absensiController.GetAbsensiByPertemuan)
@@ -113,6 +239,7 @@ func SetupRoutes(r *gin.Engine) {
 
 			// Materi endpoints
 			materi := protected.Group("/materi")
+			materi.Use(middleware.RequireRole(teachingRoles...))
 			{
 				materi.POST("", materiController.CreateMateri)
 				materi.GET("/courses/:courseId", materiController.GetMateriByCourse)
@@ -122,6 +249,7 @@ func SetupRoutes(r *gin.Engine) {
 
 			// Kajur endpoints
 			kajur := protected.Group("/kajur")
+			kajur.Use(middleware.RequireRole("kajur"))
 			{
 				// Dashboard dan overview
 				kajur.GET("/dashboard", kajurController.GetDashboard)
@@ -136,6 +264,15 @@ func SetupRoutes(r *gin.Engine) {
 				// KRS validation
 				kajur.GET("/krs/pending", kajurController.GetPendingKRSValidation)
 				kajur.PUT("/krs/:krsId/validation", kajurController.ProcessKRSValidation)
+				kajur.POST("/krs/validation/bulk", kajurController.BulkValidateKRS)
+
+				// Verifikasi dan publikasi nilai
+				kajur.GET("/grade-sheets", gradeSheetController.GetPendingSheets)
+				kajur.PUT("/grade-sheets/:id", gradeSheetController.ProcessSheet)
+				kajur.GET("/grade-corrections", gradeCorrectionController.GetCorrections)
+				kajur.PUT("/grade-corrections/:id", gradeCorrectionController.ReviewCorrection)
+				kajur.GET("/grade-appeals", gradeAppealController.GetAppeals)
+				kajur.PUT("/grade-appeals/:id", gradeAppealController.RespondAppeal)
 
 				// Laporan jurusan
 				kajur.GET("/laporan", kajurController.GenerateLaporanJurusan)
@@ -143,10 +280,37 @@ func SetupRoutes(r *gin.Engine) {
 				// Management mata kuliah
 				kajur.GET("/mata-kuliah", kajurController.GetMataKuliahDiJurusan)
 				kajur.PUT("/mata-kuliah/:courseId/status", kajurController.UpdateStatusMataKuliah)
+				kajur.PUT("/mata-kuliah/:courseId/prasyarat", prerequisiteController.SetCoursePrerequisites)
+				kajur.POST("/prasyarat/migrate", prerequisiteController.MigratePrasyarat)
+
+				// Kelas paralel dan daftar tunggu
+				kajur.GET("/kelas", kelasController.GetKelas)
+				kajur.POST("/kelas", kelasController.CreateKelas)
+				kajur.PUT("/kelas/:id", kelasController.UpdateKelas)
+				kajur.GET("/kelas/:id/waitlist", kelasController.GetKelasWaitlist)
+
+				// Override jendela KRS per mahasiswa
+				kajur.GET("/krs-overrides", calendarController.GetOverrides)
+				kajur.POST("/krs-overrides", calendarController.CreateOverride)
+				kajur.DELETE("/krs-overrides/:id", calendarController.RevokeOverride)
+
+				// Pengajuan kebijakan
+				kajur.POST("/policies", policyController.CreatePolicy)
+				kajur.PUT("/policies/:id", policyController.UpdatePolicy)
+				kajur.POST("/policies/:id/attachments", policyController.UploadAttachment)
+				kajur.POST("/policies/:id/submit", policyController.SubmitPolicy)
+			}
+
+			// Dekan endpoints
+			dekan := protected.Group("/dekan")
+			dekan.Use(middleware.RequireRole("dekan"))
+			{
+				dekan.GET("/faculty/report", dekanController.GetFacultyReport)
 			}
 
 			// Rektor endpoints
 			rektor := protected.Group("/rektor")
+			rektor.Use(middleware.RequireRole("rektor"))
 			{
 				// University dashboard
 				rektor.GET("/dashboard", rektorController.GetUniversityDashboard)
@@ -157,10 +321,21 @@ func SetupRoutes(r *gin.Engine) {
 				// Role management
 				rektor.POST("/assign-role", rektorController.AssignRole)
 				rektor.GET("/role-assignments", rektorController.GetRoleAssignments)
+				rektor.POST("/role-assignments/:id/revoke", rektorController.RevokeRoleAssignment)
 
 				// Policy approval
-				rektor.GET("/policies/pending", rektorController.GetPendingPolicies)
-				rektor.PUT("/policies/:id/approval", rektorController.ApprovePolicy)
+				rektor.GET("/policies/pending", policyController.GetPendingPolicies)
+				rektor.PUT("/policies/:id/approval", policyController.ReviewPolicy)
+
+				// Account lockout
+				rektor.POST("/users/:id/unlock", rektorController.UnlockAccount)
+				rektor.GET("/lockouts", rektorController.GetLockoutHistory)
+
+				// Kalender KRS
+				rektor.PUT("/academic-calendar", calendarController.SetCalendar)
+
+				// IPK menurut kebijakan mengulang
+				rektor.POST("/ipk/recalculate", rektorController.RecalculateIPK)
 			}
 		}
 	}
@@ -0,0 +1,317 @@
+package routes
+
+import (
+	"SIAku/config"
+	"SIAku/middleware"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+var testUsers = map[uint]middleware.Principal{
+	1: {UserID: 1, Role: "mahasiswa", Status: "aktif", MahasiswaID: 11},
+	2: {UserID: 2, Role: "dosen", Status: "aktif", DosenID: 21},
+	3: {UserID: 3, Role: "kajur", Status: "aktif", DosenID: 22, KajurID: 31},
+	4: {UserID: 4, Role: "rektor", Status: "aktif", RektorID: 41},
+	5: {UserID: 5, Role: "kajur", Status: "nonaktif", KajurID: 32},
+	6: {UserID: 6, Role: "dosen", Status: "aktif", DosenID: 23},
+	7: {UserID: 7, Role: "dosen", Status: "aktif"},
+	8: {UserID: 8, Role: "rektor", Status: "aktif", RektorID: 42, TwoFactorEnabled: true},
+	9: {UserID: 9, Role: "dekan", Status: "aktif", DosenID: 24},
+}
+
+// Grup yang juga terbuka untuk role lain: kajur dan dekan tetap dosen pengajar
+var sharedRoleGroups = map[string][]string{
+	"dosen": {"kajur", "dekan"},
+}
+
+const revokedSessionID = 999
+
+var roleEndpoints = map[string][][2]string{
+	"mahasiswa": {
+		{"GET", "/api/mahasiswa"},
+		{"PUT", "/api/mahasiswa/1"},
+		{"GET", "/api/krs"},
+		{"POST", "/api/krs"},
+		{"GET", "/api/nilai/transkrip"},
+		{"GET", "/api/nilai/khs/print"},
+		{"GET", "/api/jadwal"},
+		{"GET", "/api/krs/recommendations"},
+		{"GET", "/api/krs/print"},
+		{"GET", "/api/krs/waitlist"},
+		{"DELETE", "/api/krs/waitlist/1"},
+		{"POST", "/api/krs/submit"},
+		{"POST", "/api/krs/1/submit"},
+		{"GET", "/api/krs/1/history"},
+		{"POST", "/api/nilai/1/appeals"},
+		{"GET", "/api/nilai/appeals"},
+		{"POST", "/api/nilai/appeals/1/attachments"},
+		{"POST", "/api/courses/1/enroll"},
+		{"DELETE", "/api/courses/1/enroll"},
+	},
+	"dosen": {
+		{"POST", "/api/dosen/courses/1/students/1/nilai"},
+		{"GET", "/api/dosen/courses/1/grading-scheme"},
+		{"PUT", "/api/dosen/courses/1/grading-scheme"},
+		{"GET", "/api/dosen/courses/1/grade-sheet"},
+		{"POST", "/api/dosen/courses/1/grade-sheet/submit"},
+		{"GET", "/api/dosen/courses/1/grade-sheet/template"},
+		{"POST", "/api/dosen/courses/1/grade-sheet/import"},
+		{"POST", "/api/dosen/nilai/1/corrections"},
+		{"GET", "/api/dosen/grade-corrections"},
+		{"GET", "/api/dosen/grade-appeals"},
+		{"PUT", "/api/dosen/grade-appeals/1"},
+		{"GET", "/api/dosen/krs/pending"},
+		{"PUT", "/api/dosen/krs/1/approval"},
+		{"GET", "/api/dosen/krs/students/1"},
+		{"PUT", "/api/dosen/krs/students/1/approval"},
+		{"POST", "/api/absensi/input"},
+		{"POST", "/api/materi"},
+	},
+	"kajur": {
+		{"GET", "/api/kajur/dashboard"},
+		{"GET", "/api/kajur/mahasiswa"},
+		{"PUT", "/api/kajur/krs/1/validation"},
+		{"POST", "/api/kajur/krs/validation/bulk"},
+		{"GET", "/api/kajur/grade-sheets"},
+		{"PUT", "/api/kajur/grade-sheets/1"},
+		{"GET", "/api/kajur/grade-corrections"},
+		{"PUT", "/api/kajur/grade-corrections/1"},
+		{"GET", "/api/kajur/grade-appeals"},
+		{"PUT", "/api/kajur/grade-appeals/1"},
+		{"PUT", "/api/kajur/mata-kuliah/1/status"},
+		{"POST", "/api/kajur/policies"},
+		{"PUT", "/api/kajur/mata-kuliah/1/prasyarat"},
+		{"POST", "/api/kajur/prasyarat/migrate"},
+		{"POST", "/api/kajur/policies/1/submit"},
+		{"POST", "/api/kajur/kelas"},
+		{"PUT", "/api/kajur/kelas/1"},
+		{"GET", "/api/kajur/kelas/1/waitlist"},
+		{"POST", "/api/kajur/krs-overrides"},
+		{"DELETE", "/api/kajur/krs-overrides/1"},
+	},
+	"dekan": {
+		{"GET", "/api/dekan/faculty/report"},
+	},
+	"rektor": {
+		{"GET", "/api/rektor/dashboard"},
+		{"POST", "/api/rektor/assign-role"},
+		{"PUT", "/api/rektor/policies/1/approval"},
+		{"POST", "/api/rektor/users/1/unlock"},
+		{"PUT", "/api/rektor/academic-calendar"},
+		{"POST", "/api/rektor/ipk/recalculate"},
+	},
+}
+
+// Endpoint undangan, audit dan perubahan mata kuliah hanya untuk kajur dan rektor
+var staffAdminEndpoints = [][2]string{
+	{"POST", "/api/invitations"},
+	{"GET", "/api/invitations"},
+	{"DELETE", "/api/invitations/1"},
+	{"POST", "/api/nim-whitelist"},
+	{"GET", "/api/audit"},
+	{"GET", "/api/policies"},
+	{"GET", "/api/policies/1"},
+	{"GET", "/api/sks-rules"},
+	{"PUT", "/api/sks-rules"},
+	{"GET", "/api/grade-scales"},
+	{"POST", "/api/grade-scales"},
+	{"POST", "/api/courses"},
+	{"PUT", "/api/courses/1"},
+	{"DELETE", "/api/courses/1"},
+}
+
+func setupTestRouter(t *testing.T) *gin.Engine {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	config.AppConfig.JWTSecret = "test-secret"
+	config.AppConfig.AccessTokenTTL = time.Minute
+	config.AppConfig.TwoFactorRoles = nil
+
+	original := middleware.LoadPrincipal
+	middleware.LoadPrincipal = func(userID uint) (*middleware.Principal, error) {
+		principal, ok := testUsers[userID]
+		if !ok {
+			return nil, errors.New("not found")
+		}
+		return &principal, nil
+	}
+	originalSession := middleware.SessionActive
+	middleware.SessionActive = func(sessionID uint) bool {
+		return sessionID != revokedSessionID
+	}
+	t.Cleanup(func() {
+		middleware.LoadPrincipal = original
+		middleware.SessionActive = originalSession
+	})
+
+	r := gin.New()
+	SetupRoutes(r)
+	return r
+}
+
+func doRequest(t *testing.T, r *gin.Engine, method, path string, userID uint, role string) int {
+	t.Helper()
+	return doSessionRequest(t, r, method, path, userID, role, userID)
+}
+
+func doSessionRequest(t *testing.T, r *gin.Engine, method, path string, userID uint, role string, sessionID uint) int {
+	t.Helper()
+	token, err := middleware.GenerateJWT(userID, "user", role, sessionID)
+	if err != nil {
+		t.Fatalf("generate token: %v", err)
+	}
+
+	req := httptest.NewRequest(method, path, nil)
+	req.Header.Set("Authorization", "Bearer "+token)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w.Code
+}
+
+func TestRoleGroupsRejectOtherRoles(t *testing.T) {
+	r := setupTestRouter(t)
+
+	for _, userID := range []uint{1, 2, 3, 4, 9} {
+		user := testUsers[userID]
+		for role, endpoints := range roleEndpoints {
+			if role == user.Role || containsRole(sharedRoleGroups[role], user.Role) {
+				continue
+			}
+			for _, ep := range endpoints {
+				if code := doRequest(t, r, ep[0], ep[1], userID, user.Role); code != http.StatusForbidden {
+					t.Errorf("%s %s as %s: got %d, want %d", ep[0], ep[1], user.Role, code, http.StatusForbidden)
+				}
+			}
+		}
+	}
+}
+
+func containsRole(roles []string, role string) bool {
+	for _, r := range roles {
+		if r == role {
+			return true
+		}
+	}
+	return false
+}
+
+func TestPromotedDosenKeepsTeachingEndpoints(t *testing.T) {
+	r := setupTestRouter(t)
+
+	// Kajur dan dekan yang dipromosikan dari dosen tetap bisa input nilai, absensi dan materi
+	// (body kosong ditolak validasi, bukan RequireRole)
+	endpoints := [][2]string{
+		{"POST", "/api/dosen/courses/1/students/1/nilai"},
+		{"POST", "/api/absensi/input"},
+		{"POST", "/api/materi"},
+	}
+	for _, userID := range []uint{3, 9} {
+		user := testUsers[userID]
+		for _, ep := range endpoints {
+			if code := doRequest(t, r, ep[0], ep[1], userID, user.Role); code != http.StatusBadRequest {
+				t.Errorf("%s %s as %s: got %d, want %d", ep[0], ep[1], user.Role, code, http.StatusBadRequest)
+			}
+		}
+	}
+}
+
+func TestRoleGroupsRecheckUsersTable(t *testing.T) {
+	r := setupTestRouter(t)
+
+	// Akun nonaktif ditolak walaupun token masih valid
+	if code := doRequest(t, r, "GET", "/api/kajur/dashboard", 5, "kajur"); code != http.StatusForbidden {
+		t.Errorf("inactive account: got %d, want %d", code, http.StatusForbidden)
+	}
+
+	// Token lama masih membawa role kajur, tapi Users.Role sudah dosen
+	if code := doRequest(t, r, "GET", "/api/kajur/dashboard", 6, "kajur"); code != http.StatusForbidden {
+		t.Errorf("stale role claim: got %d, want %d", code, http.StatusForbidden)
+	}
+
+	// Akun dosen tanpa profil dosen yang terhubung
+	if code := doRequest(t, r, "GET", "/api/dosen/courses", 7, "dosen"); code != http.StatusForbidden {
+		t.Errorf("missing profile: got %d, want %d", code, http.StatusForbidden)
+	}
+
+	// Akun yang tidak ada di tabel users
+	if code := doRequest(t, r, "GET", "/api/rektor/dashboard", 99, "rektor"); code != http.StatusUnauthorized {
+		t.Errorf("unknown user: got %d, want %d", code, http.StatusUnauthorized)
+	}
+}
+
+func TestRoleGroupsRequireToken(t *testing.T) {
+	r := setupTestRouter(t)
+
+	req := httptest.NewRequest("GET", "/api/kajur/dashboard", nil)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	if w.Code != http.StatusUnauthorized {
+		t.Errorf("missing token: got %d, want %d", w.Code, http.StatusUnauthorized)
+	}
+}
+
+func TestRevokedSessionRejected(t *testing.T) {
+	r := setupTestRouter(t)
+
+	if code := doSessionRequest(t, r, "GET", "/api/rektor/dashboard", 4, "rektor", revokedSessionID); code != http.StatusUnauthorized {
+		t.Errorf("revoked session: got %d, want %d", code, http.StatusUnauthorized)
+	}
+
+	if code := doSessionRequest(t, r, "POST", "/api/auth/logout", 4, "rektor", revokedSessionID); code != http.StatusUnauthorized {
+		t.Errorf("logout with revoked session: got %d, want %d", code, http.StatusUnauthorized)
+	}
+}
+
+func TestTwoFactorPolicyPerRole(t *testing.T) {
+	r := setupTestRouter(t)
+	config.AppConfig.TwoFactorRoles = []string{"kajur", "rektor"}
+
+	// Kajur tanpa 2FA ditolak di grup kajur
+	if code := doRequest(t, r, "GET", "/api/kajur/dashboard", 3, "kajur"); code != http.StatusForbidden {
+		t.Errorf("kajur without 2FA: got %d, want %d", code, http.StatusForbidden)
+	}
+
+	// Rektor dengan 2FA aktif tetap bisa masuk
+	if code := doRequest(t, r, "POST", "/api/rektor/assign-role", 8, "rektor"); code != http.StatusBadRequest {
+		t.Errorf("rektor with 2FA: got %d, want %d", code, http.StatusBadRequest)
+	}
+
+	// Dosen tidak termasuk role wajib 2FA
+	if code := doRequest(t, r, "POST", "/api/absensi/input", 2, "dosen"); code != http.StatusBadRequest {
+		t.Errorf("dosen without 2FA: got %d, want %d", code, http.StatusBadRequest)
+	}
+}
+
+func TestStaffAdminEndpointsRequireKajurOrRektor(t *testing.T) {
+	r := setupTestRouter(t)
+
+	for _, userID := range []uint{1, 2} {
+		user := testUsers[userID]
+		for _, ep := range staffAdminEndpoints {
+			if code := doRequest(t, r, ep[0], ep[1], userID, user.Role); code != http.StatusForbidden {
+				t.Errorf("%s %s as %s: got %d, want %d", ep[0], ep[1], user.Role, code, http.StatusForbidden)
+			}
+		}
+	}
+}
+
+func TestRegisterRejectsStaffRoles(t *testing.T) {
+	r := setupTestRouter(t)
+
+	for _, role := range []string{"dosen", "kajur", "rektor"} {
+		body := `{"username":"staf1","nama":"Staf","email":"staf@siaku.ac.id","password":"rahasia","role":"` + role + `","nim":"12345678","jurusan":"Informatika"}`
+		req := httptest.NewRequest("POST", "/api/auth/register", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("register as %s: got %d, want %d", role, w.Code, http.StatusBadRequest)
+		}
+	}
+}
@@ -0,0 +1,56 @@
+package utils
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+var jamPattern = regexp.MustCompile(`^(\d{1,2})(?:[:.](\d{2}))?(?::(\d{2}))?$`)
+
+// Hari valid untuk jadwal, berurutan Senin sampai Minggu
+var HariOrder = map[string]int{
+	"senin": 1, "selasa": 2, "rabu": 3, "kamis": 4, "jumat": 5, "sabtu": 6, "minggu": 7,
+}
+
+// ParseJam mengubah jam seperti "8:00", "08.00", "08:00:00" atau "8" menjadi menit sejak 00:00
+func ParseJam(value string) (int, error) {
+	match := jamPattern.FindStringSubmatch(strings.TrimSpace(value))
+	if match == nil {
+		return 0, fmt.Errorf("invalid time %q", value)
+	}
+
+	hour, _ := strconv.Atoi(match[1])
+	minute := 0
+	if match[2] != "" {
+		minute, _ = strconv.Atoi(match[2])
+	}
+	if hour > 23 || minute > 59 {
+		return 0, fmt.Errorf("invalid time %q", value)
+	}
+	return hour*60 + minute, nil
+}
+
+// NormalizeJam mengubah jam ke format baku HH:MM
+func NormalizeJam(value string) (string, error) {
+	minutes, err := ParseJam(value)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%02d:%02d", minutes/60, minutes%60), nil
+}
+
+// NormalizeHari mengubah nama hari ke huruf kecil ("Jum'at" menjadi "jumat")
+func NormalizeHari(value string) (string, error) {
+	hari := strings.ReplaceAll(strings.ToLower(strings.TrimSpace(value)), "'", "")
+	if _, ok := HariOrder[hari]; !ok {
+		return "", fmt.Errorf("invalid day %q", value)
+	}
+	return hari, nil
+}
+
+// JamOverlap mengecek apakah rentang [startA, endA) dan [startB, endB) beririsan (dalam menit)
+func JamOverlap(startA, endA, startB, endB int) bool {
+	return startA < endB && startB < endA
+}
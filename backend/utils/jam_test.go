@@ -0,0 +1,58 @@
+package utils
+
+import "testing"
+
+func TestParseJam(t *testing.T) {
+	tests := []struct {
+		value   string
+		want    int
+		wantErr bool
+	}{
+		{value: "08:00", want: 480},
+		{value: "8:00", want: 480},
+		{value: "08.30", want: 510},
+		{value: "13:45:00", want: 825},
+		{value: "8", want: 480},
+		{value: " 07:15 ", want: 435},
+		{value: "00:00", want: 0},
+		{value: "23:59", want: 1439},
+		{value: "24:00", wantErr: true},
+		{value: "12:60", wantErr: true},
+		{value: "8:5", wantErr: true},
+		{value: "jam 8", wantErr: true},
+		{value: "", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			got, err := ParseJam(tt.value)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseJam(%q) err = %v, wantErr %v", tt.value, err, tt.wantErr)
+			}
+			if !tt.wantErr && got != tt.want {
+				t.Errorf("ParseJam(%q) = %d, want %d", tt.value, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestJamOverlap(t *testing.T) {
+	tests := []struct {
+		name                       string
+		startA, endA, startB, endB int
+		want                       bool
+	}{
+		{name: "bersentuhan di akhir", startA: 480, endA: 600, startB: 600, endB: 720, want: false},
+		{name: "bersentuhan di awal", startA: 600, endA: 720, startB: 480, endB: 600, want: false},
+		{name: "irisan satu menit", startA: 480, endA: 601, startB: 600, endB: 720, want: true},
+		{name: "di dalam", startA: 480, endA: 720, startB: 540, endB: 600, want: true},
+		{name: "sama persis", startA: 480, endA: 600, startB: 480, endB: 600, want: true},
+		{name: "terpisah", startA: 480, endA: 540, startB: 600, endB: 660, want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := JamOverlap(tt.startA, tt.endA, tt.startB, tt.endB); got != tt.want {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
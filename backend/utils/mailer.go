@@ -0,0 +1,99 @@
+package utils
+
+import (
+	"SIAku/config"
+	"fmt"
+	"log"
+	"net/smtp"
+	"strings"
+	"sync"
+)
+
+// Mailer - pengirim email yang bisa diganti (SMTP asli atau stand-in lokal)
+type Mailer interface {
+	Send(to, subject, body string) error
+}
+
+// SMTPMailer mengirim email lewat server SMTP dari konfigurasi
+type SMTPMailer struct {
+	Host     string
+	Port     string
+	Username string
+	Password string
+	From     string
+}
+
+func (m *SMTPMailer) Send(to, subject, body string) error {
+	msg := strings.Join([]string{
+		"From: " + m.From,
+		"To: " + to,
+		"Subject: " + subject,
+		"MIME-Version: 1.0",
+		"Content-Type: text/plain; charset=UTF-8",
+		"",
+		body,
+	}, "\r\n")
+
+	var auth smtp.Auth
+	if m.Username != "" {
+		auth = smtp.PlainAuth("", m.Username, m.Password, m.Host)
+	}
+
+	if err := smtp.SendMail(m.Host+":"+m.Port, auth, m.From, []string{to}, []byte(msg)); err != nil {
+		return fmt.Errorf("failed send email: %w", err)
+	}
+	return nil
+}
+
+// MailMessage - email yang ditangkap oleh MemoryMailer
+type MailMessage struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+// MemoryMailer - stand-in SMTP untuk lokal/testing, email hanya disimpan di memori dan di-log
+type MemoryMailer struct {
+	mu       sync.Mutex
+	messages []MailMessage
+}
+
+func (m *MemoryMailer) Send(to, subject, body string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.messages = append(m.messages, MailMessage{To: to, Subject: subject, Body: body})
+	log.Printf("📧 [MemoryMailer] To: %s | Subject: %s", to, subject)
+	return nil
+}
+
+// Messages mengembalikan salinan email yang sudah "terkirim"
+func (m *MemoryMailer) Messages() []MailMessage {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return append([]MailMessage(nil), m.messages...)
+}
+
+var mailer Mailer
+
+// GetMailer mengembalikan mailer aktif; tanpa SMTP_HOST dipakai MemoryMailer
+func GetMailer() Mailer {
+	if mailer == nil {
+		if config.AppConfig.SMTPHost != "" {
+			mailer = &SMTPMailer{
+				Host:     config.AppConfig.SMTPHost,
+				Port:     config.AppConfig.SMTPPort,
+				Username: config.AppConfig.SMTPUsername,
+				Password: config.AppConfig.SMTPPassword,
+				From:     config.AppConfig.SMTPFrom,
+			}
+		} else {
+			mailer = &MemoryMailer{}
+		}
+	}
+	return mailer
+}
+
+// SetMailer mengganti mailer (misalnya dengan MemoryMailer saat testing)
+func SetMailer(m Mailer) {
+	mailer = m
+}
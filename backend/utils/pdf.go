@@ -0,0 +1,206 @@
+package utils
+
+import (
+	"bytes"
+	"compress/zlib"
+	"fmt"
+	"strings"
+)
+
+// PDF minimal murni Go untuk dokumen cetak (KRS, KHS): halaman A4, font standar Helvetica dan garis.
+// Koordinat memakai titik (1/72 inci) dengan titik (0,0) di kiri atas halaman.
+
+const (
+	PDFPageWidth  = 595.28
+	PDFPageHeight = 841.89
+)
+
+// Lebar glyph Helvetica dan Helvetica-Bold (per 1000 unit) untuk karakter 32..126
+var helveticaWidths = [95]int{
+	278, 278, 355, 556, 556, 889, 667, 191, 333, 333, 389, 584, 278, 333, 278, 278,
+	556, 556, 556, 556, 556, 556, 556, 556, 556, 556, 278, 278, 584, 584, 584, 556,
+	1015, 667, 667, 722, 722, 667, 611, 778, 722, 278, 500, 667, 556, 833, 722, 778,
+	667, 778, 722, 667, 611, 722, 667, 944, 667, 667, 611, 278, 278, 278, 469, 556,
+	333, 556, 556, 500, 556, 556, 278, 556, 556, 222, 222, 500, 222, 833, 556, 556,
+	556, 556, 333, 500, 278, 556, 500, 722, 500, 500, 500, 334, 260, 334, 584,
+}
+
+var helveticaBoldWidths = [95]int{
+	278, 333, 474, 556, 556, 889, 722, 238, 333, 333, 389, 584, 278, 333, 278, 278,
+	556, 556, 556, 556, 556, 556, 556, 556, 556, 556, 333, 333, 584, 584, 584, 611,
+	975, 722, 722, 722, 722, 667, 611, 778, 722, 278, 556, 722, 611, 833, 722, 778,
+	667, 778, 722, 667, 611, 722, 667, 944, 667, 667, 611, 333, 278, 333, 584, 556,
+	333, 556, 611, 556, 611, 556, 333, 611, 611, 278, 278, 556, 278, 889, 611, 611,
+	611, 611, 389, 556, 333, 611, 556, 778, 556, 556, 500, 389, 280, 389, 584,
+}
+
+// PDF menampung isi halaman sampai disusun menjadi file oleh Bytes
+type PDF struct {
+	pages   []*bytes.Buffer
+	current *bytes.Buffer
+	title   string
+}
+
+// NewPDF membuat dokumen kosong; panggil AddPage sebelum menggambar
+func NewPDF(title string) *PDF {
+	return &PDF{title: title}
+}
+
+func (p *PDF) AddPage() {
+	p.current = &bytes.Buffer{}
+	p.pages = append(p.pages, p.current)
+}
+
+func (p *PDF) PageCount() int {
+	return len(p.pages)
+}
+
+// TextWidth menghitung lebar teks dalam titik untuk ukuran font tertentu
+func TextWidth(text string, size float64, bold bool) float64 {
+	widths := &helveticaWidths
+	if bold {
+		widths = &helveticaBoldWidths
+	}
+	total := 0
+	for _, b := range pdfEncode(text) {
+		if b >= 32 && b <= 126 {
+			total += widths[b-32]
+		} else {
+			total += 556
+		}
+	}
+	return float64(total) * size / 1000
+}
+
+// Text menulis teks dengan baseline pada (x, y)
+func (p *PDF) Text(x, y, size float64, bold bool, text string) {
+	font := "F1"
+	if bold {
+		font = "F2"
+	}
+	fmt.Fprintf(p.current, "BT /%s %.2f Tf %.2f %.2f Td (%s) Tj ET\n", font, size, x, PDFPageHeight-y, pdfEscape(pdfEncode(text)))
+}
+
+// TextRight menulis teks rata kanan terhadap x
+func (p *PDF) TextRight(x, y, size float64, bold bool, text string) {
+	p.Text(x-TextWidth(text, size, bold), y, size, bold, text)
+}
+
+// TextCenter menulis teks di tengah x
+func (p *PDF) TextCenter(x, y, size float64, bold bool, text string) {
+	p.Text(x-TextWidth(text, size, bold)/2, y, size, bold, text)
+}
+
+// Line menggambar garis dengan tebal width
+func (p *PDF) Line(x1, y1, x2, y2, width float64) {
+	fmt.Fprintf(p.current, "%.2f w %.2f %.2f m %.2f %.2f l S\n", width, x1, PDFPageHeight-y1, x2, PDFPageHeight-y2)
+}
+
+// FillRect menggambar kotak berisi warna abu-abu (gray 0 hitam .. 1 putih)
+func (p *PDF) FillRect(x, y, w, h, gray float64) {
+	fmt.Fprintf(p.current, "q %.2f g %.2f %.2f %.2f %.2f re f Q\n", gray, x, PDFPageHeight-y-h, w, h)
+}
+
+// FitText memotong teks dengan "..." supaya muat dalam lebar tertentu
+func FitText(text string, maxWidth, size float64, bold bool) string {
+	if TextWidth(text, size, bold) <= maxWidth {
+		return text
+	}
+	runes := []rune(text)
+	for len(runes) > 0 && TextWidth(string(runes)+"...", size, bold) > maxWidth {
+		runes = runes[:len(runes)-1]
+	}
+	return string(runes) + "..."
+}
+
+// Bytes menyusun file PDF lengkap beserta tabel xref
+func (p *PDF) Bytes() ([]byte, error) {
+	if len(p.pages) == 0 {
+		p.AddPage()
+	}
+
+	var objects [][]byte
+	add := func(body string) int {
+		objects = append(objects, []byte(body))
+		return len(objects)
+	}
+	addStream := func(dict string, data []byte) (int, error) {
+		var compressed bytes.Buffer
+		w := zlib.NewWriter(&compressed)
+		if _, err := w.Write(data); err != nil {
+			return 0, err
+		}
+		if err := w.Close(); err != nil {
+			return 0, err
+		}
+		body := fmt.Sprintf("<< %s /Filter /FlateDecode /Length %d >>\nstream\n", dict, compressed.Len())
+		objects = append(objects, append(append([]byte(body), compressed.Bytes()...), []byte("\nendstream")...))
+		return len(objects), nil
+	}
+
+	catalog := add("") // diisi setelah pages diketahui
+	pagesObj := add("")
+	regular := add("<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica /Encoding /WinAnsiEncoding >>")
+	bold := add("<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica-Bold /Encoding /WinAnsiEncoding >>")
+
+	kids := make([]string, 0, len(p.pages))
+	for _, page := range p.pages {
+		content, err := addStream("", page.Bytes())
+		if err != nil {
+			return nil, err
+		}
+		pageObj := add(fmt.Sprintf("<< /Type /Page /Parent %d 0 R /MediaBox [0 0 %.2f %.2f] /Resources << /Font << /F1 %d 0 R /F2 %d 0 R >> >> /Contents %d 0 R >>",
+			pagesObj, PDFPageWidth, PDFPageHeight, regular, bold, content))
+		kids = append(kids, fmt.Sprintf("%d 0 R", pageObj))
+	}
+	objects[catalog-1] = []byte(fmt.Sprintf("<< /Type /Catalog /Pages %d 0 R >>", pagesObj))
+	objects[pagesObj-1] = []byte(fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", strings.Join(kids, " "), len(kids)))
+	info := add(fmt.Sprintf("<< /Title (%s) /Producer (SIAku) >>", pdfEscape(pdfEncode(p.title))))
+
+	var out bytes.Buffer
+	out.WriteString("%PDF-1.4\n%\xE2\xE3\xCF\xD3\n")
+	offsets := make([]int, len(objects))
+	for i, obj := range objects {
+		offsets[i] = out.Len()
+		fmt.Fprintf(&out, "%d 0 obj\n", i+1)
+		out.Write(obj)
+		out.WriteString("\nendobj\n")
+	}
+
+	xref := out.Len()
+	fmt.Fprintf(&out, "xref\n0 %d\n0000000000 65535 f \n", len(objects)+1)
+	for _, offset := range offsets {
+		fmt.Fprintf(&out, "%010d 00000 n \n", offset)
+	}
+	fmt.Fprintf(&out, "trailer\n<< /Size %d /Root %d 0 R /Info %d 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(objects)+1, catalog, info, xref)
+	return out.Bytes(), nil
+}
+
+// pdfEncode mengubah teks ke WinAnsi (Latin-1); karakter di luar jangkauan diganti "?"
+func pdfEncode(text string) []byte {
+	out := make([]byte, 0, len(text))
+	for _, r := range text {
+		if r < 256 {
+			out = append(out, byte(r))
+		} else {
+			out = append(out, '?')
+		}
+	}
+	return out
+}
+
+func pdfEscape(b []byte) string {
+	var sb strings.Builder
+	for _, c := range b {
+		switch c {
+		case '(', ')', '\\':
+			sb.WriteByte('\\')
+			sb.WriteByte(c)
+		case '\n', '\r':
+			sb.WriteByte(' ')
+		default:
+			sb.WriteByte(c)
+		}
+	}
+	return sb.String()
+}
@@ -0,0 +1,65 @@
+package utils
+
+import (
+	"regexp"
+	"strings"
+)
+
+// PrasyaratToken - satu prasyarat hasil parsing teks Course.Prasyarat
+type PrasyaratToken struct {
+	Reference string // kode atau nama mata kuliah
+	MinGrade  string // kosong jika teks tidak menyebut nilai minimum
+}
+
+var (
+	prasyaratSeparator   = regexp.MustCompile(`[,;\n&]`)
+	prasyaratConjunction = regexp.MustCompile(`(?i)\s+(?:dan|and)\s+`)
+	prasyaratMinGrade    = regexp.MustCompile(`(?i)\(?\s*(?:nilai\s+)?(?:min(?:imal|imum)?\.?|>=)\s*(AB|BC|[A-E])\s*\)?`)
+	prasyaratNoise       = regexp.MustCompile(`(?i)^(?:telah\s+|sudah\s+)?lulus\s+|^mk\s+`)
+	prasyaratEmpty       = map[string]bool{"": true, "-": true, "tidak ada": true, "tanpa prasyarat": true, "none": true}
+)
+
+// ParsePrasyarat memecah teks prasyarat bebas seperti "IF101 min C, Algoritma 1 (minimal B)" menjadi token.
+// known dipakai supaya nama mata kuliah yang mengandung "dan" (mis. "Algoritma dan Struktur Data") tidak ikut dipecah.
+func ParsePrasyarat(text string, known func(reference string) bool) []PrasyaratToken {
+	if prasyaratEmpty[strings.ToLower(strings.TrimSpace(text))] {
+		return nil
+	}
+
+	var tokens []PrasyaratToken
+	for _, segment := range prasyaratSeparator.Split(text, -1) {
+		whole := parsePrasyaratPart(segment)
+		if whole.Reference == "" {
+			continue
+		}
+		if known != nil && known(whole.Reference) {
+			tokens = append(tokens, whole)
+			continue
+		}
+
+		for _, part := range prasyaratConjunction.Split(segment, -1) {
+			token := parsePrasyaratPart(part)
+			if token.Reference == "" {
+				continue
+			}
+			// "A dan B min C" - nilai minimum di akhir berlaku untuk semua bagian
+			if token.MinGrade == "" {
+				token.MinGrade = whole.MinGrade
+			}
+			tokens = append(tokens, token)
+		}
+	}
+	return tokens
+}
+
+func parsePrasyaratPart(part string) PrasyaratToken {
+	token := PrasyaratToken{}
+	if match := prasyaratMinGrade.FindStringSubmatch(part); match != nil {
+		token.MinGrade = strings.ToUpper(match[1])
+		part = prasyaratMinGrade.ReplaceAllString(part, "")
+	}
+
+	part = prasyaratNoise.ReplaceAllString(strings.TrimSpace(part), "")
+	token.Reference = strings.Trim(part, " .:-()")
+	return token
+}
@@ -12,6 +12,12 @@ var validate *validator.Validate
 
 func init() {
 	validate = validator.New()
+
+	// jam: format jam yang bisa dinormalisasi ke HH:MM (mis. "8:00", "08.00")
+	validate.RegisterValidation("jam", func(fl validator.FieldLevel) bool {
+		_, err := ParseJam(fl.Field().String())
+		return err == nil
+	})
 }
 
 // ValidateStruct melakukan validasi terhadap struct
@@ -50,6 +56,8 @@ func getValidationMessage(e validator.FieldError) string {
 		return e.Field() + " must be a valid email"
 	case "numeric":
 		return e.Field() + " must be numeric"
+	case "jam":
+		return e.Field() + " must be a valid time (HH:MM)"
 	default:
 		return e.Field() + " is invalid"
 	}
@@ -77,6 +85,15 @@ func ErrorResponse(c *gin.Context, statusCode int, message string) {
 	})
 }
 
+// ErrorResponseWithData mengirim error beserta data pendukung (mis. hasil validasi per baris)
+func ErrorResponseWithData(c *gin.Context, statusCode int, message string, data interface{}) {
+	c.JSON(statusCode, gin.H{
+		"success": false,
+		"error":   message,
+		"data":    data,
+	})
+}
+
 // GetPageParam gets page parameter from query string
 func GetPageParam(c *gin.Context) int {
 	page := 1
@@ -0,0 +1,42 @@
+package utils
+
+import (
+	"SIAku/config"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// SendWhatsAppMessage mengirim pesan lewat WhatsApp Bot Service (POST /api/wa/send)
+func SendWhatsAppMessage(phoneNumber, message string) error {
+	whatsappURL := config.AppConfig.WhatsAppServiceURL
+	if whatsappURL == "" {
+		whatsappURL = "http://localhost:3000" // fallback
+	}
+
+	payload, err := json.Marshal(map[string]string{
+		"phone_number": phoneNumber,
+		"message":      message,
+	})
+	if err != nil {
+		return err
+	}
+
+	client := &http.Client{
+		Timeout: 10 * time.Second,
+	}
+
+	resp, err := client.Post(whatsappURL+"/api/wa/send", "application/json", bytes.NewReader(payload))
+	if err != nil {
+		return fmt.Errorf("whatsapp service unreachable: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("whatsapp service returned status %d", resp.StatusCode)
+	}
+
+	return nil
+}
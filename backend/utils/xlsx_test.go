@@ -0,0 +1,150 @@
+package utils
+
+import (
+	"archive/zip"
+	"bytes"
+	"compress/flate"
+	"hash/crc32"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestXLSXRoundTrip(t *testing.T) {
+	tests := []struct {
+		name string
+		rows [][]interface{}
+		want [][]string
+	}{
+		{
+			name: "teks dan angka",
+			rows: [][]interface{}{{"NIM", "Nama", "uts"}, {"0012", "Ani & Budi", 80.5}, {"0013", "Citra", 75}},
+			want: [][]string{{"NIM", "Nama", "uts"}, {"0012", "Ani & Budi", "80.5"}, {"0013", "Citra", "75"}},
+		},
+		{
+			name: "sel kosong di tengah",
+			rows: [][]interface{}{{"NIM", nil, "uas"}, {"0014", nil, nil}},
+			want: [][]string{{"NIM", "", "uas"}, {"0014"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data, err := WriteXLSX("Nilai", tt.rows)
+			if err != nil {
+				t.Fatalf("WriteXLSX: %v", err)
+			}
+			got, err := ReadXLSX(data)
+			if err != nil {
+				t.Fatalf("ReadXLSX: %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestReadXLSXSharedStrings(t *testing.T) {
+	data := buildXLSX(t, map[string]string{
+		"xl/sharedStrings.xml": `<sst><si><t>NIM</t></si><si><r><t>Ka</t></r><r><t>kode</t></r></si></sst>`,
+		"xl/worksheets/sheet1.xml": `<worksheet><sheetData>` +
+			`<row r="1"><c r="A1" t="s"><v>0</v></c><c r="C1" t="s"><v>1</v></c></row>` +
+			`<row r="3"><c r="B3"><v>90</v></c></row></sheetData></worksheet>`,
+	}, nil)
+
+	got, err := ReadXLSX(data)
+	if err != nil {
+		t.Fatalf("ReadXLSX: %v", err)
+	}
+	want := [][]string{{"NIM", "", "Kakode"}, nil, {"", "90"}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestReadXLSXRejectsOversizedParts(t *testing.T) {
+	// Worksheet berisi spasi di atas batas: terkompresi hanya beberapa KB
+	huge := `<worksheet><sheetData>` + strings.Repeat(" ", xlsxMaxPartSize+1) + `</sheetData></worksheet>`
+
+	tests := []struct {
+		name     string
+		fakeSize bool   // ukuran tak terkompresi di header zip dipalsukan kecil
+		wantErr  string // archive/zip sendiri menolak isi yang melebihi ukuran header
+	}{
+		{name: "ukuran header jujur", wantErr: "terlalu besar"},
+		{name: "ukuran header dipalsukan", fakeSize: true, wantErr: "not a valid zip"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var fake map[string]bool
+			if tt.fakeSize {
+				fake = map[string]bool{"xl/worksheets/sheet1.xml": true}
+			}
+			data := buildXLSX(t, map[string]string{"xl/worksheets/sheet1.xml": huge}, fake)
+			if _, err := ReadXLSX(data); err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("got err %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestReadXLSXRejectsCellsOutOfRange(t *testing.T) {
+	tests := map[string]string{
+		"baris":  `<worksheet><sheetData><row r="10001"><c r="A10001"><v>1</v></c></row></sheetData></worksheet>`,
+		"kolom":  `<worksheet><sheetData><row r="1"><c r="ZZZ1"><v>1</v></c></row></sheetData></worksheet>`,
+		"shared": `<worksheet><sheetData><row r="1"><c r="A1" t="s"><v>5</v></c></row></sheetData></worksheet>`,
+	}
+	for name, sheet := range tests {
+		t.Run(name, func(t *testing.T) {
+			data := buildXLSX(t, map[string]string{"xl/worksheets/sheet1.xml": sheet}, nil)
+			if _, err := ReadXLSX(data); err == nil {
+				t.Error("expected error")
+			}
+		})
+	}
+}
+
+// buildXLSX menyusun zip dengan part yang diberikan; part di fakeSize ditulis mentah dengan ukuran header 1 byte
+func buildXLSX(t *testing.T, parts map[string]string, fakeSize map[string]bool) []byte {
+	t.Helper()
+	var out bytes.Buffer
+	zw := zip.NewWriter(&out)
+	for name, body := range parts {
+		if !fakeSize[name] {
+			w, err := zw.Create(name)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if _, err := w.Write([]byte(body)); err != nil {
+				t.Fatal(err)
+			}
+			continue
+		}
+
+		var compressed bytes.Buffer
+		fw, err := flate.NewWriter(&compressed, flate.BestSpeed)
+		if err != nil {
+			t.Fatal(err)
+		}
+		fw.Write([]byte(body))
+		fw.Close()
+		w, err := zw.CreateRaw(&zip.FileHeader{
+			Name:               name,
+			Method:             zip.Deflate,
+			CRC32:              crc32.ChecksumIEEE([]byte(body)),
+			CompressedSize64:   uint64(compressed.Len()),
+			UncompressedSize64: 1,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := w.Write(compressed.Bytes()); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return out.Bytes()
+}